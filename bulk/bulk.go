@@ -0,0 +1,246 @@
+// Package bulk читает и пишет списки кошельков в форматах CSV и JSON Lines
+// для импорта из внешних систем и сверки.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+var csvHeader = []string{"walletId", "balance"}
+
+type Importer interface {
+	ImportWallets(records []datastorage.WalletRecord) error
+}
+
+// LineError - ошибка в строке файла импорта (строки нумеруются с 1)
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+func ValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatJSONL
+}
+
+// Read разбирает и проверяет все строки файла. Возвращает записи вместе с номерами
+// их строк и ошибки по строкам; при ошибках записи импортировать нельзя.
+func Read(r io.Reader, format string) ([]datastorage.WalletRecord, []int, []LineError) {
+	var records []datastorage.WalletRecord
+	var lines []int
+	var lineErrors []LineError
+
+	switch format {
+	case FormatCSV:
+		records, lines, lineErrors = readCSV(r)
+	case FormatJSONL:
+		records, lines, lineErrors = readJSONL(r)
+	default:
+		return nil, nil, []LineError{{Line: 0, Error: "unknown format: " + format}}
+	}
+
+	if len(records) == 0 && len(lineErrors) == 0 {
+		lineErrors = append(lineErrors, LineError{Line: 1, Error: "file has no wallets"})
+	}
+
+	return records, lines, lineErrors
+}
+
+func readCSV(r io.Reader) ([]datastorage.WalletRecord, []int, []LineError) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var records []datastorage.WalletRecord
+	var lines []int
+	var lineErrors []LineError
+
+	header := true
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			line := 0
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.Line
+			}
+			return nil, nil, append(lineErrors, LineError{Line: line, Error: err.Error()})
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		if header {
+			header = false
+			if len(row) != len(csvHeader) || row[0] != csvHeader[0] || row[1] != csvHeader[1] {
+				lineErrors = append(lineErrors, LineError{Line: line, Error: "header must be walletId,balance"})
+			}
+			continue
+		}
+
+		if len(row) != 2 {
+			lineErrors = append(lineErrors, LineError{Line: line, Error: "row must contain walletId and balance"})
+			continue
+		}
+
+		balance, err := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+		if err != nil {
+			lineErrors = append(lineErrors, LineError{Line: line, Error: "wrong balance: " + row[1]})
+			continue
+		}
+
+		records = append(records, datastorage.WalletRecord{WalletId: strings.TrimSpace(row[0]), Balance: balance})
+		lines = append(lines, line)
+	}
+
+	return records, lines, append(lineErrors, validate(records, lines)...)
+}
+
+func readJSONL(r io.Reader) ([]datastorage.WalletRecord, []int, []LineError) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var records []datastorage.WalletRecord
+	var lines []int
+	var lineErrors []LineError
+
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var record datastorage.WalletRecord
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+
+		if err := decoder.Decode(&record); err != nil {
+			lineErrors = append(lineErrors, LineError{Line: line, Error: err.Error()})
+			continue
+		}
+
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, append(lineErrors, LineError{Line: line + 1, Error: err.Error()})
+	}
+
+	return records, lines, append(lineErrors, validate(records, lines)...)
+}
+
+func validate(records []datastorage.WalletRecord, lines []int) []LineError {
+	var lineErrors []LineError
+	seen := map[string]int{}
+
+	for i, record := range records {
+		switch {
+		case record.WalletId == "":
+			lineErrors = append(lineErrors, LineError{Line: lines[i], Error: "walletId is empty"})
+		case math.IsNaN(record.Balance) || math.IsInf(record.Balance, 0) || record.Balance < 0:
+			lineErrors = append(lineErrors, LineError{Line: lines[i], Error: "balance must not be less 0"})
+		case seen[record.WalletId] != 0:
+			lineErrors = append(lineErrors, LineError{
+				Line:  lines[i],
+				Error: fmt.Sprintf("walletId %s is duplicated, first seen on line %d", record.WalletId, seen[record.WalletId]),
+			})
+		default:
+			seen[record.WalletId] = lines[i]
+		}
+	}
+
+	return lineErrors
+}
+
+// Import проверяет файл и, если ошибок нет, создаёт все кошельки из него.
+// Кошельки, которые уже существуют, возвращаются как ошибки их строк.
+func Import(ds Importer, r io.Reader, format string) (int, []LineError, error) {
+	records, lines, lineErrors := Read(r, format)
+
+	if len(lineErrors) > 0 {
+		return 0, lineErrors, nil
+	}
+
+	err := ds.ImportWallets(records)
+
+	var existErr datastorage.WalletsExist
+	if errors.As(err, &existErr) {
+		exist := map[string]bool{}
+		for _, id := range existErr.Ids {
+			exist[id] = true
+		}
+
+		for i, record := range records {
+			if exist[record.WalletId] {
+				lineErrors = append(lineErrors, LineError{Line: lines[i], Error: "wallet " + record.WalletId + " already exists"})
+			}
+		}
+
+		return 0, lineErrors, nil
+	}
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return len(records), nil, nil
+}
+
+// Writer пишет записи экспорта в выбранном формате
+type Writer struct {
+	format string
+	csv    *csv.Writer
+	json   *json.Encoder
+}
+
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	switch format {
+	case FormatCSV:
+		writer := &Writer{format: format, csv: csv.NewWriter(w)}
+		return writer, writer.csv.Write(csvHeader)
+	case FormatJSONL:
+		return &Writer{format: format, json: json.NewEncoder(w)}, nil
+	}
+
+	return nil, errors.New("unknown format: " + format)
+}
+
+func (writer *Writer) Write(record datastorage.WalletRecord) error {
+	if writer.format == FormatJSONL {
+		return writer.json.Encode(record)
+	}
+
+	return writer.csv.Write([]string{record.WalletId, strconv.FormatFloat(record.Balance, 'f', -1, 64)})
+}
+
+func (writer *Writer) Flush() error {
+	if writer.format == FormatJSONL {
+		return nil
+	}
+
+	writer.csv.Flush()
+	return writer.csv.Error()
+}
@@ -0,0 +1,112 @@
+package bulk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeImporter struct {
+	records []datastorage.WalletRecord
+	err     error
+}
+
+func (f *fakeImporter) ImportWallets(records []datastorage.WalletRecord) error {
+	f.records = records
+	return f.err
+}
+
+func TestReadCSV(t *testing.T) {
+	records, lines, lineErrors := Read(strings.NewReader("walletId,balance\na1,10.5\n\na2, 0\n"), FormatCSV)
+
+	assert.Empty(t, lineErrors)
+	assert.Equal(t, []datastorage.WalletRecord{{WalletId: "a1", Balance: 10.5}, {WalletId: "a2", Balance: 0}}, records)
+	assert.Equal(t, []int{2, 4}, lines)
+}
+
+func TestReadCSVWrongLines(t *testing.T) {
+	_, _, lineErrors := Read(strings.NewReader("walletId,balance\na1,abc\n,5\na2,-1\na3,1,2\na4,1\na4,2\n"), FormatCSV)
+
+	assert.Equal(t, []LineError{
+		{Line: 2, Error: "wrong balance: abc"},
+		{Line: 5, Error: "row must contain walletId and balance"},
+		{Line: 3, Error: "walletId is empty"},
+		{Line: 4, Error: "balance must not be less 0"},
+		{Line: 7, Error: "walletId a4 is duplicated, first seen on line 6"},
+	}, lineErrors)
+}
+
+func TestReadCSVWrongHeader(t *testing.T) {
+	_, _, lineErrors := Read(strings.NewReader("id,sum\na1,1\n"), FormatCSV)
+
+	assert.Equal(t, []LineError{{Line: 1, Error: "header must be walletId,balance"}}, lineErrors)
+}
+
+func TestReadJSONL(t *testing.T) {
+	input := `{"walletId":"a1","balance":3}
+
+{"walletId":"a2","balance":4.25}
+{"walletId":"a3","sum":1}
+`
+	records, lines, lineErrors := Read(strings.NewReader(input), FormatJSONL)
+
+	assert.Equal(t, []datastorage.WalletRecord{{WalletId: "a1", Balance: 3}, {WalletId: "a2", Balance: 4.25}}, records)
+	assert.Equal(t, []int{1, 3}, lines)
+	assert.Equal(t, []LineError{{Line: 4, Error: `json: unknown field "sum"`}}, lineErrors)
+}
+
+func TestReadEmpty(t *testing.T) {
+	_, _, lineErrors := Read(strings.NewReader("walletId,balance\n"), FormatCSV)
+
+	assert.Equal(t, []LineError{{Line: 1, Error: "file has no wallets"}}, lineErrors)
+}
+
+func TestImportExistingWallets(t *testing.T) {
+	ds := &fakeImporter{err: datastorage.WalletsExist{Ids: []string{"a2"}}}
+
+	imported, lineErrors, err := Import(ds, strings.NewReader("walletId,balance\na1,1\na2,2\n"), FormatCSV)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, imported)
+	assert.Equal(t, []LineError{{Line: 3, Error: "wallet a2 already exists"}}, lineErrors)
+}
+
+func TestImportDoesNotCallStorageOnErrors(t *testing.T) {
+	ds := &fakeImporter{}
+
+	_, lineErrors, err := Import(ds, strings.NewReader("walletId,balance\na1,-1\n"), FormatCSV)
+
+	assert.NoError(t, err)
+	assert.Len(t, lineErrors, 1)
+	assert.Nil(t, ds.records)
+}
+
+func TestWriter(t *testing.T) {
+	records := []datastorage.WalletRecord{{WalletId: "a1", Balance: 10.5}, {WalletId: "a2", Balance: 0}}
+
+	for format, expected := range map[string]string{
+		FormatCSV:   "walletId,balance\na1,10.5\na2,0\n",
+		FormatJSONL: "{\"walletId\":\"a1\",\"balance\":10.5}\n{\"walletId\":\"a2\",\"balance\":0}\n",
+	} {
+		var out bytes.Buffer
+
+		writer, err := NewWriter(&out, format)
+		assert.NoError(t, err)
+
+		for _, record := range records {
+			assert.NoError(t, writer.Write(record))
+		}
+		assert.NoError(t, writer.Flush())
+
+		assert.Equal(t, expected, out.String())
+
+		// экспорт читается обратно импортом
+		read, _, lineErrors := Read(&out, format)
+		assert.Empty(t, lineErrors)
+		assert.Equal(t, records, read)
+	}
+}
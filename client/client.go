@@ -0,0 +1,334 @@
+// Package client - Go клиент REST API кошельков.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout    = 5 * time.Second
+	defaultRetries    = 2
+	defaultRetryDelay = 100 * time.Millisecond
+)
+
+// Wallet - кошелёк в ответе GET /api/v1/wallets/{id} с Accept: application/json
+type Wallet struct {
+	Id              string            `json:"walletId"`
+	Balance         float64           `json:"balance"`
+	CreditLimit     float64           `json:"creditLimit"`
+	AvailableCredit float64           `json:"availableCredit"`
+	Status          string            `json:"status"`
+	Tier            string            `json:"tier"`
+	Owner           string            `json:"owner,omitempty"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	Version         int64             `json:"version"`
+}
+
+// Event - событие из истории кошелька
+type Event struct {
+	Id        int64     `json:"id"`
+	WalletId  string    `json:"walletId"`
+	Type      string    `json:"type"`
+	Amount    float64   `json:"amount"`
+	Balance   float64   `json:"balance"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WalletRecord - кошелёк и баланс из выгрузки всех кошельков
+type WalletRecord struct {
+	WalletId string  `json:"walletId"`
+	Balance  float64 `json:"balance"`
+}
+
+type operationMessage struct {
+	WalletId      string  `json:"walletId"`
+	OperationType string  `json:"operationType"`
+	Amount        float64 `json:"amount"`
+	ToWalletId    string  `json:"toWalletId,omitempty"`
+}
+
+// Client обращается к серверу кошельков. Неудачные из-за сети или перегрузки
+// запросы повторяются; изменяющие запросы отправляются с одним Idempotency-Key
+// на все попытки, поэтому повтор не проводит операцию дважды.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	adminToken string
+	actor      string
+	retries    int
+	retryDelay time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient задаёт свой http.Client (например, с другим транспортом)
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithTimeout задаёт таймаут одной попытки запроса (по умолчанию 5 секунд)
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) { c.httpClient.Timeout = timeout }
+}
+
+// WithRetries задаёт число повторов и задержку перед первым повтором (дальше она удваивается)
+func WithRetries(retries int, delay time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.retryDelay = delay
+	}
+}
+
+// WithAdminToken задаёт ADMIN_TOKEN для административных запросов
+func WithAdminToken(token string) Option {
+	return func(c *Client) { c.adminToken = token }
+}
+
+// WithActor задаёт имя администратора для истории изменений (заголовок X-Actor)
+func WithActor(actor string) Option {
+	return func(c *Client) { c.actor = actor }
+}
+
+// New создаёт клиента для сервера по адресу baseURL, например http://localhost:80
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		retries:    defaultRetries,
+		retryDelay: defaultRetryDelay,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// GetBalance возвращает баланс кошелька
+func (c *Client) GetBalance(ctx context.Context, walletId string) (float64, error) {
+	var body string
+	err := c.do(ctx, http.MethodGet, "/api/v1/wallets/"+url.PathEscape(walletId), nil, "", &body)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(strings.TrimSpace(body), 64)
+}
+
+// GetWallet возвращает баланс вместе с кредитным лимитом, статусом и тарифом
+func (c *Client) GetWallet(ctx context.Context, walletId string) (Wallet, error) {
+	var wallet Wallet
+	err := c.do(ctx, http.MethodGet, "/api/v1/wallets/"+url.PathEscape(walletId), nil, "application/json", &wallet)
+	return wallet, err
+}
+
+// BalanceAt возвращает баланс кошелька на момент at
+func (c *Client) BalanceAt(ctx context.Context, walletId string, at time.Time) (float64, error) {
+	var body string
+	err := c.do(ctx, http.MethodGet, "/api/v1/wallets/"+url.PathEscape(walletId)+"?at="+url.QueryEscape(at.Format(time.RFC3339Nano)), nil, "", &body)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(strings.TrimSpace(body), 64)
+}
+
+func (c *Client) CreateWallet(ctx context.Context, walletId string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/wallets/wallet/create", map[string]string{"walletId": walletId}, "", nil)
+}
+
+func (c *Client) Deposit(ctx context.Context, walletId string, amount float64) error {
+	return c.operation(ctx, operationMessage{WalletId: walletId, OperationType: "DEPOSIT", Amount: amount})
+}
+
+func (c *Client) Withdraw(ctx context.Context, walletId string, amount float64) error {
+	return c.operation(ctx, operationMessage{WalletId: walletId, OperationType: "WITHDRAW", Amount: amount})
+}
+
+func (c *Client) Transfer(ctx context.Context, from, to string, amount float64) error {
+	return c.operation(ctx, operationMessage{WalletId: from, OperationType: "TRANSFER", Amount: amount, ToWalletId: to})
+}
+
+func (c *Client) operation(ctx context.Context, msg operationMessage) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/wallets/wallet", msg, "", nil)
+}
+
+// History возвращает до limit событий кошелька с id больше after (limit 0 - по умолчанию сервера)
+func (c *Client) History(ctx context.Context, walletId string, after int64, limit int) ([]Event, error) {
+	query := url.Values{}
+	if after > 0 {
+		query.Set("after", strconv.FormatInt(after, 10))
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	path := "/api/v1/wallets/" + url.PathEscape(walletId) + "/history"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var events []Event
+	err := c.do(ctx, http.MethodGet, path, nil, "application/json", &events)
+	return events, err
+}
+
+type changeStatusMessage struct {
+	Reason        string `json:"reason"`
+	BlockDeposits bool   `json:"blockDeposits"`
+}
+
+// Freeze замораживает кошелёк (нужен WithAdminToken). С blockDeposits запрещены и пополнения.
+func (c *Client) Freeze(ctx context.Context, walletId, reason string, blockDeposits bool) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/wallets/"+url.PathEscape(walletId)+"/freeze",
+		changeStatusMessage{Reason: reason, BlockDeposits: blockDeposits}, "", nil)
+}
+
+// Unfreeze размораживает кошелёк (нужен WithAdminToken)
+func (c *Client) Unfreeze(ctx context.Context, walletId, reason string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/wallets/"+url.PathEscape(walletId)+"/unfreeze",
+		changeStatusMessage{Reason: reason}, "", nil)
+}
+
+// ListWallets возвращает все кошельки с балансами (нужен WithAdminToken)
+func (c *Client) ListWallets(ctx context.Context) ([]WalletRecord, error) {
+	var body string
+	err := c.do(ctx, http.MethodGet, "/api/v1/wallets/export?format=jsonl", nil, "", &body)
+	if err != nil {
+		return nil, err
+	}
+
+	var wallets []WalletRecord
+	decoder := json.NewDecoder(strings.NewReader(body))
+	for decoder.More() {
+		var wallet WalletRecord
+		if err := decoder.Decode(&wallet); err != nil {
+			return nil, fmt.Errorf("wallet api: wrong response: %w", err)
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	return wallets, nil
+}
+
+// do выполняет запрос с повторами. out - *string для текстового ответа или значение для JSON.
+func (c *Client) do(ctx context.Context, method, path string, in any, accept string, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+
+	var key string
+	if method != http.MethodGet {
+		key = newIdempotencyKey()
+	}
+
+	delay := c.retryDelay
+
+	for attempt := 0; ; attempt++ {
+		err := c.attempt(ctx, method, path, body, key, accept, out)
+
+		if err == nil || attempt >= c.retries || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, path string, body []byte, key, accept string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	if c.adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+	if c.actor != "" {
+		req.Header.Set("X-Actor", c.actor)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		return parseError(resp.StatusCode, string(data))
+	}
+
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *string:
+		*out = string(data)
+		return nil
+	default:
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("wallet api: wrong response: %w", err)
+		}
+		return nil
+	}
+}
+
+// retryable - можно ли повторить запрос: сетевые ошибки, перегрузка и ещё не завершённый
+// запрос с тем же Idempotency-Key
+func retryable(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		var urlErr *url.Error
+		return errors.As(err, &urlErr)
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return apiErr.Code == CodeIdempotencyInProgress
+}
+
+func newIdempotencyKey() string {
+	key := make([]byte, 16)
+	rand.Read(key)
+	return hex.EncodeToString(key)
+}
@@ -0,0 +1,229 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return New(srv.URL+"/", append([]Option{WithRetries(2, time.Millisecond)}, opts...)...)
+}
+
+func TestGetBalance(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/wallets/asd1", r.URL.Path)
+		fmt.Fprintln(w, 100.5)
+	})
+
+	balance, err := c.GetBalance(context.Background(), "asd1")
+	require.NoError(t, err)
+	assert.Equal(t, 100.5, balance)
+}
+
+func TestBalanceAt(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/wallets/asd1", r.URL.Path)
+		assert.Equal(t, "2026-10-01T00:00:00+03:00", r.URL.Query().Get("at"))
+		fmt.Fprintln(w, 42.1)
+	})
+
+	at := time.Date(2026, 10, 1, 0, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+	balance, err := c.BalanceAt(context.Background(), "asd1", at)
+	require.NoError(t, err)
+	assert.Equal(t, 42.1, balance)
+}
+
+func TestGetWallet(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		fmt.Fprintln(w, `{"walletId":"asd1","balance":10,"creditLimit":5,"availableCredit":5,"status":"active","tier":"default"}`)
+	})
+
+	wallet, err := c.GetWallet(context.Background(), "asd1")
+	require.NoError(t, err)
+	assert.Equal(t, Wallet{Id: "asd1", Balance: 10, CreditLimit: 5, AvailableCredit: 5, Status: "active", Tier: "default"}, wallet)
+}
+
+func TestOperations(t *testing.T) {
+	var got []operationMessage
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/wallets/wallet", r.URL.Path)
+		assert.Len(t, r.Header.Get("Idempotency-Key"), 32)
+
+		var msg operationMessage
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		got = append(got, msg)
+
+		fmt.Fprintln(w, "Operation complit")
+	})
+
+	ctx := context.Background()
+	require.NoError(t, c.Deposit(ctx, "asd1", 100))
+	require.NoError(t, c.Withdraw(ctx, "asd1", 50))
+	require.NoError(t, c.Transfer(ctx, "asd1", "asd2", 25))
+
+	assert.Equal(t, []operationMessage{
+		{WalletId: "asd1", OperationType: "DEPOSIT", Amount: 100},
+		{WalletId: "asd1", OperationType: "WITHDRAW", Amount: 50},
+		{WalletId: "asd1", OperationType: "TRANSFER", Amount: 25, ToWalletId: "asd2"},
+	}, got)
+}
+
+func TestRetryKeepsIdempotencyKey(t *testing.T) {
+	var keys []string
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+
+		switch len(keys) {
+		case 1:
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		case 2:
+			http.Error(w, "IDEMPOTENCY_IN_PROGRESS: request with this key is in progress", http.StatusConflict)
+		default:
+			fmt.Fprintln(w, "Wallet created")
+		}
+	})
+
+	require.NoError(t, c.CreateWallet(context.Background(), "asd1"))
+
+	require.Len(t, keys, 3)
+	assert.Equal(t, keys[0], keys[1])
+	assert.Equal(t, keys[0], keys[2])
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	calls := 0
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	})
+
+	_, err := c.GetBalance(context.Background(), "asd1")
+
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, 3, calls)
+}
+
+func TestTypedErrors(t *testing.T) {
+	responses := map[string]struct {
+		status int
+		body   string
+	}{
+		"asd1": {http.StatusBadRequest, "INSUFFICIENT_FUNDS: insufficient funds"},
+		"asd2": {http.StatusBadRequest, "LIMIT_EXCEEDED: daily_withdrawal limit 150 exceeded"},
+		"asd3": {http.StatusBadRequest, "WALLET_NOT_ACTIVE: wallet is frozen"},
+		"asd4": {http.StatusBadRequest, "UUID is wrong"},
+		"asd5": {http.StatusBadRequest, "balance small for Withdraw"},
+		"asd6": {http.StatusInternalServerError, "error in data base"},
+	}
+
+	calls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var msg operationMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		res := responses[msg.WalletId]
+		http.Error(w, res.body, res.status)
+	})
+
+	ctx := context.Background()
+
+	assert.ErrorIs(t, c.Withdraw(ctx, "asd1", 1), ErrInsufficientFunds)
+	assert.ErrorIs(t, c.Withdraw(ctx, "asd2", 1), ErrLimitExceeded)
+	assert.ErrorIs(t, c.Withdraw(ctx, "asd3", 1), ErrWalletNotActive)
+	assert.ErrorIs(t, c.Withdraw(ctx, "asd4", 1), ErrUUIDUndefined)
+	assert.ErrorIs(t, c.Withdraw(ctx, "asd5", 1), ErrInsufficientFunds)
+
+	err := c.Withdraw(ctx, "asd6", 1)
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, CodeInternal, apiErr.Code)
+	assert.Equal(t, "error in data base", apiErr.Message)
+	assert.False(t, errors.Is(err, ErrInsufficientFunds))
+
+	// ответы с ошибками не повторяются
+	assert.Equal(t, 6, calls)
+}
+
+func TestHistory(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/wallets/asd1/history", r.URL.Path)
+		assert.Equal(t, "5", r.URL.Query().Get("after"))
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		fmt.Fprintln(w, `[{"id":6,"walletId":"asd1","type":"wallet.deposited","amount":100,"balance":100,"createdAt":"2026-01-02T03:04:05Z"}]`)
+	})
+
+	events, err := c.History(context.Background(), "asd1", 5, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, int64(6), events[0].Id)
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), events[0].CreatedAt)
+}
+
+func TestAdminToken(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		fmt.Fprintln(w, "1")
+	}, WithAdminToken("secret"))
+
+	_, err := c.GetBalance(context.Background(), "asd1")
+	assert.NoError(t, err)
+}
+
+func TestContextCancel(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}, WithRetries(5, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetBalance(ctx, "asd1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFreeze(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/wallets/asd1/freeze", r.URL.Path)
+		assert.Equal(t, "ops", r.Header.Get("X-Actor"))
+
+		var msg changeStatusMessage
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		assert.Equal(t, changeStatusMessage{Reason: "fraud", BlockDeposits: true}, msg)
+
+		fmt.Fprintln(w, "Status changed")
+	}, WithAdminToken("secret"), WithActor("ops"))
+
+	assert.NoError(t, c.Freeze(context.Background(), "asd1", "fraud", true))
+}
+
+func TestListWallets(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/wallets/export", r.URL.Path)
+		assert.Equal(t, "jsonl", r.URL.Query().Get("format"))
+		fmt.Fprint(w, "{\"walletId\":\"asd1\",\"balance\":10}\n{\"walletId\":\"asd2\",\"balance\":0}\n")
+	}, WithAdminToken("secret"))
+
+	wallets, err := c.ListWallets(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []WalletRecord{{WalletId: "asd1", Balance: 10}, {WalletId: "asd2", Balance: 0}}, wallets)
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Коды ошибок сервера. Отказы по правилам кошелька сервер отдаёт с кодом в начале текста,
+// остальные коды клиент выводит из текста и статуса ответа.
+const (
+	CodeLimitExceeded         = "LIMIT_EXCEEDED"
+	CodeWalletNotActive       = "WALLET_NOT_ACTIVE"
+	CodeInsufficientFunds     = "INSUFFICIENT_FUNDS"
+	CodeUUIDUndefined         = "UUID_UNDEFINED"
+	CodeWrongOperation        = "WRONG_OPERATION"
+	CodeDuplicateExternalId   = "DUPLICATE_EXTERNAL_ID"
+	CodeOperationUndefined    = "OPERATION_UNDEFINED"
+	CodeAlreadyReversed       = "ALREADY_REVERSED"
+	CodeReversalExceeded      = "REVERSAL_EXCEEDED"
+	CodeNotReversible         = "NOT_REVERSIBLE"
+	CodeFeeExceedsAmount      = "FEE_EXCEEDS_AMOUNT"
+	CodeVersionMismatch       = "VERSION_MISMATCH"
+	CodeWalletExists          = "WALLET_EXISTS"
+	CodeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+	CodeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+	CodeAuditUnavailable      = "AUDIT_UNAVAILABLE"
+	CodeBadRequest            = "BAD_REQUEST"
+	CodeUnauthorized          = "UNAUTHORIZED"
+	CodeNotFound              = "NOT_FOUND"
+	CodeInternal              = "INTERNAL"
+)
+
+// Error - ошибка, которую вернул сервер. Сравнивается через errors.Is с Err* по коду.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("wallet api: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+var (
+	ErrLimitExceeded       = &Error{Code: CodeLimitExceeded}
+	ErrWalletNotActive     = &Error{Code: CodeWalletNotActive}
+	ErrInsufficientFunds   = &Error{Code: CodeInsufficientFunds}
+	ErrUUIDUndefined       = &Error{Code: CodeUUIDUndefined}
+	ErrWrongOperation      = &Error{Code: CodeWrongOperation}
+	ErrDuplicateExternalId = &Error{Code: CodeDuplicateExternalId}
+	ErrOperationUndefined  = &Error{Code: CodeOperationUndefined}
+	ErrAlreadyReversed     = &Error{Code: CodeAlreadyReversed}
+	ErrReversalExceeded    = &Error{Code: CodeReversalExceeded}
+	ErrNotReversible       = &Error{Code: CodeNotReversible}
+	ErrFeeExceedsAmount    = &Error{Code: CodeFeeExceedsAmount}
+	ErrVersionMismatch     = &Error{Code: CodeVersionMismatch}
+	ErrWalletExists        = &Error{Code: CodeWalletExists}
+	ErrAuditUnavailable    = &Error{Code: CodeAuditUnavailable}
+	ErrUnauthorized        = &Error{Code: CodeUnauthorized}
+	ErrNotFound            = &Error{Code: CodeNotFound}
+)
+
+// ответы сервера без кода в начале текста
+var messageCodes = map[string]string{
+	"uuid undefined":             CodeUUIDUndefined,
+	"UUID is wrong":              CodeUUIDUndefined,
+	"UUID is actually exist":     CodeWalletExists,
+	"balance small for Withdraw": CodeInsufficientFunds,
+	"wrong operation type":       CodeWrongOperation,
+}
+
+// parseError разбирает текст ответа сервера с ошибкой
+func parseError(status int, body string) *Error {
+	message := strings.TrimSpace(body)
+
+	if code, rest, ok := strings.Cut(message, ": "); ok && isCode(code) {
+		return &Error{StatusCode: status, Code: code, Message: rest}
+	}
+
+	if code, ok := messageCodes[message]; ok {
+		return &Error{StatusCode: status, Code: code, Message: message}
+	}
+
+	code := CodeInternal
+	switch {
+	case status == 401 || status == 403:
+		code = CodeUnauthorized
+	case status == 404:
+		code = CodeNotFound
+	case status < 500:
+		code = CodeBadRequest
+	}
+
+	return &Error{StatusCode: status, Code: code, Message: message}
+}
+
+func isCode(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+var errAuditBroken = errors.New("audit log hash chain is broken")
+
+// auditLog - журнал аудита в базе, с которым работает команда audit
+type auditLog interface {
+	ListAudit(filter datastorage.AuditFilter) ([]datastorage.AuditEntry, error)
+	VerifyAudit() (datastorage.AuditVerification, error)
+}
+
+// auditCommand печатает записи журнала аудита, начиная с последних, а с verify - проверяет
+// цепочку hash. Если цепочка порвана, возвращает errAuditBroken.
+func auditCommand(db auditLog, args []string, out output) error {
+	if len(args) > 0 && args[0] == "verify" {
+		return auditVerifyCommand(db, args[1:], out)
+	}
+
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	actor := flags.String("actor", "", "only entries of this actor")
+	target := flags.String("target", "", "only entries changing this object, e.g. wallet:{id}")
+	requestId := flags.String("request-id", "", "only entries of this request")
+	limit := flags.Int("limit", 100, "max number of entries")
+
+	if err := flags.Parse(args); err != nil || *limit <= 0 || flags.NArg() > 0 {
+		return errUsage
+	}
+
+	entries, err := db.ListAudit(datastorage.AuditFilter{Actor: *actor, Target: *target, RequestId: *requestId, Limit: *limit})
+	if err != nil {
+		return err
+	}
+
+	return out.auditEntries(entries)
+}
+
+func auditVerifyCommand(db auditLog, args []string, out output) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+
+	result, err := db.VerifyAudit()
+	if err != nil {
+		return err
+	}
+
+	if err = out.auditVerification(result); err != nil {
+		return err
+	}
+
+	if !result.Valid {
+		return errAuditBroken
+	}
+
+	return nil
+}
+
+func (o output) auditEntries(entries []datastorage.AuditEntry) error {
+	if o.json {
+		if entries == nil {
+			entries = []datastorage.AuditEntry{}
+		}
+		return o.encode(entries)
+	}
+
+	rows := make([][]any, len(entries))
+	for i, entry := range entries {
+		rows[i] = []any{entry.Id, entry.CreatedAt.Format(time.RFC3339), entry.RequestId, entry.Actor,
+			entry.Method, entry.Path, entry.Target, entry.Status}
+	}
+
+	return o.table([]string{"ID", "TIME", "REQUEST", "ACTOR", "METHOD", "PATH", "TARGET", "STATUS"}, rows)
+}
+
+func (o output) auditVerification(result datastorage.AuditVerification) error {
+	if o.json {
+		return o.encode(result)
+	}
+
+	var brokenAt any = "-"
+	if result.BrokenAt != nil {
+		brokenAt = *result.BrokenAt
+	}
+
+	return o.table([]string{"ENTRIES", "HEAD", "VALID", "BROKEN AT", "REASON"},
+		[][]any{{result.Entries, result.Head, result.Valid, brokenAt, result.Reason}})
+}
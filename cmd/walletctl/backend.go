@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"walletGolang/client"
+	datastorage "walletGolang/dataStorage"
+)
+
+// backend - то, через что walletctl выполняет команды: HTTP API или напрямую база
+type backend interface {
+	Create(ctx context.Context, walletId string) error
+	Show(ctx context.Context, walletId string) (client.Wallet, error)
+	List(ctx context.Context) ([]client.WalletRecord, error)
+	Deposit(ctx context.Context, walletId string, amount float64) error
+	Withdraw(ctx context.Context, walletId string, amount float64) error
+	Transfer(ctx context.Context, from, to string, amount float64) error
+	Freeze(ctx context.Context, walletId, reason string, blockDeposits bool) error
+	Unfreeze(ctx context.Context, walletId, reason string) error
+	History(ctx context.Context, walletId string, after int64, limit int) ([]client.Event, error)
+}
+
+// apiBackend работает через REST API сервера
+type apiBackend struct {
+	*client.Client
+}
+
+func (b apiBackend) Create(ctx context.Context, walletId string) error {
+	return b.CreateWallet(ctx, walletId)
+}
+
+func (b apiBackend) Show(ctx context.Context, walletId string) (client.Wallet, error) {
+	return b.GetWallet(ctx, walletId)
+}
+
+func (b apiBackend) List(ctx context.Context) ([]client.WalletRecord, error) {
+	return b.ListWallets(ctx)
+}
+
+// dbBackend работает с базой напрямую через dataStorage, минуя сервер.
+// Изменения записываются в журнал аудита так же, как изменяющие запросы к серверу.
+type dbBackend struct {
+	db    datastorage.Postgres
+	actor string
+}
+
+var (
+	errWalletNotFound    = errors.New("wallet not found")
+	errWalletExists      = errors.New("wallet already exists")
+	errInsufficientFunds = errors.New("insufficient funds")
+	errAuditUnavailable  = errors.New("audit log is unavailable, nothing was changed")
+)
+
+// audited выполняет изменение кошелька change под блокировкой объекта аудита, общей с сервером.
+// Перед изменением в журнал пишется запись о начале: если это не удалось, изменение не выполняется.
+// После - запись с итогом (Status как у HTTP: 200, 400 или 500); если её записать не удалось,
+// в журнале остаётся запись о начале.
+func (b dbBackend) audited(command, walletId, reason string, request map[string]any, change func() error) error {
+	target := "wallet:" + walletId
+
+	unlock, err := b.db.LockAuditTarget(target)
+	if err != nil {
+		return errAuditUnavailable
+	}
+	defer unlock()
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	host, _ := os.Hostname()
+
+	request["walletId"] = walletId
+	raw, _ := json.Marshal(request)
+
+	entry := datastorage.AuditEntry{
+		Actor:     b.actor,
+		Subject:   "walletctl",
+		SourceIP:  host,
+		RequestId: hex.EncodeToString(id),
+		Method:    "CLI",
+		Path:      "walletctl " + command,
+		Target:    target,
+		Reason:    reason,
+		Request:   raw,
+		Before:    b.snapshot(walletId),
+	}
+
+	if _, err = b.db.AppendAudit(entry); err != nil {
+		return errAuditUnavailable
+	}
+
+	err = change()
+
+	entry.Status = auditStatus(err)
+	entry.After = b.snapshot(walletId)
+	b.db.AppendAudit(entry)
+
+	return err
+}
+
+// snapshot - кошелёк в JSON для записи аудита или nil, если его нет
+func (b dbBackend) snapshot(walletId string) json.RawMessage {
+	got, wallet, err := b.db.GetWallet(walletId)
+	if err != nil || !got {
+		return nil
+	}
+
+	raw, _ := json.Marshal(wallet)
+	return raw
+}
+
+// auditStatus - код итога изменения, как его записал бы сервер
+func auditStatus(err error) int {
+	var dbErr datastorage.DBError
+
+	switch {
+	case err == nil:
+		return 200
+	case errors.As(err, &dbErr):
+		return 500
+	}
+
+	return 400
+}
+
+func (b dbBackend) Create(ctx context.Context, walletId string) error {
+	check, err := b.db.Check(walletId)
+	if err != nil {
+		return err
+	}
+
+	if check {
+		return errWalletExists
+	}
+
+	return b.audited("create", walletId, "", map[string]any{}, func() error {
+		return b.db.CreateWallet(walletId, datastorage.WalletOptions{})
+	})
+}
+
+func (b dbBackend) Show(ctx context.Context, walletId string) (client.Wallet, error) {
+	got, wallet, err := b.db.GetWallet(walletId)
+	if err != nil {
+		return client.Wallet{}, err
+	}
+
+	if !got {
+		return client.Wallet{}, errWalletNotFound
+	}
+
+	return client.Wallet{
+		Id:              wallet.Id,
+		Balance:         wallet.Balance,
+		CreditLimit:     wallet.CreditLimit,
+		AvailableCredit: wallet.AvailableCredit,
+		Status:          wallet.Status,
+		Tier:            wallet.Tier,
+		Owner:           wallet.Owner,
+		CreatedAt:       wallet.CreatedAt,
+		Metadata:        wallet.Metadata,
+	}, nil
+}
+
+func (b dbBackend) List(ctx context.Context) ([]client.WalletRecord, error) {
+	var wallets []client.WalletRecord
+
+	err := b.db.ExportWallets(func(record datastorage.WalletRecord) error {
+		wallets = append(wallets, client.WalletRecord{WalletId: record.WalletId, Balance: record.Balance})
+		return nil
+	})
+
+	return wallets, err
+}
+
+func (b dbBackend) Deposit(ctx context.Context, walletId string, amount float64) error {
+	request := map[string]any{"operationType": datastorage.OperationDeposit, "amount": amount}
+
+	return b.audited("deposit", walletId, "", request, func() error {
+		return changed(b.db.ChangeBalance(amount, walletId, datastorage.OperationDetails{}))
+	})
+}
+
+func (b dbBackend) Withdraw(ctx context.Context, walletId string, amount float64) error {
+	request := map[string]any{"operationType": datastorage.OperationWithdraw, "amount": amount}
+
+	return b.audited("withdraw", walletId, "", request, func() error {
+		return changed(b.db.ChangeBalance(-amount, walletId, datastorage.OperationDetails{}))
+	})
+}
+
+func (b dbBackend) Transfer(ctx context.Context, from, to string, amount float64) error {
+	request := map[string]any{"operationType": datastorage.OperationTransfer, "amount": amount, "toWalletId": to}
+
+	return b.audited("transfer", from, "", request, func() error {
+		return changed(b.db.Transfer(amount, from, to, datastorage.OperationDetails{}))
+	})
+}
+
+func changed(ok bool, _ datastorage.Receipt, err error) error {
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return errInsufficientFunds
+	}
+
+	return nil
+}
+
+func (b dbBackend) Freeze(ctx context.Context, walletId, reason string, blockDeposits bool) error {
+	request := map[string]any{"reason": reason, "blockDeposits": blockDeposits}
+
+	return b.audited("freeze", walletId, reason, request, func() error {
+		return b.db.ChangeStatus(walletId, datastorage.StatusChange{
+			Status:        datastorage.StatusFrozen,
+			BlockDeposits: blockDeposits,
+			Actor:         b.actor,
+			Reason:        reason,
+		})
+	})
+}
+
+func (b dbBackend) Unfreeze(ctx context.Context, walletId, reason string) error {
+	request := map[string]any{"reason": reason}
+
+	return b.audited("unfreeze", walletId, reason, request, func() error {
+		return b.db.ChangeStatus(walletId, datastorage.StatusChange{
+			Status: datastorage.StatusActive,
+			Actor:  b.actor,
+			Reason: reason,
+		})
+	})
+}
+
+func (b dbBackend) History(ctx context.Context, walletId string, after int64, limit int) ([]client.Event, error) {
+	check, err := b.db.Check(walletId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !check {
+		return nil, errWalletNotFound
+	}
+
+	events, err := b.db.EventsAfter(walletId, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]client.Event, len(events))
+	for i, event := range events {
+		history[i] = client.Event(event)
+	}
+
+	return history, nil
+}
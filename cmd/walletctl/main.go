@@ -0,0 +1,279 @@
+// Команда walletctl - инструмент оператора для работы с кошельками через HTTP API
+// или напрямую с базой (флаг -db, подключение из config.env).
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"walletGolang/client"
+	datastorage "walletGolang/dataStorage"
+	"walletGolang/migrations"
+
+	"github.com/joho/godotenv"
+)
+
+var errUsage = errors.New("usage")
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, `usage: walletctl [-api URL | -db] [-token TOKEN] [-actor NAME] [-json] COMMAND [ARGS]
+
+commands:
+  create WALLET_ID                                   create wallet
+  show WALLET_ID                                     show balance, credit limit, status and tier
+  list                                               list all wallets with balances (admin)
+  deposit WALLET_ID AMOUNT                           deposit amount
+  withdraw WALLET_ID AMOUNT                          withdraw amount
+  transfer FROM_ID TO_ID AMOUNT                      transfer amount between wallets
+  freeze WALLET_ID -reason TEXT [-block-deposits]    freeze wallet (admin)
+  unfreeze WALLET_ID -reason TEXT                    unfreeze wallet (admin)
+  history WALLET_ID [-after ID] [-limit N]           show wallet events
+  migrate up | down [-steps N] | status              apply, revert or show migrations (only with -db)
+  audit [-actor NAME] [-target OBJECT] [-request-id ID] [-limit N]
+                                                     show audit log entries, newest first (only with -db)
+  audit verify                                       check the audit log hash chain (only with -db)
+
+flags:`)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func envOr(name, value string) string {
+	if env := os.Getenv(name); env != "" {
+		return env
+	}
+	return value
+}
+
+// run разбирает общие флаги, выбирает backend и выполняет команду. Возвращает код выхода.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("walletctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		usage(stderr)
+		flags.PrintDefaults()
+	}
+
+	api := flags.String("api", envOr("WALLET_API", "http://localhost:80"), "server URL")
+	useDB := flags.Bool("db", false, "work with the database from config.env instead of the server")
+	token := flags.String("token", os.Getenv("ADMIN_TOKEN"), "admin token for admin commands")
+	actor := flags.String("actor", envOr("USER", "walletctl"), "operator name for the status history")
+	jsonOutput := flags.Bool("json", false, "print JSON instead of tables")
+	timeout := flags.Duration("timeout", 10*time.Second, "request timeout")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	name, commandArgs := flags.Arg(0), flags.Args()[1:]
+	out := output{w: stdout, json: *jsonOutput}
+
+	var b backend
+	var db datastorage.Postgres
+
+	if *useDB {
+		// config.env необязателен: переменные могут быть заданы в окружении
+		godotenv.Load("config.env")
+
+		var err error
+		db, err = datastorage.NewPostgresFromEnv()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+
+		b = dbBackend{db: db, actor: *actor}
+	} else {
+		b = apiBackend{client.New(*api, client.WithTimeout(*timeout), client.WithAdminToken(*token), client.WithActor(*actor))}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if (name == "migrate" || name == "audit") && !*useDB {
+		fmt.Fprintln(stderr, name, "works only with -db")
+		return 2
+	}
+
+	var err error
+	switch name {
+	case "migrate":
+		err = migrations.Run(db, commandArgs, stdout)
+		if errors.Is(err, migrations.ErrUsage) {
+			err = errUsage
+		}
+	case "audit":
+		err = auditCommand(db, commandArgs, out)
+	default:
+		err = runCommand(ctx, b, name, commandArgs, out)
+	}
+
+	if errors.Is(err, errUsage) {
+		flags.Usage()
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+
+	return 0
+}
+
+// runCommand выполняет команду работы с кошельками
+func runCommand(ctx context.Context, b backend, name string, args []string, out output) error {
+	switch name {
+	case "create":
+		if len(args) != 1 {
+			return errUsage
+		}
+		if err := b.Create(ctx, args[0]); err != nil {
+			return err
+		}
+		return out.message("Wallet created")
+
+	case "show":
+		if len(args) != 1 {
+			return errUsage
+		}
+		wallet, err := b.Show(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return out.wallet(wallet)
+
+	case "list":
+		if len(args) != 0 {
+			return errUsage
+		}
+		wallets, err := b.List(ctx)
+		if err != nil {
+			return err
+		}
+		return out.wallets(wallets)
+
+	case "deposit", "withdraw":
+		if len(args) != 2 {
+			return errUsage
+		}
+		amount, err := parseAmount(args[1])
+		if err != nil {
+			return err
+		}
+		if name == "deposit" {
+			err = b.Deposit(ctx, args[0], amount)
+		} else {
+			err = b.Withdraw(ctx, args[0], amount)
+		}
+		if err != nil {
+			return err
+		}
+		return out.message("Operation complit")
+
+	case "transfer":
+		if len(args) != 3 {
+			return errUsage
+		}
+		amount, err := parseAmount(args[2])
+		if err != nil {
+			return err
+		}
+		if args[0] == args[1] {
+			return errors.New("wallets must be different")
+		}
+		if err = b.Transfer(ctx, args[0], args[1], amount); err != nil {
+			return err
+		}
+		return out.message("Operation complit")
+
+	case "freeze", "unfreeze":
+		return statusCommand(ctx, b, name, args, out)
+
+	case "history":
+		return historyCommand(ctx, b, args, out)
+	}
+
+	return errUsage
+}
+
+func parseAmount(value string) (float64, error) {
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount <= 0 {
+		return 0, errors.New("amount must be number more 0")
+	}
+	return amount, nil
+}
+
+// parseCommand разбирает флаги команды, стоящие после id кошелька
+func parseCommand(flags *flag.FlagSet, args []string) (string, error) {
+	flags.SetOutput(io.Discard)
+
+	if len(args) == 0 {
+		return "", errUsage
+	}
+
+	if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 0 {
+		return "", errUsage
+	}
+
+	return args[0], nil
+}
+
+func statusCommand(ctx context.Context, b backend, name string, args []string, out output) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	reason := flags.String("reason", "", "reason of the status change")
+	blockDeposits := flags.Bool("block-deposits", false, "block deposits too (freeze only)")
+
+	walletId, err := parseCommand(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if *reason == "" {
+		return errors.New("-reason is required")
+	}
+
+	if name == "freeze" {
+		err = b.Freeze(ctx, walletId, *reason, *blockDeposits)
+	} else {
+		err = b.Unfreeze(ctx, walletId, *reason)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return out.message("Status changed")
+}
+
+func historyCommand(ctx context.Context, b backend, args []string, out output) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	after := flags.Int64("after", 0, "show events after this id")
+	limit := flags.Int("limit", 100, "max number of events")
+
+	walletId, err := parseCommand(flags, args)
+	if err != nil {
+		return err
+	}
+
+	events, err := b.History(ctx, walletId, *after, *limit)
+	if err != nil {
+		return err
+	}
+
+	return out.events(events)
+}
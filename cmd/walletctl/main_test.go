@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"walletGolang/client"
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend запоминает вызовы и отдаёт заранее заданные данные
+type fakeBackend struct {
+	calls  []string
+	wallet client.Wallet
+	events []client.Event
+	err    error
+}
+
+func (f *fakeBackend) call(format string, args ...any) error {
+	f.calls = append(f.calls, fmt.Sprintf(format, args...))
+	return f.err
+}
+
+func (f *fakeBackend) Create(ctx context.Context, walletId string) error {
+	return f.call("create %s", walletId)
+}
+
+func (f *fakeBackend) Show(ctx context.Context, walletId string) (client.Wallet, error) {
+	return f.wallet, f.call("show %s", walletId)
+}
+
+func (f *fakeBackend) List(ctx context.Context) ([]client.WalletRecord, error) {
+	return []client.WalletRecord{{WalletId: "asd1", Balance: 10}, {WalletId: "asd2", Balance: 2.5}}, f.call("list")
+}
+
+func (f *fakeBackend) Deposit(ctx context.Context, walletId string, amount float64) error {
+	return f.call("deposit %s %v", walletId, amount)
+}
+
+func (f *fakeBackend) Withdraw(ctx context.Context, walletId string, amount float64) error {
+	return f.call("withdraw %s %v", walletId, amount)
+}
+
+func (f *fakeBackend) Transfer(ctx context.Context, from, to string, amount float64) error {
+	return f.call("transfer %s %s %v", from, to, amount)
+}
+
+func (f *fakeBackend) Freeze(ctx context.Context, walletId, reason string, blockDeposits bool) error {
+	return f.call("freeze %s %s %v", walletId, reason, blockDeposits)
+}
+
+func (f *fakeBackend) Unfreeze(ctx context.Context, walletId, reason string) error {
+	return f.call("unfreeze %s %s", walletId, reason)
+}
+
+func (f *fakeBackend) History(ctx context.Context, walletId string, after int64, limit int) ([]client.Event, error) {
+	return f.events, f.call("history %s %d %d", walletId, after, limit)
+}
+
+func TestCommands(t *testing.T) {
+	cases := map[string]string{
+		"create asd1":                               "create asd1",
+		"deposit asd1 100.5":                        "deposit asd1 100.5",
+		"withdraw asd1 1":                           "withdraw asd1 1",
+		"transfer asd1 asd2 3":                      "transfer asd1 asd2 3",
+		"freeze asd1 -reason fraud -block-deposits": "freeze asd1 fraud true",
+		"unfreeze asd1 -reason checked":             "unfreeze asd1 checked",
+		"history asd1 -after 5 -limit 10":           "history asd1 5 10",
+		"history asd1":                              "history asd1 0 100",
+	}
+
+	for command, call := range cases {
+		b := &fakeBackend{}
+		args := strings.Fields(command)
+
+		err := runCommand(context.Background(), b, args[0], args[1:], output{w: &bytes.Buffer{}})
+
+		assert.NoError(t, err, command)
+		assert.Equal(t, []string{call}, b.calls, command)
+	}
+}
+
+func TestWrongCommands(t *testing.T) {
+	cases := map[string]string{
+		"unknown":              errUsage.Error(),
+		"create":               errUsage.Error(),
+		"deposit asd1":         errUsage.Error(),
+		"deposit asd1 -5":      "amount must be number more 0",
+		"withdraw asd1 abc":    "amount must be number more 0",
+		"transfer asd1 asd1 5": "wallets must be different",
+		"freeze asd1":          "-reason is required",
+		"freeze asd1 -reason":  errUsage.Error(),
+		"history asd1 extra":   errUsage.Error(),
+	}
+
+	for command, message := range cases {
+		b := &fakeBackend{}
+		args := strings.Fields(command)
+
+		err := runCommand(context.Background(), b, args[0], args[1:], output{w: &bytes.Buffer{}})
+
+		assert.EqualError(t, err, message, command)
+		assert.Empty(t, b.calls, command)
+	}
+}
+
+func TestTableOutput(t *testing.T) {
+	var buf bytes.Buffer
+	b := &fakeBackend{
+		wallet: client.Wallet{Id: "asd1", Balance: 10, CreditLimit: 5, AvailableCredit: 5, Status: "active", Tier: "default"},
+		events: []client.Event{{Id: 1, Type: "wallet.deposited", Amount: 10, Balance: 10, CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}},
+	}
+
+	require.NoError(t, runCommand(context.Background(), b, "show", []string{"asd1"}, output{w: &buf}))
+	assert.Equal(t, "WALLET  BALANCE  CREDIT LIMIT  AVAILABLE CREDIT  STATUS  TIER\n"+
+		"asd1    10.00    5.00          5.00              active  default\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, runCommand(context.Background(), b, "list", nil, output{w: &buf}))
+	assert.Equal(t, "WALLET  BALANCE\nasd1    10.00\nasd2    2.50\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, runCommand(context.Background(), b, "history", []string{"asd1"}, output{w: &buf}))
+	assert.Equal(t, "ID  TIME                  TYPE              AMOUNT  BALANCE\n"+
+		"1   2026-01-02T03:04:05Z  wallet.deposited  10.00   10.00\n", buf.String())
+}
+
+func TestJSONOutput(t *testing.T) {
+	var buf bytes.Buffer
+	b := &fakeBackend{}
+
+	require.NoError(t, runCommand(context.Background(), b, "list", nil, output{w: &buf, json: true}))
+
+	var wallets []client.WalletRecord
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &wallets))
+	assert.Len(t, wallets, 2)
+
+	buf.Reset()
+	require.NoError(t, runCommand(context.Background(), b, "history", []string{"asd1"}, output{w: &buf, json: true}))
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestRunWithAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/wallets/wallet", r.URL.Path)
+		http.Error(w, "INSUFFICIENT_FUNDS: insufficient funds", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-api", srv.URL, "withdraw", "asd1", "100"}, &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Empty(t, stdout.String())
+	assert.Equal(t, "error: wallet api: 400 INSUFFICIENT_FUNDS: insufficient funds\n", stderr.String())
+}
+
+func TestRunUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, 2, run(nil, &stdout, &stderr))
+	assert.Equal(t, 2, run([]string{"migrate"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "migrate works only with -db")
+	assert.Equal(t, 2, run([]string{"audit", "verify"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "audit works only with -db")
+}
+
+// fakeAuditLog отдаёт заранее заданные записи и результат проверки
+type fakeAuditLog struct {
+	filter       datastorage.AuditFilter
+	entries      []datastorage.AuditEntry
+	verification datastorage.AuditVerification
+}
+
+func (f *fakeAuditLog) ListAudit(filter datastorage.AuditFilter) ([]datastorage.AuditEntry, error) {
+	f.filter = filter
+	return f.entries, nil
+}
+
+func (f *fakeAuditLog) VerifyAudit() (datastorage.AuditVerification, error) {
+	return f.verification, nil
+}
+
+func TestAuditCommand(t *testing.T) {
+	var buf bytes.Buffer
+	db := &fakeAuditLog{entries: []datastorage.AuditEntry{{
+		Id: 8, CreatedAt: time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC), RequestId: "req-1", Actor: "ivan",
+		Method: "POST", Path: "/api/v1/wallets/asd1/freeze", Target: "wallet:asd1", Status: 200,
+	}}}
+
+	require.NoError(t, auditCommand(db, strings.Fields("-actor ivan -limit 5"), output{w: &buf}))
+	assert.Equal(t, datastorage.AuditFilter{Actor: "ivan", Limit: 5}, db.filter)
+	assert.Equal(t, "ID  TIME                  REQUEST  ACTOR  METHOD  PATH                         TARGET       STATUS\n"+
+		"8   2026-10-02T09:00:00Z  req-1    ivan   POST    /api/v1/wallets/asd1/freeze  wallet:asd1  200\n", buf.String())
+
+	assert.Equal(t, errUsage, auditCommand(db, []string{"-limit", "0"}, output{w: &buf}))
+	assert.Equal(t, errUsage, auditCommand(db, []string{"verify", "extra"}, output{w: &buf}))
+
+	// порванная цепочка - ошибка, чтобы walletctl завершился с кодом 1
+	brokenAt := int64(3)
+	db.verification = datastorage.AuditVerification{Entries: 5, BrokenAt: &brokenAt, Reason: "hash mismatch"}
+
+	buf.Reset()
+	assert.Equal(t, errAuditBroken, auditCommand(db, []string{"verify"}, output{w: &buf, json: true}))
+	assert.JSONEq(t, `{"entries":5,"head":"","valid":false,"brokenAt":3,"reason":"hash mismatch"}`, buf.String())
+}
+
+func TestAuditStatus(t *testing.T) {
+	assert.Equal(t, 200, auditStatus(nil))
+	assert.Equal(t, 400, auditStatus(errInsufficientFunds))
+	assert.Equal(t, 400, auditStatus(datastorage.UUIDUndefined{}))
+	assert.Equal(t, 500, auditStatus(datastorage.DBError{}))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"walletGolang/client"
+)
+
+// output печатает результаты команд таблицей или JSON
+type output struct {
+	w    io.Writer
+	json bool
+}
+
+func (o output) message(text string) error {
+	if o.json {
+		return o.encode(map[string]string{"result": text})
+	}
+
+	_, err := fmt.Fprintln(o.w, text)
+	return err
+}
+
+func (o output) wallet(wallet client.Wallet) error {
+	if o.json {
+		return o.encode(wallet)
+	}
+
+	return o.table([]string{"WALLET", "BALANCE", "CREDIT LIMIT", "AVAILABLE CREDIT", "STATUS", "TIER"},
+		[][]any{{wallet.Id, money(wallet.Balance), money(wallet.CreditLimit), money(wallet.AvailableCredit), wallet.Status, wallet.Tier}})
+}
+
+func (o output) wallets(wallets []client.WalletRecord) error {
+	if o.json {
+		if wallets == nil {
+			wallets = []client.WalletRecord{}
+		}
+		return o.encode(wallets)
+	}
+
+	rows := make([][]any, len(wallets))
+	for i, wallet := range wallets {
+		rows[i] = []any{wallet.WalletId, money(wallet.Balance)}
+	}
+
+	return o.table([]string{"WALLET", "BALANCE"}, rows)
+}
+
+func (o output) events(events []client.Event) error {
+	if o.json {
+		if events == nil {
+			events = []client.Event{}
+		}
+		return o.encode(events)
+	}
+
+	rows := make([][]any, len(events))
+	for i, event := range events {
+		rows[i] = []any{event.Id, event.CreatedAt.Format(time.RFC3339), event.Type, money(event.Amount), money(event.Balance)}
+	}
+
+	return o.table([]string{"ID", "TIME", "TYPE", "AMOUNT", "BALANCE"}, rows)
+}
+
+func (o output) encode(value any) error {
+	encoder := json.NewEncoder(o.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func (o output) table(header []string, rows [][]any) error {
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+
+	for i, column := range header {
+		if i > 0 {
+			fmt.Fprint(tw, "\t")
+		}
+		fmt.Fprint(tw, column)
+	}
+	fmt.Fprintln(tw)
+
+	for _, row := range rows {
+		for i, value := range row {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, value)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+func money(value float64) string {
+	return fmt.Sprintf("%.2f", value)
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"walletGolang/bulk"
+	"walletGolang/interest"
+	"walletGolang/ledger"
+	"walletGolang/migrations"
+)
+
+// runCommand выполняет административную команду и возвращает код выхода
+func runCommand(name string, args []string) int {
+	switch name {
+	case "import":
+		return importCommand(args)
+	case "export":
+		return exportCommand(args)
+	case "migrate":
+		return migrateCommand(args)
+	case "reconcile":
+		return reconcileCommand(args)
+	case "accrue":
+		return accrueCommand(args)
+	}
+
+	usage()
+	return 2
+}
+
+func importCommand(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", bulk.FormatCSV, "file format: csv or jsonl")
+	file := flags.String("file", "", "file to import, - for stdin")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *file == "" || !bulk.ValidFormat(*format) {
+		usage()
+		return 2
+	}
+
+	var input io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		input = f
+	}
+
+	db, err := connectDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	imported, lineErrors, err := bulk.Import(db, input, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if len(lineErrors) > 0 {
+		encoder := json.NewEncoder(os.Stderr)
+		for _, lineErr := range lineErrors {
+			encoder.Encode(lineErr)
+		}
+		fmt.Fprintln(os.Stderr, "import rejected, wrong lines:", len(lineErrors))
+		return 1
+	}
+
+	fmt.Println("Wallets imported:", imported)
+	return 0
+}
+
+func exportCommand(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", bulk.FormatCSV, "file format: csv or jsonl")
+	file := flags.String("file", "", "output file, stdout by default")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if !bulk.ValidFormat(*format) {
+		usage()
+		return 2
+	}
+
+	var output io.Writer = os.Stdout
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		output = f
+	}
+
+	db, err := connectDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	writer, err := bulk.NewWriter(output, *format)
+	if err == nil {
+		err = db.ExportWallets(writer.Write)
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func migrateCommand(args []string) int {
+	db, err := connectDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	err = migrations.Run(db, args, os.Stdout)
+
+	if errors.Is(err, migrations.ErrUsage) {
+		usage()
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// reconcileCommand сверяет балансы кошельков с журналом проводок и печатает расхождения.
+// Код выхода 1, если остались неисправленные расхождения.
+func reconcileCommand(args []string) int {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	format := flags.String("format", ledger.FormatJSON, "report format: json or csv")
+	file := flags.String("file", "", "report file, stdout by default")
+	repair := flags.Bool("repair", false, "post correction entries for mismatches")
+	actor := flags.String("actor", os.Getenv("USER"), "who repairs the mismatches")
+	reason := flags.String("reason", "", "why the mismatches are repaired")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if !ledger.ValidFormat(*format) || (*repair && (*actor == "" || *reason == "")) {
+		usage()
+		return 2
+	}
+
+	db, err := connectDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	reconciler := ledger.NewReconciler(db)
+	reconciler.Repair = *repair
+	reconciler.Actor = *actor
+	reconciler.Reason = *reason
+
+	results, err := reconciler.RunOnce()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var output io.Writer = os.Stdout
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		output = f
+	}
+
+	if err = ledger.WriteReport(output, *format, results); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	for _, result := range results {
+		if !result.Repaired && result.Delta != 0 {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// accrueCommand начисляет проценты за дни с -from по -to включительно (по умолчанию - за вчера)
+// и печатает начисления в JSON. Уже начисленные дни пропускаются. Код выхода 1, если
+// хотя бы одному кошельку начислить не удалось.
+func accrueCommand(args []string) int {
+	accruer := interest.NewAccruer(nil)
+	yesterday := accruer.Period(time.Now()).Format(time.DateOnly)
+
+	flags := flag.NewFlagSet("accrue", flag.ContinueOnError)
+	from := flags.String("from", yesterday, "first day, YYYY-MM-DD")
+	to := flags.String("to", "", "last day, YYYY-MM-DD, -from by default")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *to == "" {
+		*to = *from
+	}
+
+	first, err := time.Parse(time.DateOnly, *from)
+	if err != nil {
+		usage()
+		return 2
+	}
+
+	last, err := time.Parse(time.DateOnly, *to)
+	if err != nil || last.Before(first) {
+		usage()
+		return 2
+	}
+
+	db, err := connectDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	accruer.Store = db
+
+	// дни идут по порядку: остаток меньше копейки переходит на следующий день
+	results := []interest.Result{}
+	for period := first; !period.After(last); period = period.AddDate(0, 0, 1) {
+		accrued, err := accruer.Accrue(period)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		results = append(results, accrued...)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	if err = encoder.Encode(results); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,328 @@
+package datastorage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// auditLockKey - ключ advisory lock, под которым записи журнала аудита добавляются по одной
+const auditLockKey = 7303
+
+// auditTargetLockKey - первый ключ advisory lock объекта аудита, второй - hashtext(target)
+const auditTargetLockKey = 7304
+
+// AuditGenesis - prev_hash первой записи журнала аудита
+var AuditGenesis = strings.Repeat("0", 64)
+
+// AuditEntry - запись журнала аудита об изменяющем запросе. Subject - кем подписан запрос
+// (отпечаток токена), Actor - кого назвал вызывающий, Target - изменяемый объект (например, wallet:{id}),
+// Before и After - его состояние до и после запроса. Id, CreatedAt, PrevHash и Hash задаёт AppendAudit.
+type AuditEntry struct {
+	Id        int64           `json:"id"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Actor     string          `json:"actor,omitempty"`
+	Subject   string          `json:"subject,omitempty"`
+	SourceIP  string          `json:"sourceIp,omitempty"`
+	RequestId string          `json:"requestId"`
+	Method    string          `json:"method"`
+	Path      string          `json:"path"`
+	Target    string          `json:"target,omitempty"`
+	Status    int             `json:"status"`
+	Reason    string          `json:"reason,omitempty"`
+	Request   json.RawMessage `json:"request,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	PrevHash  string          `json:"prevHash"`
+	Hash      string          `json:"hash"`
+}
+
+// AuditFilter - условия поиска записей аудита. Пустые поля не ограничивают поиск,
+// BeforeId выдаёт записи старее записи с этим id (следующая страница).
+type AuditFilter struct {
+	Actor     string
+	Target    string
+	RequestId string
+	From      *time.Time
+	To        *time.Time
+	BeforeId  int64
+	Limit     int
+}
+
+// AuditVerification - результат проверки цепочки: сколько записей проверено, hash последней
+// и первая запись, на которой цепочка порвана
+type AuditVerification struct {
+	Entries  int64  `json:"entries"`
+	Head     string `json:"head"`
+	Valid    bool   `json:"valid"`
+	BrokenAt *int64 `json:"brokenAt,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// canonicalJSON разбирает JSON, чтобы hash не зависел от пробелов и порядка ключей,
+// которые JSONB в базе не сохраняет
+func canonicalJSON(raw json.RawMessage) (any, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var value any
+	err := json.Unmarshal(raw, &value)
+	return value, err
+}
+
+// auditHash считает hash записи вместе с PrevHash
+func auditHash(entry AuditEntry) (string, error) {
+	payload := struct {
+		Id        int64
+		CreatedAt string
+		Actor     string
+		Subject   string
+		SourceIP  string
+		RequestId string
+		Method    string
+		Path      string
+		Target    string
+		Status    int
+		Reason    string
+		Request   any
+		Before    any
+		After     any
+		PrevHash  string
+	}{
+		Id:        entry.Id,
+		CreatedAt: entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		Actor:     entry.Actor,
+		Subject:   entry.Subject,
+		SourceIP:  entry.SourceIP,
+		RequestId: entry.RequestId,
+		Method:    entry.Method,
+		Path:      entry.Path,
+		Target:    entry.Target,
+		Status:    entry.Status,
+		Reason:    entry.Reason,
+		PrevHash:  entry.PrevHash,
+	}
+
+	var err error
+	for _, field := range []struct {
+		raw   json.RawMessage
+		value *any
+	}{{entry.Request, &payload.Request}, {entry.Before, &payload.Before}, {entry.After, &payload.After}} {
+		if *field.value, err = canonicalJSON(field.raw); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// jsonb передаёт JSON в колонку JSONB, пустое значение - как NULL
+func jsonb(raw json.RawMessage) []byte {
+	if len(raw) == 0 {
+		return nil
+	}
+	return []byte(raw)
+}
+
+// AppendAudit добавляет запись в конец журнала аудита и возвращает её с id и hash.
+// Записи добавляются под advisory lock, чтобы цепочка не ветвилась.
+func (postgres Postgres) AppendAudit(entry AuditEntry) (AuditEntry, error) {
+	ctx := context.Background()
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in AppendAudit method: ", err)
+		return AuditEntry{}, DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", auditLockKey); err != nil {
+		log.Println("error in AppendAudit method: ", err)
+		return AuditEntry{}, DBError{}
+	}
+
+	entry.PrevHash = AuditGenesis
+	err = tx.QueryRow(ctx, "SELECT id, hash FROM audit_log ORDER BY id DESC LIMIT 1").Scan(&entry.Id, &entry.PrevHash)
+
+	if err != nil && err != pgx.ErrNoRows {
+		log.Println("error in AppendAudit method: ", err)
+		return AuditEntry{}, DBError{}
+	}
+
+	entry.Id++
+	// в базе время хранится с точностью до микросекунд, hash должен сойтись после чтения
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	if entry.Hash, err = auditHash(entry); err != nil {
+		log.Println("error in AppendAudit method: ", err)
+		return AuditEntry{}, DBError{}
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (id, created_at, actor, subject, source_ip, request_id, method, path, target,
+		                        status, reason, request, before, after, prev_hash, hash)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
+		entry.Id, entry.CreatedAt, entry.Actor, entry.Subject, entry.SourceIP, entry.RequestId, entry.Method,
+		entry.Path, entry.Target, entry.Status, entry.Reason,
+		jsonb(entry.Request), jsonb(entry.Before), jsonb(entry.After), entry.PrevHash, entry.Hash)
+
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+
+	if err != nil {
+		log.Println("error in AppendAudit method: ", err)
+		return AuditEntry{}, DBError{}
+	}
+
+	return entry, nil
+}
+
+const auditQuery = `SELECT id, created_at, actor, subject, source_ip, request_id, method, path, target,
+        status, reason, request, before, after, prev_hash, hash
+   FROM audit_log`
+
+// LockAuditTarget блокирует объект target для всех копий сервера и walletctl, чтобы изменения
+// одного объекта шли по очереди и Before и After записи аудита относились к одному изменению.
+// Возвращает функцию, снимающую блокировку. Блокировку держит соединение из отдельного пула:
+// ожидающие запросы не занимают соединения, нужные самим операциям.
+func (postgres Postgres) LockAuditTarget(target string) (func(), error) {
+	ctx := context.Background()
+
+	conn, err := postgres.locks.Acquire(ctx)
+	if err != nil {
+		log.Println("error in LockAuditTarget method: ", err)
+		return nil, DBError{}
+	}
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1, hashtext($2))", auditTargetLockKey, target); err != nil {
+		log.Println("error in LockAuditTarget method: ", err)
+		conn.Release()
+		return nil, DBError{}
+	}
+
+	return func() {
+		if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock($1, hashtext($2))", auditTargetLockKey, target); err != nil {
+			log.Println("error in LockAuditTarget method: ", err)
+			// соединение с неснятой блокировкой не должно вернуться в пул
+			conn.Conn().Close(ctx)
+		}
+		conn.Release()
+	}, nil
+}
+
+func scanAuditEntry(row pgx.Row) (AuditEntry, error) {
+	var entry AuditEntry
+	var request, before, after []byte
+
+	err := row.Scan(&entry.Id, &entry.CreatedAt, &entry.Actor, &entry.Subject, &entry.SourceIP, &entry.RequestId,
+		&entry.Method, &entry.Path, &entry.Target, &entry.Status, &entry.Reason,
+		&request, &before, &after, &entry.PrevHash, &entry.Hash)
+
+	entry.CreatedAt = entry.CreatedAt.UTC()
+	entry.Request, entry.Before, entry.After = request, before, after
+
+	return entry, err
+}
+
+// ListAudit ищет записи журнала аудита, начиная с последних
+func (postgres Postgres) ListAudit(filter AuditFilter) ([]AuditEntry, error) {
+	rows, err := postgres.pool.Query(context.Background(),
+		auditQuery+`
+		  WHERE ($1 = '' OR actor = $1)
+		    AND ($2 = '' OR target = $2)
+		    AND ($3 = '' OR request_id = $3)
+		    AND ($4::TIMESTAMPTZ IS NULL OR created_at >= $4)
+		    AND ($5::TIMESTAMPTZ IS NULL OR created_at < $5)
+		    AND ($6 = 0 OR id < $6)
+		  ORDER BY id DESC
+		  LIMIT $7`,
+		filter.Actor, filter.Target, filter.RequestId, filter.From, filter.To, filter.BeforeId, filter.Limit)
+
+	if err != nil {
+		log.Println("error in ListAudit method: ", err)
+		return nil, DBError{}
+	}
+
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (AuditEntry, error) {
+		return scanAuditEntry(row)
+	})
+
+	if err != nil {
+		log.Println("error in ListAudit method: ", err)
+		return nil, DBError{}
+	}
+
+	return entries, nil
+}
+
+// VerifyAudit проходит журнал аудита от первой записи и пересчитывает hash каждой.
+// Цепочка порвана, если запись изменена, пропущена или вставлена не на своё место.
+// Удаление записей с конца журнала цепочка не выдаёт - для этого Head сверяют с сохранённым ранее.
+func (postgres Postgres) VerifyAudit() (AuditVerification, error) {
+	rows, err := postgres.pool.Query(context.Background(), auditQuery+" ORDER BY id")
+
+	if err != nil {
+		log.Println("error in VerifyAudit method: ", err)
+		return AuditVerification{}, DBError{}
+	}
+	defer rows.Close()
+
+	result := AuditVerification{Head: AuditGenesis, Valid: true}
+
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			log.Println("error in VerifyAudit method: ", err)
+			return AuditVerification{}, DBError{}
+		}
+
+		if reason := verifyAuditEntry(entry, result.Entries+1, result.Head); reason != "" {
+			result.Valid = false
+			result.BrokenAt = &entry.Id
+			result.Reason = reason
+			return result, nil
+		}
+
+		result.Entries++
+		result.Head = entry.Hash
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println("error in VerifyAudit method: ", err)
+		return AuditVerification{}, DBError{}
+	}
+
+	return result, nil
+}
+
+// verifyAuditEntry проверяет запись, которая должна идти под номером id после записи с hash prev
+func verifyAuditEntry(entry AuditEntry, id int64, prev string) string {
+	if entry.Id != id {
+		return "entries before it are missing"
+	}
+
+	if entry.PrevHash != prev {
+		return "prevHash does not match the previous entry"
+	}
+
+	hash, err := auditHash(entry)
+	if err != nil || hash != entry.Hash {
+		return "entry was modified"
+	}
+
+	return ""
+}
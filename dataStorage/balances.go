@@ -0,0 +1,74 @@
+package datastorage
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// WalletBalance - баланс кошелька на момент времени
+type WalletBalance struct {
+	WalletId string  `json:"walletId"`
+	Balance  float64 `json:"balance"`
+}
+
+// balancesAtQuery считает балансы кошельков $1 на момент $2: последний снимок до $2 плюс проводки после него
+const balancesAtQuery = `SELECT w.id, (COALESCE(s.balance, 0) + COALESCE(p.amount, 0))::FLOAT
+   FROM wallets w
+   LEFT JOIN LATERAL (
+        SELECT taken_at, balance FROM balance_snapshots
+         WHERE account_id = 'wallet:' || w.id AND taken_at <= $2
+         ORDER BY taken_at DESC
+         LIMIT 1) s ON true
+   LEFT JOIN LATERAL (
+        SELECT SUM(amount) AS amount FROM ledger_postings
+         WHERE account_id = 'wallet:' || w.id AND created_at <= $2
+           AND created_at > COALESCE(s.taken_at, '-infinity')) p ON true
+  WHERE w.id = ANY($1)
+  ORDER BY w.id`
+
+// BalancesAt считает балансы кошельков uuids на момент at. Кошельков, которых нет, в ответе нет.
+func (postgres Postgres) BalancesAt(uuids []string, at time.Time) ([]WalletBalance, error) {
+	rows, err := postgres.pool.Query(context.Background(), balancesAtQuery, uuids, at)
+	if err != nil {
+		log.Println("error in BalancesAt method: ", err)
+		return nil, DBError{}
+	}
+
+	balances, err := pgx.CollectRows(rows, pgx.RowToStructByPos[WalletBalance])
+	if err != nil {
+		log.Println("error in BalancesAt method: ", err)
+		return nil, DBError{}
+	}
+
+	return balances, nil
+}
+
+// TakeBalanceSnapshots записывает снимки остатков на момент at для счетов,
+// по которым были проводки после их последнего снимка, и возвращает число снимков.
+// Повторный вызов с тем же at ничего не меняет.
+func (postgres Postgres) TakeBalanceSnapshots(at time.Time) (int64, error) {
+	tag, err := postgres.pool.Exec(context.Background(),
+		`INSERT INTO balance_snapshots (account_id, taken_at, balance)
+		 SELECT a.id, $1, COALESCE(s.balance, 0) + p.amount
+		   FROM ledger_accounts a
+		   LEFT JOIN LATERAL (
+		        SELECT taken_at, balance FROM balance_snapshots
+		         WHERE account_id = a.id AND taken_at <= $1
+		         ORDER BY taken_at DESC
+		         LIMIT 1) s ON true
+		   JOIN LATERAL (
+		        SELECT SUM(amount) AS amount, COUNT(*) AS postings FROM ledger_postings
+		         WHERE account_id = a.id AND created_at <= $1
+		           AND created_at > COALESCE(s.taken_at, '-infinity')) p ON p.postings > 0
+		 ON CONFLICT DO NOTHING`,
+		at)
+	if err != nil {
+		log.Println("error in TakeBalanceSnapshots method: ", err)
+		return 0, DBError{}
+	}
+
+	return tag.RowsAffected(), nil
+}
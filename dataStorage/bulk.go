@@ -0,0 +1,162 @@
+package datastorage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// WalletRecord - строка импорта/экспорта кошельков
+type WalletRecord struct {
+	WalletId string  `json:"walletId"`
+	Balance  float64 `json:"balance"`
+}
+
+type WalletsExist struct {
+	Ids []string
+}
+
+func (e WalletsExist) Error() string {
+	return "wallets already exist: " + strings.Join(e.Ids, ", ")
+}
+
+// ImportWallets создаёт кошельки с начальными балансами одной транзакцией через COPY.
+// Если хотя бы один кошелёк уже существует, ничего не создаётся.
+func (postgres Postgres) ImportWallets(records []WalletRecord) error {
+	ctx := context.Background()
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in ImportWallets method: ", err)
+		return DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "CREATE TEMP TABLE import_wallets (id TEXT NOT NULL, balance FLOAT NOT NULL) ON COMMIT DROP")
+	if err != nil {
+		log.Println("error in ImportWallets method: ", err)
+		return DBError{}
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"import_wallets"}, []string{"id", "balance"},
+		pgx.CopyFromSlice(len(records), func(i int) ([]any, error) {
+			return []any{records[i].WalletId, records[i].Balance}, nil
+		}))
+
+	if err != nil {
+		log.Println("error in ImportWallets method: ", err)
+		return DBError{}
+	}
+
+	rows, err := tx.Query(ctx, "SELECT i.id FROM import_wallets i JOIN wallets w ON w.id = i.id ORDER BY i.id")
+	if err != nil {
+		log.Println("error in ImportWallets method: ", err)
+		return DBError{}
+	}
+
+	exist, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		log.Println("error in ImportWallets method: ", err)
+		return DBError{}
+	}
+
+	if len(exist) > 0 {
+		return WalletsExist{Ids: exist}
+	}
+
+	_, err = tx.Exec(ctx, "INSERT INTO wallets (id, balance) SELECT id, TRUNC(balance::NUMERIC, 2) FROM import_wallets")
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return UUIDExists{}
+		}
+		log.Println("error in ImportWallets method: ", err)
+		return DBError{}
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO ledger_accounts (id, kind, wallet_id) SELECT 'wallet:' || id, 'wallet', id FROM import_wallets")
+	if err != nil {
+		log.Println("error in ImportWallets method: ", err)
+		return DBError{}
+	}
+
+	// начальный баланс записывается как пополнение, чтобы история операций сходилась с балансом
+	rows, err = tx.Query(ctx,
+		"INSERT INTO operations (wallet_id, amount) SELECT id, TRUNC(balance::NUMERIC, 2) FROM import_wallets WHERE balance > 0 RETURNING id")
+	if err != nil {
+		log.Println("error in ImportWallets method: ", err)
+		return DBError{}
+	}
+
+	deposits, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		log.Println("error in ImportWallets method: ", err)
+		return DBError{}
+	}
+
+	// все пополнения импорта - одна запись журнала против system:cash_in
+	if err = bookEntry(ctx, tx, entryImport, LedgerCashIn, deposits...); err != nil {
+		return err
+	}
+
+	// события создания идут тем же путём, что и события операций: outbox, webhooks и pg_notify
+	rows, err = tx.Query(ctx, "SELECT id, TRUNC(balance::NUMERIC, 2)::FLOAT FROM import_wallets ORDER BY id")
+	if err != nil {
+		log.Println("error in ImportWallets method: ", err)
+		return DBError{}
+	}
+
+	imported, err := pgx.CollectRows(rows, pgx.RowToStructByPos[WalletRecord])
+	if err != nil {
+		log.Println("error in ImportWallets method: ", err)
+		return DBError{}
+	}
+
+	for _, wallet := range imported {
+		if err = addEvent(ctx, tx, wallet.WalletId, EventWalletCreated, wallet.Balance, wallet.Balance); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Println("error in ImportWallets method: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
+
+// ExportWallets передаёт все кошельки по порядку id в функцию each
+func (postgres Postgres) ExportWallets(each func(record WalletRecord) error) error {
+
+	rows, err := postgres.pool.Query(context.Background(), "SELECT id, balance FROM wallets ORDER BY id")
+	if err != nil {
+		log.Println("error in ExportWallets method: ", err)
+		return DBError{}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record WalletRecord
+		if err = rows.Scan(&record.WalletId, &record.Balance); err != nil {
+			log.Println("error in ExportWallets method: ", err)
+			return DBError{}
+		}
+
+		if err = each(record); err != nil {
+			return err
+		}
+	}
+
+	if rows.Err() != nil {
+		log.Println("error in ExportWallets method: ", rows.Err())
+		return DBError{}
+	}
+
+	return nil
+}
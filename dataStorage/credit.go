@@ -0,0 +1,24 @@
+package datastorage
+
+import (
+	"context"
+	"log"
+)
+
+func (postgres Postgres) SetCreditLimit(uuid string, creditLimit float64) error {
+
+	cmdTag, err := postgres.pool.Exec(context.Background(),
+		"UPDATE wallets SET credit_limit = TRUNC($1::NUMERIC, 2) WHERE id = $2",
+		creditLimit, uuid)
+
+	if err != nil {
+		log.Println("error in SetCreditLimit method: ", err)
+		return DBError{}
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return UUIDUndefined{}
+	}
+
+	return nil
+}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"os"
+	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -34,13 +36,124 @@ func (_ DBError) Error() string {
 
 type WalletStorage interface {
 	Get(uuid string) (bool, float64, error)
+	GetWallet(uuid string) (bool, Wallet, error)
 	Check(uuid string) (bool, error)
-	ChangeBalance(sum float64, uuid string) (bool, error)
-	CreateWallet(uuid string) error
+	ChangeBalance(sum float64, uuid string, details OperationDetails) (bool, Receipt, error)
+	Transfer(sum float64, from, to string, details OperationDetails) (bool, Receipt, error)
+	CreateWallet(uuid string, options WalletOptions) error
+	ListWallets(filter WalletFilter) (WalletPage, error)
+	UpdateMetadata(uuid string, changes map[string]*string) (Wallet, error)
 }
 
+// walletState - состояние кошелька, по которому проверяется операция
+type walletState struct {
+	balance       float64
+	creditLimit   float64
+	status        string
+	blockDeposits bool
+	limits        Limits
+	owner         string
+	createdAt     time.Time
+	metadata      map[string]string
+	version       int64
+}
+
+// walletStateColumns и walletStateFrom выбирают кошелёк вместе с действующими лимитами:
+// персональными, если заданы, иначе лимитами тарифа.
+const (
+	walletStateColumns = `w.balance, w.credit_limit, w.status, w.block_deposits, w.tier,
+        COALESCE(l.max_withdrawal, t.max_withdrawal),
+        COALESCE(l.daily_withdrawal, t.daily_withdrawal),
+        COALESCE(l.monthly_withdrawal, t.monthly_withdrawal),
+        COALESCE(l.operations_per_minute, t.operations_per_minute),
+        w.owner, w.created_at, w.metadata, w.version`
+	walletStateFrom = `
+   FROM wallets w
+   JOIN wallet_tiers t ON t.name = w.tier
+   LEFT JOIN wallet_limits l ON l.wallet_id = w.id`
+)
+
+const walletStateQuery = "SELECT " + walletStateColumns + walletStateFrom + `
+  WHERE w.id = $1`
+
+// fields возвращает указатели на поля в порядке walletStateColumns
+func (state *walletState) fields() []any {
+	return []any{&state.balance, &state.creditLimit, &state.status, &state.blockDeposits, &state.limits.Tier,
+		&state.limits.MaxWithdrawal, &state.limits.DailyWithdrawal,
+		&state.limits.MonthlyWithdrawal, &state.limits.OperationsPerMinute,
+		&state.owner, &state.createdAt, &state.metadata, &state.version}
+}
+
+func scanWalletState(row pgx.Row) (bool, walletState, error) {
+	var state walletState
+	err := row.Scan(state.fields()...)
+
+	if err == pgx.ErrNoRows {
+		return false, walletState{}, nil
+	}
+
+	if err != nil {
+		return false, walletState{}, err
+	}
+
+	return true, state, nil
+}
+
+func (state walletState) wallet(uuid string) Wallet {
+	wallet := Wallet{
+		Id:              uuid,
+		Balance:         state.balance,
+		CreditLimit:     state.creditLimit,
+		AvailableCredit: state.creditLimit,
+		Status:          state.status,
+		Tier:            state.limits.Tier,
+		Owner:           state.owner,
+		CreatedAt:       state.createdAt,
+		Metadata:        state.metadata,
+		Version:         state.version,
+	}
+
+	if state.balance < 0 {
+		wallet.AvailableCredit = math.Max(state.creditLimit+state.balance, 0)
+	}
+
+	return wallet
+}
+
+// lockWallet блокирует строку кошелька до конца транзакции и возвращает его состояние
+func lockWallet(ctx context.Context, tx pgx.Tx, uuid string) (bool, walletState, error) {
+	return scanWalletState(tx.QueryRow(ctx, walletStateQuery+" FOR UPDATE OF w", uuid))
+}
+
+type Wallet struct {
+	Id              string            `json:"walletId"`
+	Balance         float64           `json:"balance"`
+	CreditLimit     float64           `json:"creditLimit"`
+	AvailableCredit float64           `json:"availableCredit"`
+	Status          string            `json:"status"`
+	Tier            string            `json:"tier"`
+	Owner           string            `json:"owner,omitempty"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	Version         int64             `json:"version"` // растёт при каждом изменении кошелька, отдаётся как ETag
+}
+
+// WalletOptions - необязательные параметры нового кошелька
+type WalletOptions struct {
+	Owner    string            `json:"owner,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// IdempotencyKey - ключ запроса, который отмечается проведённым вместе с созданием кошелька
+	IdempotencyKey string `json:"-"`
+}
+
+// maxTargetLocks - сколько блокировок объектов аудита (см. LockAuditTarget) могут держаться одновременно
+const maxTargetLocks = 16
+
 type Postgres struct {
 	pool *pgxpool.Pool
+	// locks - отдельный пул для соединений, которые держат advisory lock объектов аудита
+	locks *pgxpool.Pool
 }
 
 func NewPostgres(host, port, user, password, dbName string) (Postgres, error) {
@@ -53,7 +166,24 @@ func NewPostgres(host, port, user, password, dbName string) (Postgres, error) {
 		return Postgres{}, errors.New("database not created")
 	}
 
-	return Postgres{pool: pool}, nil
+	config, err := pgxpool.ParseConfig(psqlconn)
+	if err != nil {
+		return Postgres{}, errors.New("database not created")
+	}
+	config.MaxConns = maxTargetLocks
+
+	locks, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		return Postgres{}, errors.New("database not created")
+	}
+
+	return Postgres{pool: pool, locks: locks}, nil
+}
+
+// NewPostgresFromEnv подключается к базе по переменным POSTGRES_HOST, POSTGRES_USER, POSTGRES_PASSWORD и POSTGRES_DB
+func NewPostgresFromEnv() (Postgres, error) {
+	return NewPostgres(os.Getenv("POSTGRES_HOST"), "5432", os.Getenv("POSTGRES_USER"),
+		os.Getenv("POSTGRES_PASSWORD"), os.Getenv("POSTGRES_DB"))
 }
 
 func (postgres Postgres) Get(uuid string) (bool, float64, error) {
@@ -76,6 +206,22 @@ func (postgres Postgres) Get(uuid string) (bool, float64, error) {
 	}
 }
 
+func (postgres Postgres) GetWallet(uuid string) (bool, Wallet, error) {
+
+	got, state, err := scanWalletState(postgres.pool.QueryRow(context.Background(), walletStateQuery, uuid))
+
+	if err != nil {
+		log.Println("error in GetWallet method: ", err)
+		return false, Wallet{}, DBError{}
+	}
+
+	if !got {
+		return false, Wallet{}, nil
+	}
+
+	return true, state.wallet(uuid), nil
+}
+
 func (postgres Postgres) Check(uuid string) (bool, error) {
 
 	var balance float64
@@ -97,43 +243,91 @@ func (postgres Postgres) Check(uuid string) (bool, error) {
 
 }
 
-func (postgres Postgres) ChangeBalance(sum float64, uuid string) (bool, error) {
-
-	cmdTag, err := postgres.pool.Exec(context.Background(),
-		"UPDATE wallets SET balance = TRUNC( (balance + $1)::NUMERIC , 2) WHERE id = $2",
-		sum, uuid)
+// ChangeBalance пополняет (sum > 0) или списывает (sum < 0) баланс. Со списания
+// удерживается комиссия по правилам тарифа кошелька.
+func (postgres Postgres) ChangeBalance(sum float64, uuid string, details OperationDetails) (bool, Receipt, error) {
+	ctx := context.Background()
 
+	tx, err := postgres.pool.Begin(ctx)
 	if err != nil {
+		log.Println("error in ChangeBalance method: ", err)
+		return false, Receipt{}, DBError{}
+	}
+	defer tx.Rollback(ctx)
 
-		if err.(*pgconn.PgError).Code == "23514" {
-			log.Println("balance too small for operation ")
-			return false, nil
+	var fee float64
+	if sum < 0 {
+		if err = lockWallets(ctx, tx, []string{uuid}); err != nil {
+			return false, Receipt{}, err
+		}
 
-		} else {
-			log.Println("error in ChangeBalance method: ", err)
-			log.Println("error code: ")
-			return false, DBError{}
+		if fee, err = feeFor(ctx, tx, OperationWithdraw, uuid, -sum); err != nil {
+			return false, Receipt{}, err
 		}
 	}
 
-	if cmdTag.RowsAffected() == 0 {
+	id, err := cashOperation(ctx, tx, sum, uuid, details, fee)
+
+	if errors.As(err, &InsufficientFunds{}) {
+		return false, Receipt{}, nil
+	}
+
+	if err != nil {
+		return false, Receipt{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
 		log.Println("error in ChangeBalance method: ", err)
-		return false, UUIDUndefined{}
+		return false, Receipt{}, DBError{}
 	}
 
-	return true, nil
+	return true, newReceipt(id, math.Abs(sum), fee), nil
 }
 
-func (postgres Postgres) CreateWallet(uuid string) error {
+func (postgres Postgres) CreateWallet(uuid string, options WalletOptions) error {
+	ctx := context.Background()
 
-	_, err := postgres.pool.Exec(context.Background(),
-		"INSERT INTO wallets (id, balance) VALUES ($1,0)", uuid)
+	if err := ValidateMetadata(options.Metadata); err != nil {
+		return err
+	}
+
+	if options.Metadata == nil {
+		options.Metadata = map[string]string{}
+	}
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in CreateWallet method: ", err)
+		return DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	// вместе с кошельком открывается его счёт в журнале
+	_, err = tx.Exec(ctx,
+		`WITH wallet AS (
+		     INSERT INTO wallets (id, balance, owner, metadata) VALUES ($1,0,$2,$3) RETURNING id)
+		 INSERT INTO ledger_accounts (id, kind, wallet_id) SELECT 'wallet:' || id, 'wallet', id FROM wallet`,
+		uuid, options.Owner, options.Metadata)
 
 	if err != nil {
 		log.Println("error in CreateWallet method: ", err)
 		return DBError{}
 	}
 
+	if err = addEvent(ctx, tx, uuid, EventWalletCreated, 0, 0); err != nil {
+		return err
+	}
+
+	if err = markIdempotencyKeyApplied(ctx, tx, options.IdempotencyKey); err != nil {
+		log.Println("error in CreateWallet method: ", err)
+		return DBError{}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Println("error in CreateWallet method: ", err)
+		return DBError{}
+	}
+
 	return nil
 
 }
@@ -1 +1,253 @@
 package datastorage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000010_add_b.up.sql":      {Data: []byte("B up")},
+		"000002_create_a.up.sql":   {Data: []byte("A up")},
+		"000002_create_a.down.sql": {Data: []byte("A down")},
+		"000010_add_b.down.sql":    {Data: []byte("B down")},
+		"README.md":                {Data: []byte("not a migration")},
+	}
+
+	migrations, err := LoadMigrations(fsys)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Migration{
+		{Version: 2, Name: "create_a", Up: "A up", Down: "A down"},
+		{Version: 10, Name: "add_b", Up: "B up", Down: "B down"},
+	}, migrations)
+}
+
+func TestWrongMigrations(t *testing.T) {
+	_, err := LoadMigrations(fstest.MapFS{"000001_a.down.sql": {Data: []byte("down")}})
+	assert.EqualError(t, err, "migration 1 has no up file")
+
+	_, err = LoadMigrations(fstest.MapFS{
+		"000001_a.up.sql": {Data: []byte("up")},
+		"000001_b.up.sql": {Data: []byte("up")},
+	})
+	assert.EqualError(t, err, "two migrations with version 1")
+}
+
+func TestWalletListQuery(t *testing.T) {
+	minBalance := 10.0
+
+	query, args, countQuery, countArgs, err := walletListQuery(WalletFilter{
+		MinBalance: &minBalance,
+		IdPrefix:   "a_b%",
+		Sort:       SortByBalance,
+		Desc:       true,
+		After:      &WalletCursor{Id: "a_b1", Balance: 50},
+		Limit:      20,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "SELECT count(*) FROM wallets w\n  WHERE w.balance >= $1 AND w.id LIKE $2", countQuery)
+	assert.Equal(t, []any{10.0, `a\_b\%%`}, countArgs)
+
+	assert.Contains(t, query, "WHERE w.balance >= $1 AND w.id LIKE $2 AND (w.balance, w.id) < ($3, $4)")
+	assert.Contains(t, query, "ORDER BY w.balance DESC, w.id DESC\n  LIMIT $5")
+	assert.Equal(t, []any{10.0, `a\_b\%%`, 50.0, "a_b1", 21}, args)
+
+	query, args, _, _, err = walletListQuery(WalletFilter{After: &WalletCursor{Id: "a"}, Limit: 1})
+	require.NoError(t, err)
+
+	assert.Contains(t, query, "WHERE w.id > $1\n  ORDER BY w.id ASC\n  LIMIT $2")
+	assert.Equal(t, []any{"a", 2}, args)
+
+	_, _, _, _, err = walletListQuery(WalletFilter{Sort: "owner"})
+	assert.EqualError(t, err, `unknown sort "owner"`)
+}
+
+func TestValidateMetadata(t *testing.T) {
+	assert.NoError(t, ValidateMetadata(nil))
+	assert.NoError(t, ValidateMetadata(map[string]string{"customer_id": "42", "campaign-2026": ""}))
+
+	assert.EqualError(t, ValidateMetadata(map[string]string{"": "x"}),
+		`wrong metadata: key "" must be 1 to 40 letters, digits, _ or -`)
+	assert.EqualError(t, ValidateMetadata(map[string]string{"a": strings.Repeat("я", 501)}),
+		`wrong metadata: value of "a" is longer than 500 characters`)
+
+	many := map[string]string{}
+	for i := 0; i <= MaxMetadataKeys; i++ {
+		many[fmt.Sprint("key", i)] = "x"
+	}
+	assert.EqualError(t, ValidateMetadata(many), "wrong metadata: more than 50 keys")
+
+	large := map[string]string{}
+	for i := 0; i < 20; i++ {
+		large[fmt.Sprint("key", i)] = strings.Repeat("x", 500)
+	}
+	assert.EqualError(t, ValidateMetadata(large), "wrong metadata: larger than 8192 bytes")
+}
+
+func TestMergeMetadata(t *testing.T) {
+	value := "new"
+	metadata := map[string]string{"a": "old", "b": "old"}
+
+	merged := mergeMetadata(metadata, map[string]*string{"a": &value, "b": nil, "c": &value})
+
+	assert.Equal(t, map[string]string{"a": "new", "c": "new"}, merged)
+	assert.Equal(t, map[string]string{"a": "old", "b": "old"}, metadata)
+}
+
+func TestWalletListMetadataQuery(t *testing.T) {
+	query, args, _, _, err := walletListQuery(WalletFilter{Metadata: map[string]string{"campaign": "spring"}, Limit: 10})
+	require.NoError(t, err)
+
+	assert.Contains(t, query, "WHERE w.metadata @> $1::jsonb")
+	assert.Equal(t, []any{`{"campaign":"spring"}`, 11}, args)
+}
+
+func TestTrialBalance(t *testing.T) {
+	report := trialBalance([]AccountBalance{
+		{Account: LedgerCashIn, Debit: 100.1},
+		{Account: LedgerCashOut, Credit: 0.2},
+		{Account: "wallets", Debit: 0.2, Credit: 100.1},
+	})
+
+	assert.Equal(t, 100.3, report.Debit)
+	assert.Equal(t, 100.3, report.Credit)
+	assert.True(t, report.Balanced)
+
+	report = trialBalance([]AccountBalance{{Account: "wallets", Credit: 10}})
+	assert.False(t, report.Balanced)
+}
+
+func TestFeeRule(t *testing.T) {
+	low, high := 1.0, 5.0
+	rule := FeeRule{OperationType: OperationWithdraw, Flat: 0.3, Percent: 1.5, MinFee: &low, MaxFee: &high}
+
+	assert.Equal(t, 1.0, rule.Fee(10))
+	assert.Equal(t, 1.8, rule.Fee(100))
+	assert.Equal(t, 5.0, rule.Fee(1000))
+	assert.Equal(t, 0.35, FeeRule{Percent: 3.3}.Fee(10.7))
+
+	assert.NoError(t, ValidateFeeRule(rule))
+	assert.EqualError(t, ValidateFeeRule(FeeRule{OperationType: OperationDeposit}),
+		"wrong fee rule: operationType must be WITHDRAW or TRANSFER")
+	assert.EqualError(t, ValidateFeeRule(FeeRule{OperationType: OperationTransfer, Percent: 101}),
+		"wrong fee rule: percent must be from 0 to 100")
+	assert.EqualError(t, ValidateFeeRule(FeeRule{OperationType: OperationTransfer, MinFee: &high, MaxFee: &low}),
+		"wrong fee rule: minFee must not be more than maxFee")
+}
+
+func TestScheduleOperation(t *testing.T) {
+	schedule := Schedule{Id: 5, WalletId: "1", OperationType: OperationTransfer, Amount: 9.99, ToWalletId: "2", Reference: "subscription-7"}
+
+	op := schedule.operation(time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, Operation{
+		WalletId:         "1",
+		OperationType:    OperationTransfer,
+		Amount:           9.99,
+		ToWalletId:       "2",
+		OperationDetails: OperationDetails{Reference: "subscription-7", ExternalId: "schedule-5-1793523600"},
+	}, op)
+}
+
+func TestScheduleRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Minute, scheduleRetryDelay(1))
+	assert.Equal(t, 2*time.Minute, scheduleRetryDelay(2))
+	assert.Equal(t, 8*time.Minute, scheduleRetryDelay(MaxScheduleAttempts-1))
+}
+
+func TestInterestRule(t *testing.T) {
+	assert.NoError(t, ValidateInterestRule(InterestRule{Tier: "savings", Rate: 4.5, DayCount: DayCount30E360}))
+	assert.EqualError(t, ValidateInterestRule(InterestRule{Rate: 4.5, DayCount: DayCountActual365}),
+		"wrong interest rule: tier is required")
+	assert.EqualError(t, ValidateInterestRule(InterestRule{Tier: "savings", Rate: 101, DayCount: DayCountActual365}),
+		"wrong interest rule: rate must be from 0 to 100")
+	assert.EqualError(t, ValidateInterestRule(InterestRule{Tier: "savings", Rate: 4.5, DayCount: "30/365"}),
+		"wrong interest rule: dayCount must be ACT/365, ACT/360, ACT/ACT or 30E/360")
+}
+
+func TestDayFraction(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	assert.Equal(t, 1.0/365, DayFraction(DayCountActual365, day(2028, 2, 29)))
+	assert.Equal(t, 1.0/360, DayFraction(DayCountActual360, day(2026, 10, 1)))
+	assert.Equal(t, 1.0/366, DayFraction(DayCountActual, day(2028, 7, 1)))
+	assert.Equal(t, 1.0/365, DayFraction(DayCountActual, day(2026, 7, 1)))
+
+	// по 30E/360 каждый месяц приносит ровно 30 дней
+	for _, month := range []time.Month{time.January, time.February, time.April} {
+		total := 0.0
+		for d := day(2026, month, 1); d.Month() == month; d = d.AddDate(0, 0, 1) {
+			total += DayFraction(DayCount30E360, d)
+		}
+		assert.InDelta(t, 30.0/360, total, 1e-12, month.String())
+	}
+
+	assert.Equal(t, 0.0, DayFraction(DayCount30E360, day(2026, 1, 31)))
+	assert.Equal(t, 3.0/360, DayFraction(DayCount30E360, day(2026, 2, 28)))
+	assert.Equal(t, 1.0/360, DayFraction(DayCount30E360, day(2026, 12, 30)))
+	assert.Equal(t, 0.0, DayFraction(DayCount30E360, day(2026, 12, 31)))
+}
+
+func TestSplitCents(t *testing.T) {
+	credited, carry := splitCents(0.07)
+	assert.Equal(t, 0.07, credited)
+	assert.InDelta(t, 0, carry, 1e-12)
+
+	// остаток копится и зачисляется, когда наберётся копейка
+	carry = 0
+	total := 0.0
+	for i := 0; i < 30; i++ {
+		credited, carry = splitCents(0.0034 + carry)
+		total += credited
+	}
+	assert.InDelta(t, 0.10, total, 1e-9)
+	assert.InDelta(t, 0.002, carry, 1e-9)
+}
+
+func TestAuditHash(t *testing.T) {
+	entry := AuditEntry{
+		Id:        1,
+		CreatedAt: time.Date(2026, 10, 2, 9, 0, 0, 123456000, time.UTC),
+		Actor:     "ivan",
+		RequestId: "req-1",
+		Method:    "POST",
+		Path:      "/api/v1/wallets/asd1/freeze",
+		Target:    "wallet:asd1",
+		Status:    200,
+		Request:   []byte(`{"reason":"fraud","blockDeposits":false}`),
+		Before:    []byte(`{"status":"active","balance":10.5}`),
+		PrevHash:  AuditGenesis,
+	}
+
+	hash, err := auditHash(entry)
+	require.NoError(t, err)
+	assert.Len(t, hash, 64)
+
+	// JSONB не хранит пробелы и порядок ключей, а время читается в другом поясе - hash тот же
+	stored := entry
+	stored.CreatedAt = entry.CreatedAt.In(time.FixedZone("UTC+3", 3*3600))
+	stored.Request = []byte(`{"blockDeposits": false, "reason": "fraud"}`)
+	stored.Before = []byte(`{"balance": 10.5, "status": "active"}`)
+
+	same, err := auditHash(stored)
+	require.NoError(t, err)
+	assert.Equal(t, hash, same)
+
+	entry.Hash = hash
+	assert.Empty(t, verifyAuditEntry(entry, 1, AuditGenesis))
+	assert.Equal(t, "entries before it are missing", verifyAuditEntry(entry, 2, AuditGenesis))
+	assert.Equal(t, "prevHash does not match the previous entry", verifyAuditEntry(entry, 1, hash))
+
+	entry.Status = 500
+	assert.Equal(t, "entry was modified", verifyAuditEntry(entry, 1, AuditGenesis))
+}
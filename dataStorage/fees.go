@@ -0,0 +1,208 @@
+package datastorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// FeeRule - правило комиссии для типа операции и тарифа (пустой Tier - для всех тарифов).
+// Комиссия - Flat плюс Percent процентов от суммы, но не меньше MinFee и не больше MaxFee.
+type FeeRule struct {
+	Tier          string   `json:"tier,omitempty"`
+	OperationType string   `json:"operationType"`
+	Flat          float64  `json:"flat"`
+	Percent       float64  `json:"percent"`
+	MinFee        *float64 `json:"minFee,omitempty"`
+	MaxFee        *float64 `json:"maxFee,omitempty"`
+}
+
+// Receipt - итог операции: Gross - сумма операции, Fee - удержанная из неё комиссия,
+// Net - сколько выдано при списании или зачислено получателю перевода
+type Receipt struct {
+	OperationId int64   `json:"operationId"`
+	Gross       float64 `json:"gross"`
+	Fee         float64 `json:"fee"`
+	Net         float64 `json:"net"`
+}
+
+type WrongFeeRule struct {
+	Reason string
+}
+
+func (e WrongFeeRule) Error() string {
+	return "wrong fee rule: " + e.Reason
+}
+
+type FeeRuleUndefined struct {
+}
+
+func (_ FeeRuleUndefined) Error() string {
+	return "fee rule undefined"
+}
+
+type FeeExceedsAmount struct {
+	Fee float64
+}
+
+func (e FeeExceedsAmount) Error() string {
+	return fmt.Sprintf("fee %v is not less than amount", e.Fee)
+}
+
+func newReceipt(id int64, amount, fee float64) Receipt {
+	return Receipt{OperationId: id, Gross: cents(amount), Fee: fee, Net: cents(amount - fee)}
+}
+
+// Fee считает комиссию с суммы amount, округлённую до копеек
+func (rule FeeRule) Fee(amount float64) float64 {
+	fee := rule.Flat + amount*rule.Percent/100
+
+	if rule.MinFee != nil {
+		fee = max(fee, *rule.MinFee)
+	}
+
+	if rule.MaxFee != nil {
+		fee = min(fee, *rule.MaxFee)
+	}
+
+	return cents(fee)
+}
+
+// ValidateFeeRule проверяет тип операции и границы правила
+func ValidateFeeRule(rule FeeRule) error {
+	wrong := func(value float64) bool {
+		return math.IsNaN(value) || math.IsInf(value, 0) || value < 0
+	}
+
+	switch {
+	case rule.OperationType != OperationWithdraw && rule.OperationType != OperationTransfer:
+		return WrongFeeRule{Reason: "operationType must be WITHDRAW or TRANSFER"}
+	case wrong(rule.Flat):
+		return WrongFeeRule{Reason: "flat must not be negative"}
+	case wrong(rule.Percent) || rule.Percent > 100:
+		return WrongFeeRule{Reason: "percent must be from 0 to 100"}
+	case rule.MinFee != nil && wrong(*rule.MinFee):
+		return WrongFeeRule{Reason: "minFee must not be negative"}
+	case rule.MaxFee != nil && wrong(*rule.MaxFee):
+		return WrongFeeRule{Reason: "maxFee must not be negative"}
+	case rule.MinFee != nil && rule.MaxFee != nil && *rule.MinFee > *rule.MaxFee:
+		return WrongFeeRule{Reason: "minFee must not be more than maxFee"}
+	}
+
+	return nil
+}
+
+// feeFor считает комиссию операции operationType на amount с кошелька uuid по правилу его тарифа
+// или общему правилу. Комиссия должна быть меньше суммы, иначе операция отклоняется.
+// Кошелёк должен быть уже заблокирован (lockWallets), чтобы тариф не сменился до проведения операции.
+func feeFor(ctx context.Context, tx pgx.Tx, operationType, uuid string, amount float64) (float64, error) {
+	var rule FeeRule
+	err := tx.QueryRow(ctx,
+		`SELECT r.flat::FLOAT, r.percent::FLOAT, r.min_fee::FLOAT, r.max_fee::FLOAT
+		   FROM wallets w JOIN fee_rules r ON r.operation_type = $1 AND (r.tier = w.tier OR r.tier IS NULL)
+		  WHERE w.id = $2
+		  ORDER BY r.tier NULLS LAST
+		  LIMIT 1`,
+		operationType, uuid).Scan(&rule.Flat, &rule.Percent, &rule.MinFee, &rule.MaxFee)
+
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+
+	if err != nil {
+		log.Println("error in feeFor: ", err)
+		return 0, DBError{}
+	}
+
+	fee := rule.Fee(amount)
+
+	if fee > 0 && fee >= cents(amount) {
+		return 0, FeeExceedsAmount{Fee: fee}
+	}
+
+	return fee, nil
+}
+
+// setFee записывает комиссию, удержанную из операции id
+func setFee(ctx context.Context, tx pgx.Tx, id int64, fee float64) error {
+	if fee == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE operations SET fee = $1 WHERE id = $2", fee, id); err != nil {
+		log.Println("error in setFee: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
+
+func (postgres Postgres) ListFeeRules() ([]FeeRule, error) {
+	rows, err := postgres.pool.Query(context.Background(),
+		`SELECT COALESCE(tier, ''), operation_type, flat::FLOAT, percent::FLOAT, min_fee::FLOAT, max_fee::FLOAT
+		   FROM fee_rules
+		  ORDER BY operation_type, tier NULLS FIRST`)
+	if err != nil {
+		log.Println("error in ListFeeRules method: ", err)
+		return nil, DBError{}
+	}
+
+	rules, err := pgx.CollectRows(rows, pgx.RowToStructByPos[FeeRule])
+	if err != nil {
+		log.Println("error in ListFeeRules method: ", err)
+		return nil, DBError{}
+	}
+
+	return rules, nil
+}
+
+// SetFeeRule создаёт или заменяет правило для пары тариф и тип операции
+func (postgres Postgres) SetFeeRule(rule FeeRule) error {
+	if err := ValidateFeeRule(rule); err != nil {
+		return err
+	}
+
+	_, err := postgres.pool.Exec(context.Background(),
+		`INSERT INTO fee_rules (tier, operation_type, flat, percent, min_fee, max_fee)
+		 VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6)
+		 ON CONFLICT ((COALESCE(tier, '')), operation_type) DO UPDATE SET
+		     flat = EXCLUDED.flat,
+		     percent = EXCLUDED.percent,
+		     min_fee = EXCLUDED.min_fee,
+		     max_fee = EXCLUDED.max_fee`,
+		rule.Tier, rule.OperationType, rule.Flat, rule.Percent, rule.MinFee, rule.MaxFee)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return TierUndefined{}
+	}
+
+	if err != nil {
+		log.Println("error in SetFeeRule method: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
+
+func (postgres Postgres) DeleteFeeRule(tier, operationType string) error {
+	tag, err := postgres.pool.Exec(context.Background(),
+		"DELETE FROM fee_rules WHERE COALESCE(tier, '') = $1 AND operation_type = $2",
+		tier, operationType)
+
+	if err != nil {
+		log.Println("error in DeleteFeeRule method: ", err)
+		return DBError{}
+	}
+
+	if tag.RowsAffected() == 0 {
+		return FeeRuleUndefined{}
+	}
+
+	return nil
+}
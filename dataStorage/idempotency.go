@@ -0,0 +1,108 @@
+package datastorage
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// IdempotentResponse - сохранённый ответ на запрос с заголовком Idempotency-Key.
+// Status равен 0, пока первый запрос с этим ключом ещё выполняется.
+type IdempotentResponse struct {
+	RequestHash string
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// ReserveIdempotencyKey занимает ключ для нового запроса. Если ключ уже занят,
+// возвращает false и сохранённый ответ. Ключи старше суток можно использовать повторно.
+func (postgres Postgres) ReserveIdempotencyKey(key, requestHash string) (bool, IdempotentResponse, error) {
+
+	ctx := context.Background()
+
+	var reserved string
+	err := postgres.pool.QueryRow(ctx,
+		`INSERT INTO idempotency_keys (key, request_hash) VALUES ($1, $2)
+		 ON CONFLICT (key) DO UPDATE
+		    SET request_hash = EXCLUDED.request_hash, status = NULL, content_type = '', body = NULL,
+		        applied_at = NULL, created_at = now()
+		  WHERE idempotency_keys.created_at < now() - interval '24 hours'
+		 RETURNING key`,
+		key, requestHash).Scan(&reserved)
+
+	if err == nil {
+		return true, IdempotentResponse{RequestHash: requestHash}, nil
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		log.Println("error in ReserveIdempotencyKey method: ", err)
+		return false, IdempotentResponse{}, DBError{}
+	}
+
+	var saved IdempotentResponse
+	var status *int
+	err = postgres.pool.QueryRow(ctx,
+		"SELECT request_hash, status, content_type, body FROM idempotency_keys WHERE key = $1",
+		key).Scan(&saved.RequestHash, &status, &saved.ContentType, &saved.Body)
+
+	if err != nil {
+		log.Println("error in ReserveIdempotencyKey method: ", err)
+		return false, IdempotentResponse{}, DBError{}
+	}
+
+	if status != nil {
+		saved.Status = *status
+	}
+
+	return false, saved, nil
+}
+
+// markIdempotencyKeyApplied отмечает в транзакции операции, что запрос с ключом проведён.
+// Отметка фиксируется вместе с операцией, поэтому такой ключ уже не освободится
+func markIdempotencyKeyApplied(ctx context.Context, tx pgx.Tx, key string) error {
+	if key == "" {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx,
+		"UPDATE idempotency_keys SET applied_at = now() WHERE key = $1 AND applied_at IS NULL",
+		key)
+
+	return err
+}
+
+// SaveIdempotentResponse сохраняет ответ на запрос, для которого был занят ключ
+func (postgres Postgres) SaveIdempotentResponse(key string, response IdempotentResponse) error {
+
+	_, err := postgres.pool.Exec(context.Background(),
+		"UPDATE idempotency_keys SET status = $2, content_type = $3, body = $4 WHERE key = $1",
+		key, response.Status, response.ContentType, response.Body)
+
+	if err != nil {
+		log.Println("error in SaveIdempotentResponse method: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey освобождает ключ, ответ на который не сохраняется,
+// чтобы повтор запроса с этим ключом выполнился заново. Ключ операции, которая
+// уже проведена (applied_at), не освобождается: возвращает false, и повторы
+// получают IDEMPOTENCY_IN_PROGRESS, пока клиент не сверит результат
+func (postgres Postgres) ReleaseIdempotencyKey(key string) (bool, error) {
+
+	tag, err := postgres.pool.Exec(context.Background(),
+		"DELETE FROM idempotency_keys WHERE key = $1 AND status IS NULL AND applied_at IS NULL",
+		key)
+
+	if err != nil {
+		log.Println("error in ReleaseIdempotencyKey method: ", err)
+		return false, DBError{}
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,321 @@
+package datastorage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Способы подсчёта дней: какую долю годовой ставки приносит один день
+const (
+	DayCountActual365 = "ACT/365"
+	DayCountActual360 = "ACT/360"
+	DayCountActual    = "ACT/ACT"
+	// каждый месяц считается за 30 дней: 31-е число не приносит процентов, а последний день февраля - за оставшиеся до 30
+	DayCount30E360 = "30E/360"
+)
+
+// InterestRule - годовая ставка Rate в процентах для кошельков тарифа Tier
+type InterestRule struct {
+	Tier     string  `json:"tier"`
+	Rate     float64 `json:"rate"`
+	DayCount string  `json:"dayCount"`
+}
+
+// Accrual - начисление процентов кошельку за день Period на баланс конца дня.
+// Interest - точная сумма, Amount - зачисленная до копеек, Carry - остаток, перешедший на следующий день.
+type Accrual struct {
+	WalletId    string    `json:"walletId"`
+	Period      time.Time `json:"period"`
+	Balance     float64   `json:"balance"`
+	Rate        float64   `json:"rate"`
+	DayCount    string    `json:"dayCount"`
+	Interest    float64   `json:"interest"`
+	Amount      float64   `json:"amount"`
+	Carry       float64   `json:"carry"`
+	OperationId *int64    `json:"operationId,omitempty"`
+}
+
+type WrongInterestRule struct {
+	Reason string
+}
+
+func (e WrongInterestRule) Error() string {
+	return "wrong interest rule: " + e.Reason
+}
+
+type InterestRuleUndefined struct {
+}
+
+func (_ InterestRuleUndefined) Error() string {
+	return "interest rule undefined"
+}
+
+// ValidateInterestRule проверяет ставку и способ подсчёта дней
+func ValidateInterestRule(rule InterestRule) error {
+	switch {
+	case rule.Tier == "":
+		return WrongInterestRule{Reason: "tier is required"}
+	case math.IsNaN(rule.Rate) || rule.Rate < 0 || rule.Rate > 100:
+		return WrongInterestRule{Reason: "rate must be from 0 to 100"}
+	}
+
+	switch rule.DayCount {
+	case DayCountActual365, DayCountActual360, DayCountActual, DayCount30E360:
+		return nil
+	}
+
+	return WrongInterestRule{Reason: "dayCount must be ACT/365, ACT/360, ACT/ACT or 30E/360"}
+}
+
+// DayFraction возвращает долю года, которую составляет день day
+func DayFraction(dayCount string, day time.Time) float64 {
+	switch dayCount {
+	case DayCountActual360:
+		return 1.0 / 360
+	case DayCountActual:
+		return 1 / float64(time.Date(day.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay())
+	case DayCount30E360:
+		// номер дня в 30-дневном месяце: последний день месяца - всегда 30-й
+		position := func(t time.Time) int {
+			if t.AddDate(0, 0, 1).Day() == 1 {
+				return 30
+			}
+			return min(t.Day(), 30)
+		}
+
+		previous := 0
+		if day.Day() > 1 {
+			previous = position(day.AddDate(0, 0, -1))
+		}
+		return float64(position(day)-previous) / 360
+	}
+
+	return 1.0 / 365
+}
+
+// splitCents делит сумму на зачисляемую часть до копеек и остаток меньше копейки
+func splitCents(amount float64) (float64, float64) {
+	// погрешность float не должна съедать копейку: 0.07 * 100 = 7.000000000000001
+	credited := math.Floor(amount*100+1e-9) / 100
+	return credited, amount - credited
+}
+
+func (postgres Postgres) ListInterestRules() ([]InterestRule, error) {
+	rows, err := postgres.pool.Query(context.Background(),
+		"SELECT tier, rate::FLOAT, day_count FROM interest_rules ORDER BY tier")
+	if err != nil {
+		log.Println("error in ListInterestRules method: ", err)
+		return nil, DBError{}
+	}
+
+	rules, err := pgx.CollectRows(rows, pgx.RowToStructByPos[InterestRule])
+	if err != nil {
+		log.Println("error in ListInterestRules method: ", err)
+		return nil, DBError{}
+	}
+
+	return rules, nil
+}
+
+// SetInterestRule создаёт или заменяет ставку тарифа. Уже сделанные начисления не пересчитываются.
+func (postgres Postgres) SetInterestRule(rule InterestRule) error {
+	if err := ValidateInterestRule(rule); err != nil {
+		return err
+	}
+
+	_, err := postgres.pool.Exec(context.Background(),
+		`INSERT INTO interest_rules (tier, rate, day_count) VALUES ($1, $2, $3)
+		 ON CONFLICT (tier) DO UPDATE SET rate = EXCLUDED.rate, day_count = EXCLUDED.day_count`,
+		rule.Tier, rule.Rate, rule.DayCount)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return TierUndefined{}
+	}
+
+	if err != nil {
+		log.Println("error in SetInterestRule method: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
+
+func (postgres Postgres) DeleteInterestRule(tier string) error {
+	tag, err := postgres.pool.Exec(context.Background(),
+		"DELETE FROM interest_rules WHERE tier = $1", tier)
+
+	if err != nil {
+		log.Println("error in DeleteInterestRule method: ", err)
+		return DBError{}
+	}
+
+	if tag.RowsAffected() == 0 {
+		return InterestRuleUndefined{}
+	}
+
+	return nil
+}
+
+// InterestWallets возвращает активные кошельки с ненулевой ставкой, которым ещё не начислены проценты за день period.
+// Замороженные и закрытые кошельки пропускаются: операции с ними всё равно отклонились бы.
+func (postgres Postgres) InterestWallets(period time.Time) ([]string, error) {
+	rows, err := postgres.pool.Query(context.Background(),
+		`SELECT w.id FROM wallets w
+		   JOIN interest_rules r ON r.tier = w.tier AND r.rate > 0
+		  WHERE w.status = $2 AND w.created_at < $1::DATE + 1
+		    AND NOT EXISTS (SELECT 1 FROM interest_accruals a WHERE a.wallet_id = w.id AND a.period = $1::DATE)
+		  ORDER BY w.id`,
+		periodDate(period), StatusActive)
+	if err != nil {
+		log.Println("error in InterestWallets method: ", err)
+		return nil, DBError{}
+	}
+
+	uuids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		log.Println("error in InterestWallets method: ", err)
+		return nil, DBError{}
+	}
+
+	return uuids, nil
+}
+
+// LastInterestPeriod возвращает последний день, за который начислялись проценты; false - начислений ещё не было
+func (postgres Postgres) LastInterestPeriod() (time.Time, bool, error) {
+	var period *time.Time
+	err := postgres.pool.QueryRow(context.Background(), "SELECT max(period) FROM interest_accruals").Scan(&period)
+
+	if err != nil {
+		log.Println("error in LastInterestPeriod method: ", err)
+		return time.Time{}, false, DBError{}
+	}
+
+	if period == nil {
+		return time.Time{}, false, nil
+	}
+
+	return period.UTC(), true, nil
+}
+
+// periodDate передаёт день в колонку DATE строкой: time.Time ушёл бы как TIMESTAMPTZ,
+// и день зависел бы от часового пояса сессии
+func periodDate(period time.Time) string {
+	return period.UTC().Format(time.DateOnly)
+}
+
+// AccrueInterest начисляет кошельку uuid проценты за день period (полночь UTC) на баланс журнала в конце дня
+// по ставке его тарифа. К точной сумме добавляется остаток прошлого начисления, зачисляется целое число копеек
+// пополнением против счёта system:interest, а новый остаток сохраняется. Остаток переходит от начисления
+// к начислению в порядке их проведения, поэтому дни, начисленные задним числом, не учитывают его дважды. false - начислять нечего:
+// за этот день уже начислено или у тарифа кошелька нет ставки.
+func (postgres Postgres) AccrueInterest(uuid string, period time.Time) (Accrual, bool, error) {
+	ctx := context.Background()
+	accrual := Accrual{WalletId: uuid, Period: period}
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in AccrueInterest method: ", err)
+		return Accrual{}, false, DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	day := periodDate(period)
+
+	// начисления кошелька идут по одному: каждое забирает остаток предыдущего
+	if err = lockWallets(ctx, tx, []string{uuid}); err != nil {
+		return Accrual{}, false, err
+	}
+
+	err = tx.QueryRow(ctx,
+		`SELECT r.rate::FLOAT, r.day_count FROM wallets w JOIN interest_rules r ON r.tier = w.tier WHERE w.id = $1`,
+		uuid).Scan(&accrual.Rate, &accrual.DayCount)
+
+	if err == pgx.ErrNoRows {
+		return Accrual{}, false, nil
+	}
+
+	if err != nil {
+		log.Println("error in AccrueInterest method: ", err)
+		return Accrual{}, false, DBError{}
+	}
+
+	// запись за день занимается первой: параллельное начисление за тот же день ждёт её и ничего не начисляет
+	tag, err := tx.Exec(ctx,
+		`INSERT INTO interest_accruals (wallet_id, period, rate, day_count, created_at)
+		 VALUES ($1, $2::DATE, $3, $4, clock_timestamp())
+		 ON CONFLICT DO NOTHING`,
+		uuid, day, accrual.Rate, accrual.DayCount)
+
+	if err != nil {
+		log.Println("error in AccrueInterest method: ", err)
+		return Accrual{}, false, DBError{}
+	}
+
+	if tag.RowsAffected() == 0 {
+		return Accrual{}, false, nil
+	}
+
+	var walletId string
+	var carry float64
+	err = tx.QueryRow(ctx, balancesAtQuery, []string{uuid}, period.AddDate(0, 0, 1)).Scan(&walletId, &accrual.Balance)
+
+	if err == nil {
+		err = tx.QueryRow(ctx,
+			`SELECT COALESCE((SELECT carry::FLOAT FROM interest_accruals
+			                   WHERE wallet_id = $1 AND period != $2::DATE
+			                   ORDER BY created_at DESC
+			                   LIMIT 1), 0)`,
+			uuid, day).Scan(&carry)
+	}
+
+	if err != nil {
+		log.Println("error in AccrueInterest method: ", err)
+		return Accrual{}, false, DBError{}
+	}
+
+	// отрицательный баланс (кредит) процентов не приносит
+	accrual.Interest = max(accrual.Balance, 0) * accrual.Rate / 100 * DayFraction(accrual.DayCount, period)
+	accrual.Amount, accrual.Carry = splitCents(accrual.Interest + carry)
+
+	// повторное начисление за день исключает запись в interest_accruals, поэтому externalId не нужен:
+	// пространство externalId принадлежит клиентам
+	if accrual.Amount > 0 {
+		id, err := changeBalance(ctx, tx, accrual.Amount, uuid, OperationDetails{
+			Reference:   "interest",
+			Description: "interest for " + period.Format(time.DateOnly),
+			system:      true,
+		})
+		if err != nil {
+			return Accrual{}, false, err
+		}
+
+		if err = bookEntry(ctx, tx, entryInterest, LedgerInterest, id); err != nil {
+			return Accrual{}, false, err
+		}
+
+		accrual.OperationId = &id
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE interest_accruals SET balance = $3, interest = $4, amount = $5, carry = $6, operation_id = $7
+		  WHERE wallet_id = $1 AND period = $2::DATE`,
+		uuid, day, accrual.Balance, accrual.Interest, accrual.Amount, accrual.Carry, accrual.OperationId)
+
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+
+	if err != nil {
+		log.Println("error in AccrueInterest method: ", err)
+		return Accrual{}, false, DBError{}
+	}
+
+	return accrual, true, nil
+}
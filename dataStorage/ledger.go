@@ -0,0 +1,174 @@
+package datastorage
+
+import (
+	"context"
+	"log"
+	"math"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Системные счета журнала. Счёт кошелька называется wallet:{id}.
+const (
+	LedgerCashIn  = "system:cash_in"
+	LedgerCashOut = "system:cash_out"
+	LedgerFees    = "system:fees"
+	LedgerFX      = "system:fx"
+	LedgerOpening = "system:opening"
+	// исправления расхождений журнала с балансом кошелька
+	LedgerCorrections = "system:corrections"
+	// начисленные проценты
+	LedgerInterest = "system:interest"
+)
+
+const (
+	AccountWallet = "wallet"
+	AccountSystem = "system"
+)
+
+// виды записей журнала
+const (
+	entryDeposit    = "deposit"
+	entryWithdraw   = "withdraw"
+	entryTransfer   = "transfer"
+	entryReversal   = "reversal"
+	entryImport     = "import"
+	entryCorrection = "correction"
+	entryInterest   = "interest"
+)
+
+// AccountBalance - обороты счёта: Debit и Credit положительны, Balance = Credit - Debit.
+// Счета кошельков сводятся в одну строку wallets, Accounts - их число.
+type AccountBalance struct {
+	Account  string  `json:"account"`
+	Kind     string  `json:"kind"`
+	Accounts int64   `json:"accounts"`
+	Debit    float64 `json:"debit"`
+	Credit   float64 `json:"credit"`
+	Balance  float64 `json:"balance"`
+}
+
+// TrialBalance - оборотная ведомость журнала. Сумма дебетов всегда равна сумме кредитов,
+// Balanced показывает, что это так.
+type TrialBalance struct {
+	Accounts []AccountBalance `json:"accounts"`
+	Debit    float64          `json:"debit"`
+	Credit   float64          `json:"credit"`
+	Balanced bool             `json:"balanced"`
+}
+
+// bookEntry записывает в журнал запись kind из операций operationIds: каждая операция
+// проводится по счёту своего кошелька, удержанные комиссии - по system:fees, а остаток
+// с обратным знаком - по счёту counter. Для перевода counter пустой: списание, зачисление
+// и комиссия и так дают в сумме ноль.
+// Все проводки записи вставляются одним запросом, триггер проверяет, что их сумма равна нулю.
+func bookEntry(ctx context.Context, tx pgx.Tx, kind, counter string, operationIds ...int64) error {
+	_, err := tx.Exec(ctx,
+		`WITH entry AS (
+		     INSERT INTO journal_entries (kind) VALUES ($1) RETURNING id),
+		 legs AS (
+		     SELECT id, 'wallet:' || wallet_id AS account_id, amount::NUMERIC(20, 2) AS amount, fee::NUMERIC(20, 2) AS fee
+		       FROM operations WHERE id = ANY($2::BIGINT[]) AND amount::NUMERIC(20, 2) != 0)
+		 INSERT INTO ledger_postings (entry_id, account_id, operation_id, amount)
+		 SELECT entry.id, legs.account_id, legs.id, legs.amount FROM entry, legs
+		  UNION ALL
+		 SELECT entry.id, $4::TEXT, NULL, SUM(legs.fee) FROM entry, legs
+		  GROUP BY entry.id HAVING SUM(legs.fee) != 0
+		  UNION ALL
+		 SELECT entry.id, $3::TEXT, NULL, -SUM(legs.amount + legs.fee) FROM entry, legs
+		  WHERE $3::TEXT != '' GROUP BY entry.id HAVING SUM(legs.amount + legs.fee) != 0`,
+		kind, operationIds, counter, LedgerFees)
+
+	if err != nil {
+		log.Println("error in bookEntry: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
+
+// cashOperation проводит пополнение или списание извне: деньги приходят
+// со счёта system:cash_in и уходят на system:cash_out. Комиссия fee удерживается из списания.
+func cashOperation(ctx context.Context, tx pgx.Tx, sum float64, uuid string, details OperationDetails, fee float64) (int64, error) {
+
+	id, err := changeBalance(ctx, tx, sum, uuid, details)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = setFee(ctx, tx, id, fee); err != nil {
+		return 0, err
+	}
+
+	kind, counter := entryDeposit, LedgerCashIn
+	if sum < 0 {
+		kind, counter = entryWithdraw, LedgerCashOut
+	}
+
+	return id, bookEntry(ctx, tx, kind, counter, id)
+}
+
+// counterAccount находит системный счёт, против которого проведена операция (кроме комиссии)
+func counterAccount(ctx context.Context, tx pgx.Tx, operationId int64) (string, bool, error) {
+	var account string
+	err := tx.QueryRow(ctx,
+		`SELECT c.account_id FROM ledger_postings p
+		   JOIN ledger_postings c ON c.entry_id = p.entry_id AND c.operation_id IS NULL AND c.account_id != $2
+		  WHERE p.operation_id = $1
+		  LIMIT 1`,
+		operationId, LedgerFees).Scan(&account)
+
+	if err == pgx.ErrNoRows {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return account, true, nil
+}
+
+// TrialBalance считает обороты по системным счетам и сводно по счетам кошельков
+func (postgres Postgres) TrialBalance() (TrialBalance, error) {
+	rows, err := postgres.pool.Query(context.Background(),
+		`SELECT CASE WHEN a.kind = 'wallet' THEN 'wallets' ELSE a.id END, a.kind, COUNT(DISTINCT a.id),
+		        COALESCE(-SUM(p.amount) FILTER (WHERE p.amount < 0), 0)::FLOAT,
+		        COALESCE(SUM(p.amount) FILTER (WHERE p.amount > 0), 0)::FLOAT
+		   FROM ledger_accounts a LEFT JOIN ledger_postings p ON p.account_id = a.id
+		  GROUP BY 1, 2
+		  ORDER BY 2, 1`)
+	if err != nil {
+		log.Println("error in TrialBalance method: ", err)
+		return TrialBalance{}, DBError{}
+	}
+
+	accounts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (AccountBalance, error) {
+		var account AccountBalance
+		err := row.Scan(&account.Account, &account.Kind, &account.Accounts, &account.Debit, &account.Credit)
+		account.Balance = cents(account.Credit - account.Debit)
+		return account, err
+	})
+	if err != nil {
+		log.Println("error in TrialBalance method: ", err)
+		return TrialBalance{}, DBError{}
+	}
+
+	return trialBalance(accounts), nil
+}
+
+// trialBalance подводит итоги по оборотам счетов
+func trialBalance(accounts []AccountBalance) TrialBalance {
+	report := TrialBalance{Accounts: accounts}
+
+	for _, account := range accounts {
+		report.Debit += account.Debit
+		report.Credit += account.Credit
+	}
+
+	report.Debit = cents(report.Debit)
+	report.Credit = cents(report.Credit)
+	report.Balanced = math.Abs(report.Debit-report.Credit) < 0.005
+
+	return report
+}
@@ -0,0 +1,191 @@
+package datastorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Limits описывает ограничения на операции кошелька. Пустое поле означает отсутствие ограничения.
+type Limits struct {
+	Tier                string   `json:"tier,omitempty"`
+	MaxWithdrawal       *float64 `json:"maxWithdrawal"`
+	DailyWithdrawal     *float64 `json:"dailyWithdrawal"`
+	MonthlyWithdrawal   *float64 `json:"monthlyWithdrawal"`
+	OperationsPerMinute *int     `json:"operationsPerMinute"`
+}
+
+const (
+	LimitMaxWithdrawal       = "max_withdrawal"
+	LimitDailyWithdrawal     = "daily_withdrawal"
+	LimitMonthlyWithdrawal   = "monthly_withdrawal"
+	LimitOperationsPerMinute = "operations_per_minute"
+)
+
+type LimitExceeded struct {
+	Limit string
+	Value float64
+}
+
+func (e LimitExceeded) Error() string {
+	return fmt.Sprintf("%s limit %v exceeded", e.Limit, e.Value)
+}
+
+type TierUndefined struct {
+}
+
+func (_ TierUndefined) Error() string {
+	return "tier undefined"
+}
+
+// checkLimits проверяет, что операция на sum не нарушит лимиты кошелька.
+// Вызывается внутри транзакции после lockWallet, поэтому параллельные операции
+// над тем же кошельком ждут её завершения.
+func checkLimits(ctx context.Context, tx pgx.Tx, uuid string, sum float64, limits Limits) error {
+
+	if limits.OperationsPerMinute != nil {
+		var count int
+		err := tx.QueryRow(ctx,
+			"SELECT count(*) FROM operations WHERE wallet_id = $1 AND created_at > now() - interval '1 minute'",
+			uuid).Scan(&count)
+
+		if err != nil {
+			return err
+		}
+
+		if count >= *limits.OperationsPerMinute {
+			return LimitExceeded{Limit: LimitOperationsPerMinute, Value: float64(*limits.OperationsPerMinute)}
+		}
+	}
+
+	if sum >= 0 {
+		return nil
+	}
+
+	withdrawal := -sum
+
+	if limits.MaxWithdrawal != nil && withdrawal > *limits.MaxWithdrawal {
+		return LimitExceeded{Limit: LimitMaxWithdrawal, Value: *limits.MaxWithdrawal}
+	}
+
+	periods := []struct {
+		limit *float64
+		name  string
+		trunc string
+	}{
+		{limits.DailyWithdrawal, LimitDailyWithdrawal, "day"},
+		{limits.MonthlyWithdrawal, LimitMonthlyWithdrawal, "month"},
+	}
+
+	for _, period := range periods {
+		if period.limit == nil {
+			continue
+		}
+
+		var spent float64
+		err := tx.QueryRow(ctx,
+			`SELECT COALESCE(SUM(-amount), 0) FROM operations
+			  WHERE wallet_id = $1 AND amount < 0 AND reversal_of IS NULL AND created_at >= date_trunc($2, now())`,
+			uuid, period.trunc).Scan(&spent)
+
+		if err != nil {
+			return err
+		}
+
+		if spent+withdrawal > *period.limit {
+			return LimitExceeded{Limit: period.name, Value: *period.limit}
+		}
+	}
+
+	return nil
+}
+
+func (postgres Postgres) GetLimits(uuid string) (bool, Limits, error) {
+
+	got, state, err := scanWalletState(postgres.pool.QueryRow(context.Background(), walletStateQuery, uuid))
+
+	if err != nil {
+		log.Println("error in GetLimits method: ", err)
+		return false, Limits{}, DBError{}
+	}
+
+	return got, state.limits, nil
+}
+
+func (postgres Postgres) SetWalletLimits(uuid string, limits Limits) error {
+	ctx := context.Background()
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in SetWalletLimits method: ", err)
+		return DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	if limits.Tier != "" {
+		cmdTag, err := tx.Exec(ctx, "UPDATE wallets SET tier = $1 WHERE id = $2", limits.Tier, uuid)
+
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				return TierUndefined{}
+			}
+			log.Println("error in SetWalletLimits method: ", err)
+			return DBError{}
+		}
+
+		if cmdTag.RowsAffected() == 0 {
+			return UUIDUndefined{}
+		}
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO wallet_limits (wallet_id, max_withdrawal, daily_withdrawal, monthly_withdrawal, operations_per_minute)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (wallet_id) DO UPDATE SET
+		     max_withdrawal = EXCLUDED.max_withdrawal,
+		     daily_withdrawal = EXCLUDED.daily_withdrawal,
+		     monthly_withdrawal = EXCLUDED.monthly_withdrawal,
+		     operations_per_minute = EXCLUDED.operations_per_minute`,
+		uuid, limits.MaxWithdrawal, limits.DailyWithdrawal, limits.MonthlyWithdrawal, limits.OperationsPerMinute)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return UUIDUndefined{}
+		}
+		log.Println("error in SetWalletLimits method: ", err)
+		return DBError{}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Println("error in SetWalletLimits method: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
+
+func (postgres Postgres) SetTierLimits(tier string, limits Limits) error {
+
+	_, err := postgres.pool.Exec(context.Background(),
+		`INSERT INTO wallet_tiers (name, max_withdrawal, daily_withdrawal, monthly_withdrawal, operations_per_minute)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (name) DO UPDATE SET
+		     max_withdrawal = EXCLUDED.max_withdrawal,
+		     daily_withdrawal = EXCLUDED.daily_withdrawal,
+		     monthly_withdrawal = EXCLUDED.monthly_withdrawal,
+		     operations_per_minute = EXCLUDED.operations_per_minute`,
+		tier, limits.MaxWithdrawal, limits.DailyWithdrawal, limits.MonthlyWithdrawal, limits.OperationsPerMinute)
+
+	if err != nil {
+		log.Println("error in SetTierLimits method: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package datastorage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SortById        = "id"
+	SortByBalance   = "balance"
+	SortByCreatedAt = "createdAt"
+)
+
+var walletSortColumns = map[string]string{
+	SortById:        "w.id",
+	SortByBalance:   "w.balance",
+	SortByCreatedAt: "w.created_at",
+}
+
+// WalletCursor - последний кошелёк предыдущей страницы, с которого продолжается выборка
+type WalletCursor struct {
+	Id        string
+	Balance   float64
+	CreatedAt time.Time
+}
+
+// WalletFilter - условия выборки кошельков. Пустые поля не ограничивают выборку.
+type WalletFilter struct {
+	MinBalance  *float64
+	MaxBalance  *float64
+	Status      string
+	Owner       string
+	IdPrefix    string
+	CreatedFrom time.Time // включительно
+	CreatedTo   time.Time // не включительно
+	Sort        string
+	Desc        bool
+	Metadata    map[string]string // кошелёк должен содержать все эти пары
+	After       *WalletCursor
+	Limit       int
+}
+
+// WalletPage - страница кошельков, Total - число всех кошельков под фильтром
+type WalletPage struct {
+	Wallets []Wallet
+	Total   int64
+	More    bool
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы префикс искался буквально
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// walletListQuery строит запрос страницы кошельков и запрос их общего числа.
+// Страница читается по ключу (sort, id), поэтому глубокие страницы не дороже первой.
+func walletListQuery(filter WalletFilter) (string, []any, string, []any, error) {
+	if filter.Sort == "" {
+		filter.Sort = SortById
+	}
+
+	column, ok := walletSortColumns[filter.Sort]
+	if !ok {
+		return "", nil, "", nil, fmt.Errorf("unknown sort %q", filter.Sort)
+	}
+
+	var conditions []string
+	var args []any
+
+	add := func(condition string, values ...any) {
+		for _, value := range values {
+			args = append(args, value)
+			condition = strings.Replace(condition, "?", "$"+strconv.Itoa(len(args)), 1)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if filter.MinBalance != nil {
+		add("w.balance >= ?", *filter.MinBalance)
+	}
+	if filter.MaxBalance != nil {
+		add("w.balance <= ?", *filter.MaxBalance)
+	}
+	if filter.Status != "" {
+		add("w.status = ?", filter.Status)
+	}
+	if filter.Owner != "" {
+		add("w.owner = ?", filter.Owner)
+	}
+	if filter.IdPrefix != "" {
+		add("w.id LIKE ?", escapeLike(filter.IdPrefix)+"%")
+	}
+	if len(filter.Metadata) > 0 {
+		data, _ := json.Marshal(filter.Metadata)
+		add("w.metadata @> ?::jsonb", string(data))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		add("w.created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		add("w.created_at < ?", filter.CreatedTo)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "\n  WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	countQuery := "SELECT count(*) FROM wallets w" + where
+	countArgs := append([]any(nil), args...)
+
+	comparison, direction := ">", "ASC"
+	if filter.Desc {
+		comparison, direction = "<", "DESC"
+	}
+
+	if filter.After != nil {
+		switch filter.Sort {
+		case SortById:
+			add("w.id "+comparison+" ?", filter.After.Id)
+		case SortByBalance:
+			add("(w.balance, w.id) "+comparison+" (?, ?)", filter.After.Balance, filter.After.Id)
+		case SortByCreatedAt:
+			add("(w.created_at, w.id) "+comparison+" (?, ?)", filter.After.CreatedAt, filter.After.Id)
+		}
+	}
+
+	if len(conditions) > 0 {
+		where = "\n  WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	order := column + " " + direction
+	if filter.Sort != SortById {
+		order += ", w.id " + direction
+	}
+
+	// берём на одну строку больше, чтобы узнать, есть ли следующая страница
+	args = append(args, filter.Limit+1)
+	query := "SELECT w.id, " + walletStateColumns + walletStateFrom + where +
+		"\n  ORDER BY " + order + "\n  LIMIT $" + strconv.Itoa(len(args))
+
+	return query, args, countQuery, countArgs, nil
+}
+
+func (postgres Postgres) ListWallets(filter WalletFilter) (WalletPage, error) {
+	ctx := context.Background()
+
+	query, args, countQuery, countArgs, err := walletListQuery(filter)
+	if err != nil {
+		log.Println("error in ListWallets method: ", err)
+		return WalletPage{}, err
+	}
+
+	var page WalletPage
+
+	if err = postgres.pool.QueryRow(ctx, countQuery, countArgs...).Scan(&page.Total); err != nil {
+		log.Println("error in ListWallets method: ", err)
+		return WalletPage{}, DBError{}
+	}
+
+	rows, err := postgres.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("error in ListWallets method: ", err)
+		return WalletPage{}, DBError{}
+	}
+	defer rows.Close()
+
+	page.Wallets = []Wallet{}
+
+	for rows.Next() {
+		var id string
+		var state walletState
+
+		if err = rows.Scan(append([]any{&id}, state.fields()...)...); err != nil {
+			log.Println("error in ListWallets method: ", err)
+			return WalletPage{}, DBError{}
+		}
+
+		page.Wallets = append(page.Wallets, state.wallet(id))
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println("error in ListWallets method: ", err)
+		return WalletPage{}, DBError{}
+	}
+
+	if len(page.Wallets) > filter.Limit {
+		page.Wallets = page.Wallets[:filter.Limit]
+		page.More = true
+	}
+
+	return page, nil
+}
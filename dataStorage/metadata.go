@@ -0,0 +1,118 @@
+package datastorage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"unicode/utf8"
+)
+
+const (
+	MaxMetadataKeys        = 50
+	MaxMetadataKeyLength   = 40
+	MaxMetadataValueLength = 500
+	MaxMetadataSize        = 8192 // байт в JSON
+)
+
+var metadataKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type WrongMetadata struct {
+	Reason string
+}
+
+func (e WrongMetadata) Error() string {
+	return "wrong metadata: " + e.Reason
+}
+
+// ValidateMetadataKey проверяет, что ключ можно хранить и искать по нему
+func ValidateMetadataKey(key string) error {
+	if len(key) > MaxMetadataKeyLength || !metadataKey.MatchString(key) {
+		return WrongMetadata{Reason: fmt.Sprintf("key %q must be 1 to %d letters, digits, _ or -", key, MaxMetadataKeyLength)}
+	}
+	return nil
+}
+
+// ValidateMetadata проверяет ключи, значения и размер метаданных кошелька
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataKeys {
+		return WrongMetadata{Reason: fmt.Sprintf("more than %d keys", MaxMetadataKeys)}
+	}
+
+	for key, value := range metadata {
+		if err := ValidateMetadataKey(key); err != nil {
+			return err
+		}
+
+		if utf8.RuneCountInString(value) > MaxMetadataValueLength {
+			return WrongMetadata{Reason: fmt.Sprintf("value of %q is longer than %d characters", key, MaxMetadataValueLength)}
+		}
+	}
+
+	if data, _ := json.Marshal(metadata); len(data) > MaxMetadataSize {
+		return WrongMetadata{Reason: fmt.Sprintf("larger than %d bytes", MaxMetadataSize)}
+	}
+
+	return nil
+}
+
+// mergeMetadata применяет изменения к метаданным: nil удаляет ключ, остальные значения задают его
+func mergeMetadata(metadata map[string]string, changes map[string]*string) map[string]string {
+	merged := make(map[string]string, len(metadata)+len(changes))
+
+	for key, value := range metadata {
+		merged[key] = value
+	}
+
+	for key, value := range changes {
+		if value == nil {
+			delete(merged, key)
+		} else {
+			merged[key] = *value
+		}
+	}
+
+	return merged
+}
+
+// UpdateMetadata меняет метаданные кошелька и возвращает кошелёк с новыми метаданными
+func (postgres Postgres) UpdateMetadata(uuid string, changes map[string]*string) (Wallet, error) {
+	ctx := context.Background()
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in UpdateMetadata method: ", err)
+		return Wallet{}, DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	got, state, err := lockWallet(ctx, tx, uuid)
+
+	if err != nil {
+		log.Println("error in UpdateMetadata method: ", err)
+		return Wallet{}, DBError{}
+	}
+
+	if !got {
+		return Wallet{}, UUIDUndefined{}
+	}
+
+	state.metadata = mergeMetadata(state.metadata, changes)
+
+	if err = ValidateMetadata(state.metadata); err != nil {
+		return Wallet{}, err
+	}
+
+	if _, err = tx.Exec(ctx, "UPDATE wallets SET metadata = $1 WHERE id = $2", state.metadata, uuid); err != nil {
+		log.Println("error in UpdateMetadata method: ", err)
+		return Wallet{}, DBError{}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Println("error in UpdateMetadata method: ", err)
+		return Wallet{}, DBError{}
+	}
+
+	return state.wallet(uuid), nil
+}
@@ -0,0 +1,280 @@
+package datastorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationFile - имя файла миграции в формате golang-migrate: 000001_name.up.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration - одна миграция схемы
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// LoadMigrations читает миграции из fsys и сортирует их по версии
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("wrong migration %s: %w", entry.Name(), err)
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("two migrations with version %d", version)
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// migrationLockKey - ключ advisory lock, под которым миграции выполняет только одна копия сервера
+const migrationLockKey = 7302
+
+// SchemaBehind - схема базы старее встроенных миграций или осталась в незавершённом состоянии
+type SchemaBehind struct {
+	Version int64
+	Latest  int64
+	Dirty   bool
+}
+
+func (e SchemaBehind) Error() string {
+	if e.Dirty {
+		return fmt.Sprintf("schema version %d is dirty, fix it manually", e.Version)
+	}
+	return fmt.Sprintf("schema version %d is behind %d, run migrate up", e.Version, e.Latest)
+}
+
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// schemaVersion возвращает версию схемы из таблицы schema_migrations (как у golang-migrate);
+// 0, если миграции ещё не применялись
+func schemaVersion(ctx context.Context, q querier) (int64, bool, error) {
+
+	_, err := q.Exec(ctx,
+		"CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)")
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	var version int64
+	var dirty bool
+	err = q.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
+
+func (postgres Postgres) SchemaVersion() (int64, bool, error) {
+
+	version, dirty, err := schemaVersion(context.Background(), postgres.pool)
+
+	if err != nil {
+		log.Println("error in SchemaVersion method: ", err)
+		return 0, false, DBError{}
+	}
+
+	return version, dirty, nil
+}
+
+// CheckSchema возвращает SchemaBehind, если применены не все migrations
+func (postgres Postgres) CheckSchema(migrations []Migration) error {
+
+	version, dirty, err := postgres.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	var latest int64
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+
+	if dirty || version < latest {
+		return SchemaBehind{Version: version, Latest: latest, Dirty: dirty}
+	}
+
+	return nil
+}
+
+// withMigrationLock выполняет f на отдельном соединении под advisory lock,
+// чтобы одновременно запущенные копии сервера не применяли миграции параллельно
+func (postgres Postgres) withMigrationLock(f func(ctx context.Context, conn *pgxpool.Conn) error) error {
+
+	ctx := context.Background()
+
+	conn, err := postgres.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return err
+	}
+	defer conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	return f(ctx, conn)
+}
+
+// MigrateUp применяет миграции новее текущей версии схемы, каждую в своей транзакции
+// вместе с записью новой версии. Возвращает применённые миграции.
+func (postgres Postgres) MigrateUp(migrations []Migration) ([]Migration, error) {
+
+	var applied []Migration
+
+	err := postgres.withMigrationLock(func(ctx context.Context, conn *pgxpool.Conn) error {
+		// версия читается под блокировкой: другая копия могла только что применить миграции
+		version, dirty, err := schemaVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return SchemaBehind{Version: version, Dirty: true}
+		}
+
+		for _, migration := range migrations {
+			if migration.Version <= version {
+				continue
+			}
+
+			if err = applyMigration(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// MigrateDown откатывает steps последних применённых миграций. Возвращает откаченные миграции.
+func (postgres Postgres) MigrateDown(migrations []Migration, steps int) ([]Migration, error) {
+
+	var reverted []Migration
+
+	err := postgres.withMigrationLock(func(ctx context.Context, conn *pgxpool.Conn) error {
+		version, dirty, err := schemaVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return SchemaBehind{Version: version, Dirty: true}
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := migrations[i]
+			if migration.Version > version {
+				continue
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+
+			var previous int64
+			if i > 0 {
+				previous = migrations[i-1].Version
+			}
+
+			if err = applyMigration(ctx, conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// applyMigration выполняет sql и записывает версию схемы в одной транзакции
+func applyMigration(ctx context.Context, conn *pgxpool.Conn, sql string, version int64) error {
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+
+	if version > 0 {
+		if _, err = tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
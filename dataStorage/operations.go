@@ -0,0 +1,445 @@
+package datastorage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	OperationDeposit  = "DEPOSIT"
+	OperationWithdraw = "WITHDRAW"
+	OperationTransfer = "TRANSFER"
+)
+
+// OperationDetails - необязательные сведения об операции от вызывающей системы.
+// ExternalId уникален в пределах кошелька и не даёт провести одну операцию дважды.
+// IfMatch - версии кошелька, при которых операцию можно проводить (nil - при любой).
+type OperationDetails struct {
+	Reference   string  `json:"reference,omitempty"`
+	Description string  `json:"description,omitempty"`
+	ExternalId  string  `json:"externalId,omitempty"`
+	IfMatch     []int64 `json:"-"`
+
+	// IdempotencyKey - ключ запроса, который отмечается проведённым вместе с операцией
+	IdempotencyKey string `json:"-"`
+
+	// reversal - компенсирующая операция отмены: она не проверяет лимиты
+	// и списывает только то, что есть на балансе, без кредитной линии
+	reversal bool
+
+	// system - зачисление самого сервиса (проценты): лимиты клиента на него не действуют
+	system bool
+}
+
+// Operation - одна операция пакета. ToWalletId заполняется только для TRANSFER.
+type Operation struct {
+	WalletId      string  `json:"walletId"`
+	OperationType string  `json:"operationType"`
+	Amount        float64 `json:"amount"`
+	ToWalletId    string  `json:"toWalletId,omitempty"`
+	OperationDetails
+}
+
+type InsufficientFunds struct {
+}
+
+func (_ InsufficientFunds) Error() string {
+	return "balance small for operation"
+}
+
+type DuplicateExternalId struct {
+	ExternalId string
+}
+
+func (e DuplicateExternalId) Error() string {
+	return "operation with externalId " + e.ExternalId + " already exists"
+}
+
+type VersionMismatch struct {
+	Version int64
+}
+
+func (e VersionMismatch) Error() string {
+	return "wallet version " + strconv.FormatInt(e.Version, 10) + " does not match If-Match"
+}
+
+type WrongOperation struct {
+	OperationType string
+}
+
+func (e WrongOperation) Error() string {
+	return "wrong operation type: " + e.OperationType
+}
+
+// changeBalance меняет баланс кошелька в рамках транзакции tx, проверяя статус,
+// лимиты и кредитный лимит, и возвращает id записанной операции.
+// Ошибки БД логируются и возвращаются как DBError.
+func changeBalance(ctx context.Context, tx pgx.Tx, sum float64, uuid string, details OperationDetails) (int64, error) {
+
+	got, state, err := lockWallet(ctx, tx, uuid)
+
+	if err != nil {
+		log.Println("error in changeBalance: ", err)
+		return 0, DBError{}
+	}
+
+	if !got {
+		log.Println("error in changeBalance: wallet not found")
+		return 0, UUIDUndefined{}
+	}
+
+	// версия проверяется под блокировкой строки: между проверкой и изменением кошелёк никто не изменит
+	if details.IfMatch != nil && !slices.Contains(details.IfMatch, state.version) {
+		log.Println("wallet version mismatch: ", state.version)
+		return 0, VersionMismatch{Version: state.version}
+	}
+
+	if err = checkStatus(state, sum); err != nil {
+		log.Println("operation on not active wallet: ", err)
+		return 0, err
+	}
+
+	if !details.reversal && !details.system {
+		err = checkLimits(ctx, tx, uuid, sum, state.limits)
+	}
+
+	if err != nil {
+		var limitErr LimitExceeded
+		if errors.As(err, &limitErr) {
+			log.Println("limit exceeded: ", limitErr)
+			return 0, limitErr
+		}
+		log.Println("error in changeBalance: ", err)
+		return 0, DBError{}
+	}
+
+	// баланс может уйти в минус не больше чем на кредитный лимит кошелька,
+	// а отмена не может списать больше, чем есть на балансе
+	var balance, amount float64
+	err = tx.QueryRow(ctx,
+		`UPDATE wallets SET balance = TRUNC( (balance + $1)::NUMERIC , 2)
+		  WHERE id = $2
+		    AND CASE WHEN $3 THEN $1 >= 0 OR TRUNC( (balance + $1)::NUMERIC , 2) >= 0
+		             ELSE TRUNC( (balance + $1)::NUMERIC , 2) >= -credit_limit END
+		  RETURNING balance, TRUNC($1::NUMERIC, 2)::FLOAT`,
+		sum, uuid, details.reversal).Scan(&balance, &amount)
+
+	if err == pgx.ErrNoRows {
+		log.Println("balance too small for operation ")
+		return 0, InsufficientFunds{}
+	}
+
+	if err != nil {
+		log.Println("error in changeBalance: ", err)
+		return 0, DBError{}
+	}
+
+	var id int64
+	err = tx.QueryRow(ctx,
+		`INSERT INTO operations (wallet_id, amount, reference, description, external_id)
+		 VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''))
+		 RETURNING id`,
+		uuid, amount, details.Reference, details.Description, details.ExternalId).Scan(&id)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		log.Println("duplicate external id: ", details.ExternalId)
+		return 0, DuplicateExternalId{ExternalId: details.ExternalId}
+	}
+
+	if err != nil {
+		log.Println("error in changeBalance: ", err)
+		return 0, DBError{}
+	}
+
+	if err = markIdempotencyKeyApplied(ctx, tx, details.IdempotencyKey); err != nil {
+		log.Println("error in changeBalance: ", err)
+		return 0, DBError{}
+	}
+
+	eventType := EventDeposited
+	if amount < 0 {
+		eventType = EventWithdrawn
+	}
+
+	return id, addEvent(ctx, tx, uuid, eventType, amount, balance)
+}
+
+// lockWallets блокирует кошельки в порядке id, чтобы параллельные переводы
+// между одними и теми же кошельками не приводили к взаимной блокировке.
+func lockWallets(ctx context.Context, tx pgx.Tx, uuids []string) error {
+	ids := append([]string(nil), uuids...)
+	sort.Strings(ids)
+
+	_, err := tx.Exec(ctx,
+		"SELECT id FROM wallets WHERE id = ANY($1) ORDER BY id FOR UPDATE",
+		ids)
+
+	if err != nil {
+		log.Println("error in lockWallets: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
+
+// transfer переводит sum между кошельками и возвращает id списания и зачисления.
+// Обе операции связываются через transfer_id - id списания. Комиссия fee удерживается
+// из зачисления и записывается на списание.
+func transfer(ctx context.Context, tx pgx.Tx, sum float64, from, to string, details OperationDetails, fee float64) (int64, int64, error) {
+
+	if err := lockWallets(ctx, tx, []string{from, to}); err != nil {
+		return 0, 0, err
+	}
+
+	debitId, err := changeBalance(ctx, tx, -sum, from, details)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// условие If-Match относится к кошельку отправителя
+	credit := details
+	credit.IfMatch = nil
+
+	creditId, err := changeBalance(ctx, tx, cents(sum-fee), to, credit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE operations SET transfer_id = $1 WHERE id IN ($1, $2)", debitId, creditId)
+
+	if err != nil {
+		log.Println("error in transfer: ", err)
+		return 0, 0, DBError{}
+	}
+
+	if err = setFee(ctx, tx, debitId, fee); err != nil {
+		return 0, 0, err
+	}
+
+	return debitId, creditId, bookEntry(ctx, tx, entryTransfer, "", debitId, creditId)
+}
+
+// applyOperation проводит операцию op и возвращает id её операции (для перевода - списания)
+func applyOperation(ctx context.Context, tx pgx.Tx, op Operation) (int64, error) {
+	var fee float64
+	var err error
+
+	if op.OperationType == OperationWithdraw || op.OperationType == OperationTransfer {
+		uuids := []string{op.WalletId}
+		if op.OperationType == OperationTransfer {
+			uuids = append(uuids, op.ToWalletId)
+		}
+
+		if err = lockWallets(ctx, tx, uuids); err != nil {
+			return 0, err
+		}
+
+		if fee, err = feeFor(ctx, tx, op.OperationType, op.WalletId, op.Amount); err != nil {
+			return 0, err
+		}
+	}
+
+	switch op.OperationType {
+	case OperationDeposit:
+		return cashOperation(ctx, tx, op.Amount, op.WalletId, op.OperationDetails, 0)
+	case OperationWithdraw:
+		return cashOperation(ctx, tx, -op.Amount, op.WalletId, op.OperationDetails, fee)
+	case OperationTransfer:
+		id, _, err := transfer(ctx, tx, op.Amount, op.WalletId, op.ToWalletId, op.OperationDetails, fee)
+		return id, err
+	}
+
+	return 0, WrongOperation{OperationType: op.OperationType}
+}
+
+func (postgres Postgres) Transfer(sum float64, from, to string, details OperationDetails) (bool, Receipt, error) {
+	ctx := context.Background()
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in Transfer method: ", err)
+		return false, Receipt{}, DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockWallets(ctx, tx, []string{from, to}); err != nil {
+		return false, Receipt{}, err
+	}
+
+	fee, err := feeFor(ctx, tx, OperationTransfer, from, sum)
+	if err != nil {
+		return false, Receipt{}, err
+	}
+
+	debitId, _, err := transfer(ctx, tx, sum, from, to, details, fee)
+
+	if errors.As(err, &InsufficientFunds{}) {
+		return false, Receipt{}, nil
+	}
+
+	if err != nil {
+		return false, Receipt{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Println("error in Transfer method: ", err)
+		return false, Receipt{}, DBError{}
+	}
+
+	return true, newReceipt(debitId, sum, fee), nil
+}
+
+// Batch выполняет пакет операций и возвращает ошибку по каждой из них (nil - операция проведена).
+// В режиме atomic все операции выполняются в одной транзакции: первая же ошибка
+// откатывает весь пакет. Иначе каждая операция проводится в своей транзакции.
+func (postgres Postgres) Batch(ops []Operation, atomic bool) ([]error, error) {
+	ctx := context.Background()
+	results := make([]error, len(ops))
+
+	if !atomic {
+		for i, op := range ops {
+			results[i] = postgres.applyInTx(ctx, op)
+		}
+		return results, nil
+	}
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in Batch method: ", err)
+		return nil, DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	var uuids []string
+	for _, op := range ops {
+		uuids = append(uuids, op.WalletId)
+		if op.ToWalletId != "" {
+			uuids = append(uuids, op.ToWalletId)
+		}
+	}
+
+	if err = lockWallets(ctx, tx, uuids); err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		_, err = applyOperation(ctx, tx, op)
+
+		if errors.As(err, &DBError{}) {
+			return nil, err
+		}
+
+		if err != nil {
+			results[i] = err
+			return results, nil
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Println("error in Batch method: ", err)
+		return nil, DBError{}
+	}
+
+	return results, nil
+}
+
+func (postgres Postgres) applyInTx(ctx context.Context, op Operation) error {
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in Batch method: ", err)
+		return DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = applyOperation(ctx, tx, op); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Println("error in Batch method: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
+
+// OperationRecord - проведённая операция кошелька. Amount отрицательный для списаний.
+// ReversalOf - id операции, которую эта операция отменяет.
+type OperationRecord struct {
+	Id         int64     `json:"id"`
+	WalletId   string    `json:"walletId"`
+	Amount     float64   `json:"amount"`
+	Fee        float64   `json:"fee,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ReversalOf *int64    `json:"reversalOf,omitempty"`
+	OperationDetails
+}
+
+// OperationFilter - условия поиска операций. Пустые поля не ограничивают поиск.
+type OperationFilter struct {
+	WalletId   string
+	ExternalId string
+	Reference  string
+	Limit      int
+}
+
+const operationRecordQuery = `SELECT id, wallet_id, amount, fee, created_at, reversal_of,
+        COALESCE(reference, ''), COALESCE(description, ''), COALESCE(external_id, '')
+   FROM operations`
+
+func scanOperationRecords(rows pgx.Rows) ([]OperationRecord, error) {
+	defer rows.Close()
+
+	operations := []OperationRecord{}
+
+	for rows.Next() {
+		var op OperationRecord
+
+		err := rows.Scan(&op.Id, &op.WalletId, &op.Amount, &op.Fee, &op.CreatedAt, &op.ReversalOf,
+			&op.Reference, &op.Description, &op.ExternalId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		operations = append(operations, op)
+	}
+
+	return operations, rows.Err()
+}
+
+// FindOperations ищет операции по кошельку, externalId и reference, начиная с последних
+func (postgres Postgres) FindOperations(filter OperationFilter) ([]OperationRecord, error) {
+	rows, err := postgres.pool.Query(context.Background(),
+		operationRecordQuery+`
+		  WHERE ($1 = '' OR wallet_id = $1)
+		    AND ($2 = '' OR external_id = $2)
+		    AND ($3 = '' OR reference = $3)
+		  ORDER BY id DESC
+		  LIMIT $4`,
+		filter.WalletId, filter.ExternalId, filter.Reference, filter.Limit)
+
+	if err != nil {
+		log.Println("error in FindOperations method: ", err)
+		return nil, DBError{}
+	}
+
+	operations, err := scanOperationRecords(rows)
+
+	if err != nil {
+		log.Println("error in FindOperations method: ", err)
+		return nil, DBError{}
+	}
+
+	return operations, nil
+}
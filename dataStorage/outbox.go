@@ -0,0 +1,254 @@
+package datastorage
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	EventWalletCreated = "wallet.created"
+	EventDeposited     = "wallet.deposited"
+	EventWithdrawn     = "wallet.withdrawn"
+)
+
+// eventsChannel - канал LISTEN/NOTIFY, в который addEvent отправляет события
+const eventsChannel = "wallet_events"
+
+// outboxLockKey - ключ advisory lock, под которым работает только одна копия relay
+const outboxLockKey = 7301
+
+// Event - событие кошелька из таблицы outbox. Balance - баланс после операции.
+type Event struct {
+	Id        int64     `json:"id"`
+	WalletId  string    `json:"walletId"`
+	Type      string    `json:"type"`
+	Amount    float64   `json:"amount"`
+	Balance   float64   `json:"balance"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// addEvent записывает событие в outbox в той же транзакции, что и изменение кошелька,
+// и ставит в очередь его доставку подписанным на него вебхукам
+func addEvent(ctx context.Context, tx pgx.Tx, uuid, eventType string, amount, balance float64) error {
+
+	event := Event{WalletId: uuid, Type: eventType, Amount: amount, Balance: balance}
+	err := tx.QueryRow(ctx,
+		"INSERT INTO outbox (wallet_id, event_type, amount, balance) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		uuid, eventType, amount, balance).Scan(&event.Id, &event.CreatedAt)
+
+	if err != nil {
+		log.Println("error in addEvent: ", err)
+		return DBError{}
+	}
+
+	// уведомление уйдёт слушателям только после коммита транзакции
+	payload, _ := json.Marshal(event)
+	_, err = tx.Exec(ctx, "SELECT pg_notify($1, $2)", eventsChannel, string(payload))
+
+	if err != nil {
+		log.Println("error in addEvent: ", err)
+		return DBError{}
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO webhook_deliveries (subscription_id, event_id)
+		 SELECT id, $1 FROM webhook_subscriptions
+		  WHERE (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+		    AND (cardinality(wallet_ids) = 0 OR $3 = ANY(wallet_ids))`,
+		event.Id, eventType, uuid)
+
+	if err != nil {
+		log.Println("error in addEvent: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
+
+// RelayEvents передаёт в publish неопубликованные события по порядку id.
+// Если публикация не удалась, событие откладывается на retryDelay(attempts),
+// а следующие события того же кошелька ждут его, чтобы не нарушать порядок.
+// Одновременно события публикует только одна копия сервера.
+// Публикация идёт вне транзакции: медленный приёмник не держит открытую транзакцию,
+// которая мешала бы vacuum на outbox. Отметки о публикации пишутся потом короткой транзакцией.
+func (postgres Postgres) RelayEvents(limit int, publish func(event Event) error, retryDelay func(attempts int) time.Duration) (int, error) {
+	ctx := context.Background()
+
+	conn, err := postgres.pool.Acquire(ctx)
+	if err != nil {
+		log.Println("error in RelayEvents method: ", err)
+		return 0, DBError{}
+	}
+	defer conn.Release()
+
+	// блокировка сессии, а не транзакции: она держится, пока идёт публикация
+	var locked bool
+	err = conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", outboxLockKey).Scan(&locked)
+	if err != nil {
+		log.Println("error in RelayEvents method: ", err)
+		return 0, DBError{}
+	}
+
+	if !locked {
+		return 0, nil
+	}
+
+	defer func() {
+		if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", outboxLockKey); err != nil {
+			log.Println("error in RelayEvents method: ", err)
+			// соединение с неснятой блокировкой не должно вернуться в пул
+			conn.Conn().Close(ctx)
+		}
+	}()
+
+	rows, err := conn.Query(ctx,
+		`SELECT o.id, o.wallet_id, o.event_type, o.amount, o.balance, o.created_at, o.attempts
+		   FROM outbox o
+		  WHERE o.published_at IS NULL
+		    AND o.next_attempt_at <= now()
+		    AND NOT EXISTS (SELECT 1 FROM outbox p
+		                     WHERE p.wallet_id = o.wallet_id AND p.published_at IS NULL
+		                       AND p.id < o.id AND p.next_attempt_at > now())
+		  ORDER BY o.id
+		  LIMIT $1`,
+		limit)
+
+	if err != nil {
+		log.Println("error in RelayEvents method: ", err)
+		return 0, DBError{}
+	}
+
+	type pendingEvent struct {
+		Event
+		attempts int
+		err      error
+	}
+
+	pending, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (pendingEvent, error) {
+		var e pendingEvent
+		err := row.Scan(&e.Id, &e.WalletId, &e.Type, &e.Amount, &e.Balance, &e.CreatedAt, &e.attempts)
+		return e, err
+	})
+
+	if err != nil {
+		log.Println("error in RelayEvents method: ", err)
+		return 0, DBError{}
+	}
+
+	var done []pendingEvent
+	blocked := map[string]bool{}
+
+	for _, e := range pending {
+		if blocked[e.WalletId] {
+			continue
+		}
+
+		if e.err = publish(e.Event); e.err != nil {
+			blocked[e.WalletId] = true
+		}
+		done = append(done, e)
+	}
+
+	if len(done) == 0 {
+		return 0, nil
+	}
+
+	// если отметки не запишутся, опубликованные события уйдут ещё раз: доставка "хотя бы один раз"
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("error in RelayEvents method: ", err)
+		return 0, DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	published := 0
+
+	for _, e := range done {
+		if e.err != nil {
+			_, err = tx.Exec(ctx,
+				`UPDATE outbox SET attempts = attempts + 1, last_error = $1, next_attempt_at = now() + $2::interval
+				  WHERE id = $3`,
+				e.err.Error(), retryDelay(e.attempts+1), e.Id)
+		} else {
+			published++
+
+			_, err = tx.Exec(ctx, "UPDATE outbox SET published_at = now() WHERE id = $1", e.Id)
+		}
+
+		if err != nil {
+			log.Println("error in RelayEvents method: ", err)
+			return 0, DBError{}
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Println("error in RelayEvents method: ", err)
+		return 0, DBError{}
+	}
+
+	return published, nil
+}
+
+// EventsAfter возвращает до limit событий кошелька с id больше afterId
+func (postgres Postgres) EventsAfter(uuid string, afterId int64, limit int) ([]Event, error) {
+
+	rows, err := postgres.pool.Query(context.Background(),
+		`SELECT id, wallet_id, event_type, amount, balance, created_at
+		   FROM outbox WHERE wallet_id = $1 AND id > $2 ORDER BY id LIMIT $3`,
+		uuid, afterId, limit)
+
+	if err != nil {
+		log.Println("error in EventsAfter method: ", err)
+		return nil, DBError{}
+	}
+
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Event, error) {
+		var e Event
+		err := row.Scan(&e.Id, &e.WalletId, &e.Type, &e.Amount, &e.Balance, &e.CreatedAt)
+		return e, err
+	})
+
+	if err != nil {
+		log.Println("error in EventsAfter method: ", err)
+		return nil, DBError{}
+	}
+
+	return events, nil
+}
+
+// ListenEvents слушает уведомления addEvent и передаёт события в publish, пока не отменён ctx
+// или не оборвалось соединение. Для прослушивания берётся отдельное соединение из пула.
+func (postgres Postgres) ListenEvents(ctx context.Context, publish func(event Event)) error {
+
+	conn, err := postgres.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		conn.Exec(context.Background(), "UNLISTEN *")
+		conn.Release()
+	}()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+eventsChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event Event
+		if err = json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			log.Println("wrong event notification: ", err)
+			continue
+		}
+
+		publish(event)
+	}
+}
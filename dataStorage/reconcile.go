@@ -0,0 +1,126 @@
+package datastorage
+
+import (
+	"context"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Mismatch - расхождение баланса кошелька с суммой проводок по его счёту, Delta = Balance - LedgerBalance
+type Mismatch struct {
+	WalletId      string  `json:"walletId"`
+	Balance       float64 `json:"balance"`
+	LedgerBalance float64 `json:"ledgerBalance"`
+	Delta         float64 `json:"delta"`
+}
+
+// mismatchQuery сравнивает баланс кошелька с суммой всех проводок по его счёту
+const mismatchQuery = `
+	SELECT w.id, w.balance::NUMERIC(20, 2)::FLOAT, COALESCE(SUM(p.amount), 0)::FLOAT
+	  FROM wallets w
+	  LEFT JOIN ledger_postings p ON p.account_id = 'wallet:' || w.id`
+
+func scanMismatch(row pgx.CollectableRow) (Mismatch, error) {
+	var mismatch Mismatch
+	err := row.Scan(&mismatch.WalletId, &mismatch.Balance, &mismatch.LedgerBalance)
+	mismatch.Delta = cents(mismatch.Balance - mismatch.LedgerBalance)
+	return mismatch, err
+}
+
+// FindMismatches пересчитывает балансы всех кошельков по журналу проводок
+// и возвращает те, что не сходятся, по порядку id
+func (postgres Postgres) FindMismatches() ([]Mismatch, error) {
+	rows, err := postgres.pool.Query(context.Background(),
+		mismatchQuery+`
+		 GROUP BY w.id, w.balance
+		HAVING w.balance::NUMERIC(20, 2) != COALESCE(SUM(p.amount), 0)
+		 ORDER BY w.id`)
+	if err != nil {
+		log.Println("error in FindMismatches method: ", err)
+		return nil, DBError{}
+	}
+
+	mismatches, err := pgx.CollectRows(rows, scanMismatch)
+	if err != nil {
+		log.Println("error in FindMismatches method: ", err)
+		return nil, DBError{}
+	}
+
+	return mismatches, nil
+}
+
+// RepairMismatch приводит журнал к балансу кошелька: разница проводится записью correction
+// против счёта system:corrections и записывается в ledger_corrections с actor и reason.
+// Кошелёк блокируется, поэтому расхождение пересчитывается без параллельных операций.
+// Возвращает исправленное расхождение, Delta 0 - исправлять было нечего.
+func (postgres Postgres) RepairMismatch(uuid, actor, reason string) (Mismatch, error) {
+	ctx := context.Background()
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in RepairMismatch method: ", err)
+		return Mismatch{}, DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	got, _, err := lockWallet(ctx, tx, uuid)
+	if err != nil {
+		log.Println("error in RepairMismatch method: ", err)
+		return Mismatch{}, DBError{}
+	}
+
+	if !got {
+		return Mismatch{}, UUIDUndefined{}
+	}
+
+	rows, err := tx.Query(ctx, mismatchQuery+" WHERE w.id = $1 GROUP BY w.id, w.balance", uuid)
+	if err == nil {
+		var mismatch Mismatch
+		mismatch, err = pgx.CollectExactlyOneRow(rows, scanMismatch)
+
+		if err == nil && mismatch.Delta == 0 {
+			return mismatch, nil
+		}
+
+		if err == nil {
+			err = bookCorrection(ctx, tx, mismatch, actor, reason)
+		}
+
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+
+		if err == nil {
+			return mismatch, nil
+		}
+	}
+
+	log.Println("error in RepairMismatch method: ", err)
+	return Mismatch{}, DBError{}
+}
+
+// bookCorrection проводит Delta на счёт кошелька против system:corrections.
+// Счёт кошелька открывается, если кошелёк создан в обход журнала.
+func bookCorrection(ctx context.Context, tx pgx.Tx, mismatch Mismatch, actor, reason string) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO ledger_accounts (id, kind, wallet_id) VALUES ('wallet:' || $1, 'wallet', $1)
+		 ON CONFLICT DO NOTHING`,
+		mismatch.WalletId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`WITH entry AS (
+		     INSERT INTO journal_entries (kind) VALUES ($8) RETURNING id),
+		 postings AS (
+		     INSERT INTO ledger_postings (entry_id, account_id, amount)
+		     SELECT entry.id, p.account_id, p.amount
+		       FROM entry, (VALUES ('wallet:' || $1, $2::NUMERIC), ($3, -$2::NUMERIC)) AS p (account_id, amount))
+		 INSERT INTO ledger_corrections (entry_id, wallet_id, balance, ledger_balance, actor, reason)
+		 SELECT entry.id, $1, $4, $5, $6, $7 FROM entry`,
+		mismatch.WalletId, mismatch.Delta, LedgerCorrections, mismatch.Balance, mismatch.LedgerBalance, actor, reason, entryCorrection)
+
+	return err
+}
@@ -0,0 +1,243 @@
+package datastorage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type OperationUndefined struct {
+}
+
+func (_ OperationUndefined) Error() string {
+	return "operation undefined"
+}
+
+type AlreadyReversed struct {
+}
+
+func (_ AlreadyReversed) Error() string {
+	return "operation is already fully reversed"
+}
+
+type ReversalExceeded struct {
+	Remaining float64
+}
+
+func (e ReversalExceeded) Error() string {
+	return fmt.Sprintf("amount exceeds remaining %v", e.Remaining)
+}
+
+type NotReversible struct {
+	Reason string
+}
+
+func (e NotReversible) Error() string {
+	return "operation can not be reversed: " + e.Reason
+}
+
+// Reversal - результат отмены операции: проведённые компенсирующие операции
+// и сумма, которую ещё можно вернуть
+type Reversal struct {
+	OperationId int64             `json:"operationId"`
+	Amount      float64           `json:"amount"`
+	Remaining   float64           `json:"remaining"`
+	Operations  []OperationRecord `json:"operations"`
+}
+
+// reversedOperation - отменяемая операция, для перевода - его списание
+type reversedOperation struct {
+	id         int64
+	walletId   string
+	amount     float64
+	fee        float64
+	reference  string
+	transferId *int64
+	reversalOf *int64
+}
+
+func lockOperation(ctx context.Context, tx pgx.Tx, id int64) (bool, reversedOperation, error) {
+	var op reversedOperation
+	err := tx.QueryRow(ctx,
+		`SELECT id, wallet_id, amount, fee, COALESCE(reference, ''), transfer_id, reversal_of
+		   FROM operations WHERE id = $1 FOR UPDATE`,
+		id).Scan(&op.id, &op.walletId, &op.amount, &op.fee, &op.reference, &op.transferId, &op.reversalOf)
+
+	if err == pgx.ErrNoRows {
+		return false, reversedOperation{}, nil
+	}
+
+	if err != nil {
+		return false, reversedOperation{}, err
+	}
+
+	return true, op, nil
+}
+
+// cents округляет сумму до копеек, чтобы убрать погрешность float
+func cents(sum float64) float64 {
+	return math.Round(sum*100) / 100
+}
+
+// ReverseOperation проводит компенсирующую операцию на amount (0 - весь остаток) со ссылкой на исходную.
+// Перевод отменяется целиком: сумма возвращается с кошелька получателя отправителю.
+// Удержанная комиссия не возвращается и в остаток не входит.
+// Исходная операция блокируется до конца транзакции, поэтому параллельные отмены
+// не могут вернуть больше, чем было проведено.
+func (postgres Postgres) ReverseOperation(id int64, amount float64, details OperationDetails) (Reversal, error) {
+	ctx := context.Background()
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in ReverseOperation method: ", err)
+		return Reversal{}, DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	// сначала узнаём, перевод ли это, чтобы части перевода всегда блокировались в одном порядке
+	var transferId *int64
+	err = tx.QueryRow(ctx, "SELECT transfer_id FROM operations WHERE id = $1", id).Scan(&transferId)
+
+	if err == pgx.ErrNoRows {
+		return Reversal{}, OperationUndefined{}
+	}
+
+	if err != nil {
+		log.Println("error in ReverseOperation method: ", err)
+		return Reversal{}, DBError{}
+	}
+
+	// перевод учитывается по списанию (его id - transfer_id), даже если передан id зачисления
+	var op, credit reversedOperation
+
+	if transferId != nil {
+		_, op, err = lockOperation(ctx, tx, *transferId)
+		if err == nil {
+			err = tx.QueryRow(ctx, "SELECT id FROM operations WHERE transfer_id = $1 AND id != $1", *transferId).Scan(&credit.id)
+		}
+		if err == nil {
+			_, credit, err = lockOperation(ctx, tx, credit.id)
+		}
+	} else {
+		_, op, err = lockOperation(ctx, tx, id)
+	}
+
+	if err != nil {
+		log.Println("error in ReverseOperation method: ", err)
+		return Reversal{}, DBError{}
+	}
+
+	if op.reversalOf != nil {
+		return Reversal{}, NotReversible{Reason: "it is a reversal itself"}
+	}
+
+	var reversed float64
+	err = tx.QueryRow(ctx,
+		"SELECT COALESCE(SUM(amount), 0) FROM operations WHERE reversal_of = $1", op.id).Scan(&reversed)
+
+	if err != nil {
+		log.Println("error in ReverseOperation method: ", err)
+		return Reversal{}, DBError{}
+	}
+
+	// комиссия не возвращается: отменить можно только то, что дошло до получателя или было выдано
+	remaining := cents(math.Abs(op.amount) - op.fee - math.Abs(reversed))
+
+	if remaining <= 0 {
+		return Reversal{}, AlreadyReversed{}
+	}
+
+	if amount == 0 {
+		amount = remaining
+	}
+
+	if amount > remaining {
+		return Reversal{}, ReversalExceeded{Remaining: remaining}
+	}
+
+	if details.Reference == "" {
+		details.Reference = op.reference
+	}
+
+	if details.Description == "" {
+		details.Description = fmt.Sprintf("reversal of operation %d", op.id)
+	}
+
+	details.reversal = true
+	reversal := Reversal{OperationId: op.id, Amount: amount, Remaining: cents(remaining - amount)}
+
+	// id компенсирующей операции -> id отменяемой
+	links := map[int64]int64{}
+
+	if op.transferId != nil {
+		// credit.walletId получал деньги, теперь возвращает их отправителю
+		debitId, creditId, err := transfer(ctx, tx, amount, credit.walletId, op.walletId, details, 0)
+		if err != nil {
+			return Reversal{}, err
+		}
+		links[debitId] = credit.id
+		links[creditId] = op.id
+	} else {
+		sum := amount
+		if op.amount > 0 {
+			sum = -amount
+		}
+
+		// отмена проводится против того же системного счёта, что и исходная операция
+		counter, found, err := counterAccount(ctx, tx, op.id)
+		if err != nil {
+			log.Println("error in ReverseOperation method: ", err)
+			return Reversal{}, DBError{}
+		}
+
+		if !found {
+			counter = LedgerCashIn
+			if sum < 0 {
+				counter = LedgerCashOut
+			}
+		}
+
+		reversalId, err := changeBalance(ctx, tx, sum, op.walletId, details)
+		if err != nil {
+			return Reversal{}, err
+		}
+
+		if err = bookEntry(ctx, tx, entryReversal, counter, reversalId); err != nil {
+			return Reversal{}, err
+		}
+		links[reversalId] = op.id
+	}
+
+	for reversalId, originalId := range links {
+		_, err = tx.Exec(ctx, "UPDATE operations SET reversal_of = $1 WHERE id = $2", originalId, reversalId)
+		if err != nil {
+			log.Println("error in ReverseOperation method: ", err)
+			return Reversal{}, DBError{}
+		}
+	}
+
+	rows, err := tx.Query(ctx, operationRecordQuery+" WHERE id = ANY($1) ORDER BY id",
+		slices.Collect(maps.Keys(links)))
+	if err != nil {
+		log.Println("error in ReverseOperation method: ", err)
+		return Reversal{}, DBError{}
+	}
+
+	reversal.Operations, err = scanOperationRecords(rows)
+	if err != nil {
+		log.Println("error in ReverseOperation method: ", err)
+		return Reversal{}, DBError{}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Println("error in ReverseOperation method: ", err)
+		return Reversal{}, DBError{}
+	}
+
+	return reversal, nil
+}
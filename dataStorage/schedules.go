@@ -0,0 +1,447 @@
+package datastorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	ScheduleActive    = "active"
+	ScheduleCompleted = "completed"
+	ScheduleCancelled = "cancelled"
+
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+
+	// MaxScheduleAttempts - сколько раз запуск повторяется после сбоя базы, прежде чем записаться отказом
+	MaxScheduleAttempts = 5
+)
+
+// scheduleRetryDelay - через сколько повторить запуск после attempts сбоев базы подряд:
+// минута, затем вдвое дольше после каждого сбоя
+func scheduleRetryDelay(attempts int) time.Duration {
+	return time.Minute << (attempts - 1)
+}
+
+// Schedule - запланированная операция. Разовая выполняется в RunAt, повторяющаяся - начиная с RunAt
+// через каждый Interval (длительность Go, например 24h) или по выражению Cron, пока не наступит EndsAt.
+// NextRunAt пустой, когда запусков больше не будет. Attempts - сколько раз запуск в NextRunAt прервал сбой базы,
+// LastError - последний такой сбой, RetryAt - когда запуск будет повторён.
+type Schedule struct {
+	Id            int64      `json:"id"`
+	WalletId      string     `json:"walletId"`
+	OperationType string     `json:"operationType"`
+	Amount        float64    `json:"amount"`
+	ToWalletId    string     `json:"toWalletId,omitempty"`
+	Reference     string     `json:"reference,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	RunAt         time.Time  `json:"runAt"`
+	Interval      string     `json:"interval,omitempty"`
+	Cron          string     `json:"cron,omitempty"`
+	EndsAt        *time.Time `json:"endsAt,omitempty"`
+	Status        string     `json:"status"`
+	NextRunAt     *time.Time `json:"nextRunAt,omitempty"`
+	Runs          int64      `json:"runs"`
+	Attempts      int        `json:"attempts,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	RetryAt       *time.Time `json:"retryAt,omitempty"`
+	Actor         string     `json:"actor"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// ScheduleRun - запуск запланированной операции: проведённая операция или причина отказа
+type ScheduleRun struct {
+	Id           int64     `json:"id"`
+	ScheduledFor time.Time `json:"scheduledFor"`
+	Status       string    `json:"status"`
+	OperationId  *int64    `json:"operationId,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	RanAt        time.Time `json:"ranAt"`
+}
+
+// ScheduleFilter - условия выбора запланированных операций. Пустые поля не ограничивают выбор.
+type ScheduleFilter struct {
+	WalletId string
+	Status   string
+	Limit    int
+}
+
+type ScheduleNotActive struct {
+	Status string
+}
+
+func (e ScheduleNotActive) Error() string {
+	return "schedule is " + e.Status
+}
+
+// operation - операция, которую проводит запуск на момент scheduledFor.
+// externalId запуска не даёт провести операцию за один момент дважды.
+func (schedule Schedule) operation(scheduledFor time.Time) Operation {
+	return Operation{
+		WalletId:      schedule.WalletId,
+		OperationType: schedule.OperationType,
+		Amount:        schedule.Amount,
+		ToWalletId:    schedule.ToWalletId,
+		OperationDetails: OperationDetails{
+			Reference:   schedule.Reference,
+			Description: schedule.Description,
+			ExternalId:  fmt.Sprintf("schedule-%d-%d", schedule.Id, scheduledFor.Unix()),
+		},
+	}
+}
+
+const scheduleColumns = `id, wallet_id, operation_type, amount, COALESCE(to_wallet_id, ''),
+        COALESCE(reference, ''), COALESCE(description, ''), run_at, COALESCE(run_interval, ''), COALESCE(cron, ''),
+        ends_at, status, next_run_at, runs, attempts, COALESCE(last_error, ''), retry_at, actor, created_at`
+
+const scheduleQuery = "SELECT " + scheduleColumns + " FROM schedules"
+
+func scanSchedule(row pgx.Row) (Schedule, error) {
+	var s Schedule
+	err := row.Scan(&s.Id, &s.WalletId, &s.OperationType, &s.Amount, &s.ToWalletId,
+		&s.Reference, &s.Description, &s.RunAt, &s.Interval, &s.Cron,
+		&s.EndsAt, &s.Status, &s.NextRunAt, &s.Runs, &s.Attempts, &s.LastError, &s.RetryAt, &s.Actor, &s.CreatedAt)
+	return s, err
+}
+
+// CreateSchedule сохраняет запланированную операцию, первый запуск - в RunAt
+func (postgres Postgres) CreateSchedule(schedule Schedule) (Schedule, error) {
+	row := postgres.pool.QueryRow(context.Background(),
+		`INSERT INTO schedules (wallet_id, operation_type, amount, to_wallet_id, reference, description,
+		                        run_at, run_interval, cron, ends_at, next_run_at, actor)
+		 VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''),
+		         $7, NULLIF($8, ''), NULLIF($9, ''), $10, $7, $11)
+		 RETURNING `+scheduleColumns,
+		schedule.WalletId, schedule.OperationType, schedule.Amount, schedule.ToWalletId, schedule.Reference, schedule.Description,
+		schedule.RunAt, schedule.Interval, schedule.Cron, schedule.EndsAt, schedule.Actor)
+
+	created, err := scanSchedule(row)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		log.Println("schedule wallet not found: ", pgErr.ConstraintName)
+		return Schedule{}, UUIDUndefined{}
+	}
+
+	if err != nil {
+		log.Println("error in CreateSchedule method: ", err)
+		return Schedule{}, DBError{}
+	}
+
+	return created, nil
+}
+
+// ListSchedules возвращает запланированные операции под фильтром, начиная с последних
+func (postgres Postgres) ListSchedules(filter ScheduleFilter) ([]Schedule, error) {
+	rows, err := postgres.pool.Query(context.Background(),
+		scheduleQuery+`
+		  WHERE ($1 = '' OR wallet_id = $1 OR to_wallet_id = $1)
+		    AND ($2 = '' OR status = $2)
+		  ORDER BY id DESC
+		  LIMIT $3`,
+		filter.WalletId, filter.Status, filter.Limit)
+
+	if err != nil {
+		log.Println("error in ListSchedules method: ", err)
+		return nil, DBError{}
+	}
+
+	schedules, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Schedule, error) {
+		return scanSchedule(row)
+	})
+
+	if err != nil {
+		log.Println("error in ListSchedules method: ", err)
+		return nil, DBError{}
+	}
+
+	return schedules, nil
+}
+
+func (postgres Postgres) GetSchedule(id int64) (bool, Schedule, error) {
+	schedule, err := scanSchedule(postgres.pool.QueryRow(context.Background(),
+		scheduleQuery+" WHERE id = $1", id))
+
+	if err == pgx.ErrNoRows {
+		return false, Schedule{}, nil
+	}
+
+	if err != nil {
+		log.Println("error in GetSchedule method: ", err)
+		return false, Schedule{}, DBError{}
+	}
+
+	return true, schedule, nil
+}
+
+// CancelSchedule отменяет будущие запуски. Завершённую или уже отменённую операцию
+// отменить нельзя - возвращается ScheduleNotActive.
+func (postgres Postgres) CancelSchedule(id int64) (bool, Schedule, error) {
+	ctx := context.Background()
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in CancelSchedule method: ", err)
+		return false, Schedule{}, DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	// блокировка ждёт, пока идущий запуск не закончится
+	schedule, err := scanSchedule(tx.QueryRow(ctx, scheduleQuery+" WHERE id = $1 FOR UPDATE", id))
+
+	if err == pgx.ErrNoRows {
+		return false, Schedule{}, nil
+	}
+
+	if err != nil {
+		log.Println("error in CancelSchedule method: ", err)
+		return false, Schedule{}, DBError{}
+	}
+
+	if schedule.Status != ScheduleActive {
+		return true, Schedule{}, ScheduleNotActive{Status: schedule.Status}
+	}
+
+	schedule, err = scanSchedule(tx.QueryRow(ctx,
+		`UPDATE schedules SET status = $2, next_run_at = NULL, retry_at = NULL WHERE id = $1 RETURNING `+scheduleColumns,
+		id, ScheduleCancelled))
+
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+
+	if err != nil {
+		log.Println("error in CancelSchedule method: ", err)
+		return false, Schedule{}, DBError{}
+	}
+
+	return true, schedule, nil
+}
+
+// ScheduleRuns возвращает последние limit запусков запланированной операции
+func (postgres Postgres) ScheduleRuns(id int64, limit int) (bool, []ScheduleRun, error) {
+	ctx := context.Background()
+
+	var exists bool
+	err := postgres.pool.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM schedules WHERE id = $1)", id).Scan(&exists)
+
+	if err != nil {
+		log.Println("error in ScheduleRuns method: ", err)
+		return false, nil, DBError{}
+	}
+
+	if !exists {
+		return false, nil, nil
+	}
+
+	rows, err := postgres.pool.Query(ctx,
+		`SELECT id, scheduled_for, status, operation_id, COALESCE(error, ''), ran_at
+		   FROM schedule_runs
+		  WHERE schedule_id = $1
+		  ORDER BY id DESC
+		  LIMIT $2`,
+		id, limit)
+
+	if err != nil {
+		log.Println("error in ScheduleRuns method: ", err)
+		return false, nil, DBError{}
+	}
+
+	runs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ScheduleRun, error) {
+		var run ScheduleRun
+		err := row.Scan(&run.Id, &run.ScheduledFor, &run.Status, &run.OperationId, &run.Error, &run.RanAt)
+		return run, err
+	})
+
+	if err != nil {
+		log.Println("error in ScheduleRuns method: ", err)
+		return false, nil, DBError{}
+	}
+
+	return true, runs, nil
+}
+
+// RunDueSchedules выполняет до limit запланированных операций, время которых пришло, каждую в своей транзакции.
+// next возвращает момент следующего запуска или false, если запусков больше не будет.
+// Операция блокируется с SKIP LOCKED, а запуск, проведённая операция и следующий момент
+// записываются одной транзакцией, поэтому несколько копий сервера не выполнят запуск дважды.
+// Запуск, прерванный сбоем базы, откладывается (см. scheduleRetryDelay), и проход продолжается с остальными.
+func (postgres Postgres) RunDueSchedules(limit int, next func(schedule Schedule) (time.Time, bool)) (int, error) {
+	ran := 0
+
+	for ran < limit {
+		got, err := postgres.runDueSchedule(next)
+		if err != nil || !got {
+			return ran, err
+		}
+		ran++
+	}
+
+	return ran, nil
+}
+
+func (postgres Postgres) runDueSchedule(next func(schedule Schedule) (time.Time, bool)) (bool, error) {
+	ctx := context.Background()
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in RunDueSchedules method: ", err)
+		return false, DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	schedule, err := scanSchedule(tx.QueryRow(ctx,
+		scheduleQuery+`
+		  WHERE status = 'active' AND next_run_at <= now() AND (retry_at IS NULL OR retry_at <= now())
+		  ORDER BY next_run_at
+		  LIMIT 1
+		    FOR UPDATE SKIP LOCKED`))
+
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+
+	if err != nil {
+		log.Println("error in RunDueSchedules method: ", err)
+		return false, DBError{}
+	}
+
+	scheduledFor := *schedule.NextRunAt
+
+	// операция проводится в точке сохранения: отказ откатывает только её, а запуск записывается
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		log.Println("error in RunDueSchedules method: ", err)
+		return false, DBError{}
+	}
+
+	status := RunSucceeded
+	var errText *string
+
+	operationId, runErr := applyOperation(ctx, savepoint, schedule.operation(scheduledFor))
+
+	// сбой базы - не отказ операции: запуск откладывается и повторяется для того же момента
+	if errors.As(runErr, &DBError{}) {
+		tx.Rollback(ctx)
+		return true, postgres.retrySchedule(schedule, runErr, next)
+	}
+
+	if runErr == nil {
+		err = savepoint.Commit(ctx)
+	} else {
+		log.Println("schedule", schedule.Id, "run failed: ", runErr)
+		err = savepoint.Rollback(ctx)
+
+		status = RunFailed
+		text := runErr.Error()
+		errText = &text
+	}
+
+	if err != nil {
+		log.Println("error in RunDueSchedules method: ", err)
+		return false, DBError{}
+	}
+
+	var operation *int64
+	if runErr == nil {
+		operation = &operationId
+	}
+
+	err = finishScheduleRun(ctx, tx, schedule, status, operation, errText, next)
+
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+
+	if err != nil {
+		log.Println("error in RunDueSchedules method: ", err)
+		return false, DBError{}
+	}
+
+	return true, nil
+}
+
+// finishScheduleRun записывает запуск в момент NextRunAt и назначает следующий
+func finishScheduleRun(ctx context.Context, tx pgx.Tx, schedule Schedule, status string, operation *int64, errText *string,
+	next func(schedule Schedule) (time.Time, bool)) error {
+
+	var nextRunAt *time.Time
+	if at, ok := next(schedule); ok {
+		nextRunAt = &at
+	}
+
+	_, err := tx.Exec(ctx,
+		`INSERT INTO schedule_runs (schedule_id, scheduled_for, status, operation_id, error)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		schedule.Id, *schedule.NextRunAt, status, operation, errText)
+
+	if err == nil {
+		_, err = tx.Exec(ctx,
+			`UPDATE schedules SET runs = runs + 1, next_run_at = $2, attempts = 0, last_error = NULL, retry_at = NULL,
+			        status = CASE WHEN $2::TIMESTAMPTZ IS NULL THEN $3 ELSE status END
+			  WHERE id = $1`,
+			schedule.Id, nextRunAt, ScheduleCompleted)
+	}
+
+	return err
+}
+
+// retrySchedule записывает попытку запуска, прерванную сбоем базы, и откладывает запуск на scheduleRetryDelay.
+// После MaxScheduleAttempts попыток запуск записывается отказом, и назначается следующий.
+// Если записать попытку не удалось, база недоступна и проход останавливается.
+func (postgres Postgres) retrySchedule(schedule Schedule, runErr error, next func(schedule Schedule) (time.Time, bool)) error {
+	ctx := context.Background()
+
+	attempts := schedule.Attempts + 1
+	text := runErr.Error()
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in RunDueSchedules method: ", err)
+		return DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	// пока блокировки не было, запуск могли выполнить или отменить
+	var locked int64
+	err = tx.QueryRow(ctx,
+		"SELECT id FROM schedules WHERE id = $1 AND status = 'active' AND next_run_at = $2 AND attempts = $3 FOR UPDATE",
+		schedule.Id, *schedule.NextRunAt, schedule.Attempts).Scan(&locked)
+
+	if err == pgx.ErrNoRows {
+		return nil
+	}
+
+	if err == nil {
+		if attempts >= MaxScheduleAttempts {
+			log.Println("schedule", schedule.Id, "run failed after", attempts, "attempts: ", runErr)
+			text = fmt.Sprintf("%s (after %d attempts)", text, attempts)
+			err = finishScheduleRun(ctx, tx, schedule, RunFailed, nil, &text, next)
+		} else {
+			delay := scheduleRetryDelay(attempts)
+			log.Println("schedule", schedule.Id, "run will be retried in", delay, ": ", runErr)
+			_, err = tx.Exec(ctx,
+				`UPDATE schedules SET attempts = $2, last_error = $3, retry_at = now() + make_interval(secs => $4)
+				  WHERE id = $1`,
+				schedule.Id, attempts, text, delay.Seconds())
+		}
+	}
+
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+
+	if err != nil {
+		log.Println("error in RunDueSchedules method: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
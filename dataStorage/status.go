@@ -0,0 +1,166 @@
+package datastorage
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	StatusActive = "active"
+	StatusFrozen = "frozen"
+	StatusClosed = "closed"
+)
+
+type WalletNotActive struct {
+	Status string
+}
+
+func (e WalletNotActive) Error() string {
+	return "wallet is " + e.Status
+}
+
+type BalanceNotZero struct {
+}
+
+func (_ BalanceNotZero) Error() string {
+	return "balance is not zero"
+}
+
+// StatusChange - запись о смене статуса кошелька: кто и почему его изменил
+type StatusChange struct {
+	Status        string    `json:"status"`
+	BlockDeposits bool      `json:"blockDeposits"`
+	Actor         string    `json:"actor"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type WalletStatus struct {
+	Status        string         `json:"status"`
+	BlockDeposits bool           `json:"blockDeposits"`
+	History       []StatusChange `json:"history"`
+}
+
+// checkStatus проверяет, что кошелёк в состоянии state допускает операцию на sum.
+// Замороженный кошелёк не допускает списаний, а пополнений - если они заблокированы.
+func checkStatus(state walletState, sum float64) error {
+	switch state.status {
+	case StatusActive:
+		return nil
+	case StatusFrozen:
+		if sum > 0 && !state.blockDeposits {
+			return nil
+		}
+	}
+
+	return WalletNotActive{Status: state.status}
+}
+
+func (postgres Postgres) ChangeStatus(uuid string, change StatusChange) error {
+	ctx := context.Background()
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in ChangeStatus method: ", err)
+		return DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	got, state, err := lockWallet(ctx, tx, uuid)
+
+	if err != nil {
+		log.Println("error in ChangeStatus method: ", err)
+		return DBError{}
+	}
+
+	if !got {
+		return UUIDUndefined{}
+	}
+
+	if state.status == StatusClosed {
+		return WalletNotActive{Status: state.status}
+	}
+
+	if change.Status == StatusClosed && state.balance != 0 {
+		return BalanceNotZero{}
+	}
+
+	if change.Status != StatusFrozen {
+		change.BlockDeposits = false
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE wallets SET status = $1, block_deposits = $2 WHERE id = $3",
+		change.Status, change.BlockDeposits, uuid)
+
+	if err != nil {
+		log.Println("error in ChangeStatus method: ", err)
+		return DBError{}
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO wallet_status_changes (wallet_id, status, block_deposits, actor, reason)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		uuid, change.Status, change.BlockDeposits, change.Actor, change.Reason)
+
+	if err != nil {
+		log.Println("error in ChangeStatus method: ", err)
+		return DBError{}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Println("error in ChangeStatus method: ", err)
+		return DBError{}
+	}
+
+	return nil
+}
+
+func (postgres Postgres) GetStatus(uuid string) (bool, WalletStatus, error) {
+	ctx := context.Background()
+
+	var status WalletStatus
+	got, state, err := scanWalletState(postgres.pool.QueryRow(ctx, walletStateQuery, uuid))
+
+	if err != nil {
+		log.Println("error in GetStatus method: ", err)
+		return false, WalletStatus{}, DBError{}
+	}
+
+	if !got {
+		return false, WalletStatus{}, nil
+	}
+
+	status.Status = state.status
+	status.BlockDeposits = state.blockDeposits
+	status.History = []StatusChange{}
+
+	rows, err := postgres.pool.Query(ctx,
+		`SELECT status, block_deposits, actor, reason, created_at
+		   FROM wallet_status_changes WHERE wallet_id = $1 ORDER BY id`,
+		uuid)
+
+	if err != nil {
+		log.Println("error in GetStatus method: ", err)
+		return false, WalletStatus{}, DBError{}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change StatusChange
+		err = rows.Scan(&change.Status, &change.BlockDeposits, &change.Actor, &change.Reason, &change.CreatedAt)
+		if err != nil {
+			log.Println("error in GetStatus method: ", err)
+			return false, WalletStatus{}, DBError{}
+		}
+		status.History = append(status.History, change)
+	}
+
+	if rows.Err() != nil {
+		log.Println("error in GetStatus method: ", rows.Err())
+		return false, WalletStatus{}, DBError{}
+	}
+
+	return true, status, nil
+}
@@ -0,0 +1,256 @@
+package datastorage
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookSubscription - подписка на события. Пустые EventTypes и WalletIds означают "все".
+type WebhookSubscription struct {
+	Id         int64     `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"eventTypes"`
+	WalletIds  []string  `json:"walletIds"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// WebhookDelivery - доставка события одной подписке, которую нужно отправить
+type WebhookDelivery struct {
+	Id       int64
+	URL      string
+	Secret   string
+	Attempts int
+	Event    Event
+}
+
+type WebhookAttempt struct {
+	AttemptedAt time.Time `json:"attemptedAt"`
+	StatusCode  *int      `json:"statusCode"`
+	Error       *string   `json:"error"`
+}
+
+// WebhookDeliveryLog - доставка вместе с историей попыток
+type WebhookDeliveryLog struct {
+	Id            int64            `json:"id"`
+	EventId       int64            `json:"eventId"`
+	EventType     string           `json:"eventType"`
+	WalletId      string           `json:"walletId"`
+	Status        string           `json:"status"`
+	NextAttemptAt time.Time        `json:"nextAttemptAt"`
+	Attempts      []WebhookAttempt `json:"attempts"`
+}
+
+func (postgres Postgres) CreateWebhook(subscription WebhookSubscription) (WebhookSubscription, error) {
+
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+
+	if subscription.WalletIds == nil {
+		subscription.WalletIds = []string{}
+	}
+
+	err := postgres.pool.QueryRow(context.Background(),
+		`INSERT INTO webhook_subscriptions (url, secret, event_types, wallet_ids)
+		 VALUES ($1, $2, $3, $4) RETURNING id, created_at`,
+		subscription.URL, subscription.Secret, subscription.EventTypes, subscription.WalletIds).
+		Scan(&subscription.Id, &subscription.CreatedAt)
+
+	if err != nil {
+		log.Println("error in CreateWebhook method: ", err)
+		return WebhookSubscription{}, DBError{}
+	}
+
+	return subscription, nil
+}
+
+// ListWebhooks возвращает подписки без секретов
+func (postgres Postgres) ListWebhooks() ([]WebhookSubscription, error) {
+
+	rows, err := postgres.pool.Query(context.Background(),
+		"SELECT id, url, event_types, wallet_ids, created_at FROM webhook_subscriptions ORDER BY id")
+
+	if err != nil {
+		log.Println("error in ListWebhooks method: ", err)
+		return nil, DBError{}
+	}
+
+	subscriptions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (WebhookSubscription, error) {
+		var s WebhookSubscription
+		err := row.Scan(&s.Id, &s.URL, &s.EventTypes, &s.WalletIds, &s.CreatedAt)
+		return s, err
+	})
+
+	if err != nil {
+		log.Println("error in ListWebhooks method: ", err)
+		return nil, DBError{}
+	}
+
+	return subscriptions, nil
+}
+
+func (postgres Postgres) DeleteWebhook(id int64) (bool, error) {
+
+	cmdTag, err := postgres.pool.Exec(context.Background(),
+		"DELETE FROM webhook_subscriptions WHERE id = $1", id)
+
+	if err != nil {
+		log.Println("error in DeleteWebhook method: ", err)
+		return false, DBError{}
+	}
+
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// WebhookDeliveries возвращает последние limit доставок подписки, при непустом status - только с этим статусом
+func (postgres Postgres) WebhookDeliveries(id int64, status string, limit int) (bool, []WebhookDeliveryLog, error) {
+	ctx := context.Background()
+
+	var exists bool
+	err := postgres.pool.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM webhook_subscriptions WHERE id = $1)", id).Scan(&exists)
+
+	if err != nil {
+		log.Println("error in WebhookDeliveries method: ", err)
+		return false, nil, DBError{}
+	}
+
+	if !exists {
+		return false, nil, nil
+	}
+
+	rows, err := postgres.pool.Query(ctx,
+		`SELECT d.id, d.event_id, o.event_type, o.wallet_id, d.status, d.next_attempt_at,
+		        COALESCE(json_agg(json_build_object(
+		            'attemptedAt', a.attempted_at, 'statusCode', a.status_code, 'error', a.error) ORDER BY a.id)
+		            FILTER (WHERE a.id IS NOT NULL), '[]')
+		   FROM webhook_deliveries d
+		   JOIN outbox o ON o.id = d.event_id
+		   LEFT JOIN webhook_attempts a ON a.delivery_id = d.id
+		  WHERE d.subscription_id = $1 AND ($2 = '' OR d.status = $2)
+		  GROUP BY d.id, o.id
+		  ORDER BY d.id DESC
+		  LIMIT $3`,
+		id, status, limit)
+
+	if err != nil {
+		log.Println("error in WebhookDeliveries method: ", err)
+		return false, nil, DBError{}
+	}
+
+	deliveries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (WebhookDeliveryLog, error) {
+		var d WebhookDeliveryLog
+		err := row.Scan(&d.Id, &d.EventId, &d.EventType, &d.WalletId, &d.Status, &d.NextAttemptAt, &d.Attempts)
+		return d, err
+	})
+
+	if err != nil {
+		log.Println("error in WebhookDeliveries method: ", err)
+		return false, nil, DBError{}
+	}
+
+	return true, deliveries, nil
+}
+
+// DeliverWebhooks отправляет через send доставки, время которых пришло, и записывает каждую попытку.
+// send возвращает HTTP код ответа (0, если ответа не было) и ошибку. После неудачи retry
+// решает, когда повторить доставку; если повторять не нужно, доставка помечается dead.
+// Доставки блокируются с SKIP LOCKED, поэтому несколько копий сервера не отправят одну дважды.
+func (postgres Postgres) DeliverWebhooks(limit int, send func(delivery WebhookDelivery) (int, error), retry func(attempts int) (time.Duration, bool)) (int, error) {
+	ctx := context.Background()
+
+	tx, err := postgres.pool.Begin(ctx)
+	if err != nil {
+		log.Println("error in DeliverWebhooks method: ", err)
+		return 0, DBError{}
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`SELECT d.id, s.url, s.secret, d.attempts,
+		        o.id, o.wallet_id, o.event_type, o.amount, o.balance, o.created_at
+		   FROM webhook_deliveries d
+		   JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		   JOIN outbox o ON o.id = d.event_id
+		  WHERE d.status = 'pending' AND d.next_attempt_at <= now()
+		  ORDER BY d.next_attempt_at
+		  LIMIT $1
+		    FOR UPDATE OF d SKIP LOCKED`,
+		limit)
+
+	if err != nil {
+		log.Println("error in DeliverWebhooks method: ", err)
+		return 0, DBError{}
+	}
+
+	deliveries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (WebhookDelivery, error) {
+		var d WebhookDelivery
+		err := row.Scan(&d.Id, &d.URL, &d.Secret, &d.Attempts,
+			&d.Event.Id, &d.Event.WalletId, &d.Event.Type, &d.Event.Amount, &d.Event.Balance, &d.Event.CreatedAt)
+		return d, err
+	})
+
+	if err != nil {
+		log.Println("error in DeliverWebhooks method: ", err)
+		return 0, DBError{}
+	}
+
+	for _, d := range deliveries {
+		statusCode, sendErr := send(d)
+
+		var code *int
+		if statusCode != 0 {
+			code = &statusCode
+		}
+
+		var errText *string
+		status := DeliveryDelivered
+		var delay time.Duration
+
+		if sendErr != nil {
+			text := sendErr.Error()
+			errText = &text
+
+			var again bool
+			delay, again = retry(d.Attempts + 1)
+
+			status = DeliveryPending
+			if !again {
+				status = DeliveryDead
+			}
+		}
+
+		_, err = tx.Exec(ctx,
+			"INSERT INTO webhook_attempts (delivery_id, status_code, error) VALUES ($1, $2, $3)",
+			d.Id, code, errText)
+
+		if err == nil {
+			_, err = tx.Exec(ctx,
+				`UPDATE webhook_deliveries SET status = $1, attempts = attempts + 1, next_attempt_at = now() + $2::interval
+				  WHERE id = $3`,
+				status, delay, d.Id)
+		}
+
+		if err != nil {
+			log.Println("error in DeliverWebhooks method: ", err)
+			return 0, DBError{}
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Println("error in DeliverWebhooks method: ", err)
+		return 0, DBError{}
+	}
+
+	return len(deliveries), nil
+}
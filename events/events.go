@@ -0,0 +1,110 @@
+// Package events раздаёт события кошельков подписчикам внутри процесса.
+package events
+
+import (
+	"log"
+	"sync"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+// subscriberBuffer - сколько событий может ждать медленный подписчик. Если буфер полон,
+// подписчик отключается (канал закрывается) и догоняет пропущенное после переподключения по Last-Event-ID
+const subscriberBuffer = 64
+
+// Broadcaster рассылает опубликованные события подписчикам их кошелька.
+// Для хранилищ, которые сами не хранят события, Broadcaster может помнить
+// последние historySize событий каждого кошелька и нумеровать их (NextId).
+type Broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan datastorage.Event]struct{}
+	history     map[string][]datastorage.Event
+	historySize int
+	lastId      int64
+}
+
+func NewBroadcaster(historySize int) *Broadcaster {
+	return &Broadcaster{
+		subscribers: map[string]map[chan datastorage.Event]struct{}{},
+		history:     map[string][]datastorage.Event{},
+		historySize: historySize,
+	}
+}
+
+// NextId выдаёт id для события, которое не пришло из хранилища
+func (b *Broadcaster) NextId() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastId++
+	return b.lastId
+}
+
+func (b *Broadcaster) Publish(event datastorage.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.historySize > 0 {
+		history := append(b.history[event.WalletId], event)
+		if len(history) > b.historySize {
+			history = history[len(history)-b.historySize:]
+		}
+		b.history[event.WalletId] = history
+	}
+
+	for ch := range b.subscribers[event.WalletId] {
+		select {
+		case ch <- event:
+		default:
+			// молча пропустить событие нельзя: поток ушёл бы дальше без него
+			log.Println("events subscriber is too slow, disconnecting:", event.WalletId)
+			b.unsubscribe(event.WalletId, ch)
+		}
+	}
+}
+
+// unsubscribe удаляет подписчика и закрывает его канал; вызывается под b.mu
+func (b *Broadcaster) unsubscribe(uuid string, ch chan datastorage.Event) {
+	if _, ok := b.subscribers[uuid][ch]; !ok {
+		return
+	}
+
+	delete(b.subscribers[uuid], ch)
+	if len(b.subscribers[uuid]) == 0 {
+		delete(b.subscribers, uuid)
+	}
+	close(ch)
+}
+
+// Subscribe подписывается на события кошелька. Возвращённую функцию нужно вызвать,
+// чтобы отписаться; после неё канал закрыт. Канал закрывается и раньше, если подписчик
+// не успевает читать события.
+func (b *Broadcaster) Subscribe(uuid string) (<-chan datastorage.Event, func()) {
+	ch := make(chan datastorage.Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[uuid] == nil {
+		b.subscribers[uuid] = map[chan datastorage.Event]struct{}{}
+	}
+	b.subscribers[uuid][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.unsubscribe(uuid, ch)
+	}
+}
+
+// EventsAfter возвращает сохранённые события кошелька с id больше afterId
+func (b *Broadcaster) EventsAfter(uuid string, afterId int64, limit int) ([]datastorage.Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var events []datastorage.Event
+	for _, event := range b.history[uuid] {
+		if event.Id > afterId && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishToSubscribers(t *testing.T) {
+	b := NewBroadcaster(0)
+
+	first, unsubscribeFirst := b.Subscribe("asd1")
+	defer unsubscribeFirst()
+
+	other, unsubscribeOther := b.Subscribe("asd2")
+	defer unsubscribeOther()
+
+	b.Publish(datastorage.Event{Id: 1, WalletId: "asd1", Type: datastorage.EventDeposited})
+
+	assert.Equal(t, int64(1), (<-first).Id)
+	assert.Empty(t, other)
+}
+
+func TestUnsubscribeClosesChannel(t *testing.T) {
+	b := NewBroadcaster(0)
+
+	ch, unsubscribe := b.Subscribe("asd1")
+	unsubscribe()
+	unsubscribe()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	// публикация без подписчиков не блокируется
+	b.Publish(datastorage.Event{Id: 1, WalletId: "asd1"})
+}
+
+func TestSlowSubscriberIsDisconnected(t *testing.T) {
+	b := NewBroadcaster(0)
+
+	ch, unsubscribe := b.Subscribe("asd1")
+	defer unsubscribe()
+
+	fast, unsubscribeFast := b.Subscribe("asd1")
+	defer unsubscribeFast()
+
+	for i := 1; i <= subscriberBuffer+10; i++ {
+		b.Publish(datastorage.Event{Id: int64(i), WalletId: "asd1"})
+		if i <= subscriberBuffer {
+			<-fast
+		}
+	}
+
+	// подписчик получает всё, что успело попасть в буфер, и затем закрытый канал, а не поток с пропусками
+	var ids []int64
+	for event := range ch {
+		ids = append(ids, event.Id)
+	}
+	assert.Len(t, ids, subscriberBuffer)
+	assert.Equal(t, int64(subscriberBuffer), ids[len(ids)-1])
+
+	// успевающий подписчик остаётся подключённым
+	assert.Len(t, fast, 10)
+}
+
+func TestEventsAfter(t *testing.T) {
+	b := NewBroadcaster(2)
+
+	for i := 0; i < 3; i++ {
+		b.Publish(datastorage.Event{Id: b.NextId(), WalletId: "asd1"})
+	}
+	b.Publish(datastorage.Event{Id: b.NextId(), WalletId: "asd2"})
+
+	events, err := b.EventsAfter("asd1", 2, 10)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, int64(3), events[0].Id)
+
+	// хранятся только последние 2 события кошелька
+	events, _ = b.EventsAfter("asd1", 0, 10)
+	assert.Len(t, events, 2)
+	assert.Equal(t, int64(2), events[0].Id)
+}
+
+func TestNoHistory(t *testing.T) {
+	b := NewBroadcaster(0)
+
+	b.Publish(datastorage.Event{Id: 1, WalletId: "asd1"})
+
+	events, err := b.EventsAfter("asd1", 0, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,135 @@
+// Package interest начисляет проценты на остатки кошельков по ставкам их тарифов.
+package interest
+
+import (
+	"context"
+	"log"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+type Store interface {
+	LastInterestPeriod() (time.Time, bool, error)
+	InterestWallets(period time.Time) ([]string, error)
+	AccrueInterest(uuid string, period time.Time) (datastorage.Accrual, bool, error)
+}
+
+// Result - начисление кошельку или причина, по которой оно не прошло
+type Result struct {
+	datastorage.Accrual
+	Error string `json:"error,omitempty"`
+}
+
+// Accruer начисляет проценты за прошедший день. Запуск можно повторять сколько угодно:
+// за один день кошельку начисляется не больше одного раза.
+type Accruer struct {
+	Store    Store
+	Interval time.Duration
+	// Delay - отставание от полуночи: проводки ещё не закоммиченных транзакций
+	// прошедшего дня должны попасть в баланс конца дня
+	Delay time.Duration
+}
+
+func NewAccruer(store Store) Accruer {
+	return Accruer{
+		Store:    store,
+		Interval: time.Hour,
+		Delay:    5 * time.Minute,
+	}
+}
+
+// Period возвращает последний завершившийся к now - Delay день (полночь UTC)
+func (accruer Accruer) Period(now time.Time) time.Time {
+	return now.Add(-accruer.Delay).UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+}
+
+// Accrue начисляет проценты за день period всем кошелькам, которым они ещё не начислены.
+// Ошибка одного кошелька не останавливает остальные, она попадает в Result.Error.
+func (accruer Accruer) Accrue(period time.Time) ([]Result, error) {
+	uuids, err := accruer.Store.InterestWallets(period)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(uuids))
+
+	for _, uuid := range uuids {
+		accrual, ok, err := accruer.Store.AccrueInterest(uuid, period)
+
+		switch {
+		case err != nil:
+			results = append(results, Result{
+				Accrual: datastorage.Accrual{WalletId: uuid, Period: period},
+				Error:   err.Error(),
+			})
+		case ok:
+			results = append(results, Result{Accrual: accrual})
+		}
+	}
+
+	return results, nil
+}
+
+// RunOnce начисляет проценты за Period текущего времени и за дни после последнего начисления,
+// пропущенные, пока сервер не работал
+func (accruer Accruer) RunOnce() ([]Result, error) {
+	return accruer.catchUp(time.Now())
+}
+
+func (accruer Accruer) catchUp(now time.Time) ([]Result, error) {
+	to := accruer.Period(now)
+	from := to
+
+	last, ok, err := accruer.Store.LastInterestPeriod()
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && last.Before(to) {
+		from = last.AddDate(0, 0, 1)
+	}
+
+	var results []Result
+	for period := from; !period.After(to); period = period.AddDate(0, 0, 1) {
+		accrued, err := accruer.Accrue(period)
+		results = append(results, accrued...)
+
+		if err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// Run начисляет проценты раз в Interval, пока не отменён ctx
+func (accruer Accruer) Run(ctx context.Context) {
+	log.Println("interest accrual started")
+
+	for {
+		results, err := accruer.RunOnce()
+		if err != nil {
+			log.Println("interest accrual error:", err)
+		}
+
+		failed := 0
+		for _, result := range results {
+			if result.Error != "" {
+				failed++
+				log.Println("interest accrual failed:", result.WalletId, result.Error)
+			}
+		}
+
+		if len(results) > 0 {
+			log.Println("interest accrued:", len(results)-failed, "failed:", failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("interest accrual stopped")
+			return
+		case <-time.After(accruer.Interval):
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package interest
+
+import (
+	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore начисляет по 0.01 и помнит начисленные дни
+type fakeStore struct {
+	wallets []string
+	failed  map[string]error
+	accrued map[string]bool
+}
+
+func (store *fakeStore) LastInterestPeriod() (time.Time, bool, error) {
+	var last time.Time
+	for key := range store.accrued {
+		period, _ := time.Parse(time.DateOnly, key[len(key)-len(time.DateOnly):])
+		if period.After(last) {
+			last = period
+		}
+	}
+	return last, !last.IsZero(), nil
+}
+
+func (store *fakeStore) InterestWallets(period time.Time) ([]string, error) {
+	var uuids []string
+	for _, uuid := range store.wallets {
+		if !store.accrued[uuid+period.Format(time.DateOnly)] {
+			uuids = append(uuids, uuid)
+		}
+	}
+	return uuids, nil
+}
+
+func (store *fakeStore) AccrueInterest(uuid string, period time.Time) (datastorage.Accrual, bool, error) {
+	if err := store.failed[uuid]; err != nil {
+		return datastorage.Accrual{}, false, err
+	}
+
+	key := uuid + period.Format(time.DateOnly)
+	if store.accrued[key] {
+		return datastorage.Accrual{}, false, nil
+	}
+	store.accrued[key] = true
+
+	return datastorage.Accrual{WalletId: uuid, Period: period, Amount: 0.01}, true, nil
+}
+
+func TestPeriod(t *testing.T) {
+	accruer := NewAccruer(nil)
+
+	assert.Equal(t, time.Date(2026, 9, 29, 0, 0, 0, 0, time.UTC),
+		accruer.Period(time.Date(2026, 10, 1, 0, 3, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC),
+		accruer.Period(time.Date(2026, 10, 1, 0, 7, 0, 0, time.UTC)))
+	// день считается по UTC
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		accruer.Period(time.Date(2026, 10, 1, 23, 0, 0, 0, time.FixedZone("UTC-3", -3*3600))))
+}
+
+func TestAccrue(t *testing.T) {
+	store := &fakeStore{
+		wallets: []string{"a", "b", "c"},
+		failed:  map[string]error{"c": datastorage.WalletNotActive{Status: datastorage.StatusFrozen}},
+		accrued: map[string]bool{},
+	}
+	accruer := NewAccruer(store)
+	period := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	results, err := accruer.Accrue(period)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Result{
+		{Accrual: datastorage.Accrual{WalletId: "a", Period: period, Amount: 0.01}},
+		{Accrual: datastorage.Accrual{WalletId: "b", Period: period, Amount: 0.01}},
+		{Accrual: datastorage.Accrual{WalletId: "c", Period: period}, Error: "wallet is frozen"},
+	}, results)
+
+	// повторный запуск за тот же день начисляет только тому, кому не удалось
+	delete(store.failed, "c")
+
+	results, err = accruer.Accrue(period)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "c", results[0].WalletId)
+
+	results, err = accruer.Accrue(period)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestCatchUp(t *testing.T) {
+	store := &fakeStore{
+		wallets: []string{"a"},
+		accrued: map[string]bool{"a2026-09-27": true},
+	}
+	accruer := NewAccruer(store)
+
+	// сервер не работал 28 и 29 сентября: вместе с 30-м начисляются и они
+	results, err := accruer.catchUp(time.Date(2026, 10, 1, 1, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, time.Date(2026, 9, 28, 0, 0, 0, 0, time.UTC), results[0].Period)
+	assert.Equal(t, time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC), results[2].Period)
+
+	results, err = accruer.catchUp(time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	// без прошлых начислений начисляется только последний день
+	store = &fakeStore{wallets: []string{"a"}, accrued: map[string]bool{}}
+
+	results, err = NewAccruer(store).catchUp(time.Date(2026, 10, 1, 1, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC), results[0].Period)
+}
@@ -0,0 +1,141 @@
+package ledger
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+var csvHeader = []string{"walletId", "balance", "ledgerBalance", "delta", "repaired", "error"}
+
+type ReconcileStore interface {
+	FindMismatches() ([]datastorage.Mismatch, error)
+	RepairMismatch(uuid, actor, reason string) (datastorage.Mismatch, error)
+}
+
+// Result - расхождение кошелька и итог его исправления
+type Result struct {
+	datastorage.Mismatch
+	Repaired bool   `json:"repaired"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Reconciler сверяет балансы кошельков с журналом проводок. С Repair расхождения
+// исправляются проводкой на счёт system:corrections от имени Actor.
+type Reconciler struct {
+	Store    ReconcileStore
+	Interval time.Duration
+	Repair   bool
+	Actor    string
+	Reason   string
+}
+
+func NewReconciler(store ReconcileStore) Reconciler {
+	return Reconciler{
+		Store:    store,
+		Interval: 24 * time.Hour,
+		Actor:    "reconciler",
+		Reason:   "scheduled reconciliation",
+	}
+}
+
+// RunOnce находит расхождения и исправляет их, если задан Repair.
+// Ошибка исправления одного кошелька не останавливает остальные, она попадает в Result.Error.
+func (reconciler Reconciler) RunOnce() ([]Result, error) {
+	mismatches, err := reconciler.Store.FindMismatches()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(mismatches))
+
+	for _, mismatch := range mismatches {
+		result := Result{Mismatch: mismatch}
+
+		if reconciler.Repair {
+			// расхождение пересчитывается под блокировкой кошелька и может отличаться от найденного
+			repaired, err := reconciler.Store.RepairMismatch(mismatch.WalletId, reconciler.Actor, reconciler.Reason)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Mismatch = repaired
+				result.Repaired = repaired.Delta != 0
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// Run сверяет журнал раз в Interval, пока не отменён ctx, и пишет расхождения в лог
+func (reconciler Reconciler) Run(ctx context.Context) {
+	log.Println("ledger reconciliation started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("ledger reconciliation stopped")
+			return
+		case <-time.After(reconciler.Interval):
+		}
+
+		results, err := reconciler.RunOnce()
+		if err != nil {
+			log.Println("ledger reconciliation error:", err)
+			continue
+		}
+
+		for _, result := range results {
+			log.Println("ledger mismatch:", result.WalletId, "balance", result.Balance,
+				"ledger", result.LedgerBalance, "delta", result.Delta, "repaired", result.Repaired, result.Error)
+		}
+
+		log.Println("ledger reconciliation done, mismatches:", len(results))
+	}
+}
+
+func ValidFormat(format string) bool {
+	return format == FormatJSON || format == FormatCSV
+}
+
+// WriteReport пишет результаты сверки JSON-массивом или CSV с заголовком
+func WriteReport(w io.Writer, format string, results []Result) error {
+	switch format {
+	case FormatJSON:
+		if results == nil {
+			results = []Result{}
+		}
+		return json.NewEncoder(w).Encode(results)
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		writer.Write(csvHeader)
+		for _, result := range results {
+			writer.Write([]string{
+				result.WalletId,
+				strconv.FormatFloat(result.Balance, 'f', -1, 64),
+				strconv.FormatFloat(result.LedgerBalance, 'f', -1, 64),
+				strconv.FormatFloat(result.Delta, 'f', -1, 64),
+				strconv.FormatBool(result.Repaired),
+				result.Error,
+			})
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	return errors.New("unknown format: " + format)
+}
@@ -0,0 +1,86 @@
+package ledger
+
+import (
+	"bytes"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeReconcileStore struct {
+	mismatches []datastorage.Mismatch
+	repaired   []string
+}
+
+func (store *fakeReconcileStore) FindMismatches() ([]datastorage.Mismatch, error) {
+	return store.mismatches, nil
+}
+
+func (store *fakeReconcileStore) RepairMismatch(uuid, actor, reason string) (datastorage.Mismatch, error) {
+	store.repaired = append(store.repaired, uuid+" "+actor+" "+reason)
+
+	switch uuid {
+	case "asd2":
+		// за время сверки операция уже выровняла кошелёк
+		return datastorage.Mismatch{WalletId: uuid, Balance: 5, LedgerBalance: 5}, nil
+	case "asd3":
+		return datastorage.Mismatch{}, datastorage.DBError{}
+	}
+
+	for _, mismatch := range store.mismatches {
+		if mismatch.WalletId == uuid {
+			return mismatch, nil
+		}
+	}
+	return datastorage.Mismatch{}, datastorage.UUIDUndefined{}
+}
+
+func testMismatches() []datastorage.Mismatch {
+	return []datastorage.Mismatch{
+		{WalletId: "asd1", Balance: 100, LedgerBalance: 90.5, Delta: 9.5},
+		{WalletId: "asd2", Balance: 5, LedgerBalance: 4, Delta: 1},
+		{WalletId: "asd3", Balance: 0, LedgerBalance: 1, Delta: -1},
+	}
+}
+
+func TestReconcileReport(t *testing.T) {
+	store := &fakeReconcileStore{mismatches: testMismatches()}
+
+	results, err := NewReconciler(store).RunOnce()
+	require.NoError(t, err)
+	assert.Empty(t, store.repaired)
+	require.Len(t, results, 3)
+	assert.False(t, results[0].Repaired)
+
+	var out bytes.Buffer
+	require.NoError(t, WriteReport(&out, FormatCSV, results[:1]))
+	assert.Equal(t, "walletId,balance,ledgerBalance,delta,repaired,error\nasd1,100,90.5,9.5,false,\n", out.String())
+
+	out.Reset()
+	require.NoError(t, WriteReport(&out, FormatJSON, nil))
+	assert.Equal(t, "[]\n", out.String())
+
+	assert.Error(t, WriteReport(&out, "xml", results))
+}
+
+func TestReconcileRepair(t *testing.T) {
+	store := &fakeReconcileStore{mismatches: testMismatches()}
+
+	reconciler := NewReconciler(store)
+	reconciler.Repair = true
+	reconciler.Actor = "ivan"
+	reconciler.Reason = "manual fix"
+
+	results, err := reconciler.RunOnce()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"asd1 ivan manual fix", "asd2 ivan manual fix", "asd3 ivan manual fix"}, store.repaired)
+	assert.Equal(t, []Result{
+		{Mismatch: datastorage.Mismatch{WalletId: "asd1", Balance: 100, LedgerBalance: 90.5, Delta: 9.5}, Repaired: true},
+		{Mismatch: datastorage.Mismatch{WalletId: "asd2", Balance: 5, LedgerBalance: 5}},
+		{Mismatch: datastorage.Mismatch{WalletId: "asd3", Balance: 0, LedgerBalance: 1, Delta: -1}, Error: "DB error"},
+	}, results)
+}
@@ -0,0 +1,62 @@
+// Package ledger - фоновые задачи журнала проводок.
+package ledger
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type SnapshotStore interface {
+	TakeBalanceSnapshots(at time.Time) (int64, error)
+}
+
+// Snapshotter периодически сохраняет остатки счетов, чтобы баланс на момент времени
+// считался от ближайшего снимка, а не по всей истории счёта
+type Snapshotter struct {
+	Store    SnapshotStore
+	Interval time.Duration
+	// Delay - отставание снимка от текущего времени: проводки ещё не закоммиченных
+	// транзакций не должны оказаться до момента снимка
+	Delay time.Duration
+}
+
+func NewSnapshotter(store SnapshotStore) Snapshotter {
+	return Snapshotter{
+		Store:    store,
+		Interval: time.Hour,
+		Delay:    5 * time.Minute,
+	}
+}
+
+// Cutoff возвращает момент снимка для now: now - Delay, округлённое вниз до Interval,
+// поэтому несколько экземпляров сервера пишут снимки на одни и те же моменты
+func (snapshotter Snapshotter) Cutoff(now time.Time) time.Time {
+	return now.Add(-snapshotter.Delay).Truncate(snapshotter.Interval)
+}
+
+// RunOnce снимает остатки на Cutoff текущего времени и возвращает число снимков
+func (snapshotter Snapshotter) RunOnce() (int64, error) {
+	return snapshotter.Store.TakeBalanceSnapshots(snapshotter.Cutoff(time.Now()))
+}
+
+// Run снимает остатки раз в Interval, пока не отменён ctx
+func (snapshotter Snapshotter) Run(ctx context.Context) {
+	log.Println("balance snapshots started")
+
+	for {
+		taken, err := snapshotter.RunOnce()
+		if err != nil {
+			log.Println("balance snapshots error:", err)
+		} else if taken > 0 {
+			log.Println("balance snapshots taken:", taken)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("balance snapshots stopped")
+			return
+		case <-time.After(snapshotter.Interval):
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSnapshotStore struct {
+	taken []time.Time
+}
+
+func (store *fakeSnapshotStore) TakeBalanceSnapshots(at time.Time) (int64, error) {
+	store.taken = append(store.taken, at)
+	return 1, nil
+}
+
+func TestCutoff(t *testing.T) {
+	snapshotter := NewSnapshotter(nil)
+
+	now := time.Date(2026, 10, 1, 0, 3, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 9, 30, 23, 0, 0, 0, time.UTC), snapshotter.Cutoff(now))
+
+	now = time.Date(2026, 10, 1, 0, 7, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), snapshotter.Cutoff(now))
+}
+
+func TestRunOnce(t *testing.T) {
+	store := &fakeSnapshotStore{}
+	snapshotter := NewSnapshotter(store)
+
+	taken, err := snapshotter.RunOnce()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), taken)
+
+	require.Len(t, store.taken, 1)
+	assert.True(t, store.taken[0].Before(time.Now().Add(-snapshotter.Delay)))
+	assert.Equal(t, store.taken[0], store.taken[0].Truncate(time.Hour))
+}
@@ -1,37 +1,155 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 	datastorage "walletGolang/dataStorage"
+	"walletGolang/interest"
+	"walletGolang/ledger"
+	"walletGolang/migrations"
+	"walletGolang/outbox"
+	"walletGolang/scheduler"
 	"walletGolang/server"
+	"walletGolang/webhook"
 
 	"github.com/joho/godotenv"
 )
 
-func startServer() {
+func connectDB() (datastorage.Postgres, error) {
 	err := godotenv.Load("config.env")
 
 	if err != nil {
-		fmt.Println(err)
-		return
+		return datastorage.Postgres{}, err
+	}
+
+	return datastorage.NewPostgresFromEnv()
+}
+
+// migrateOnStart применяет встроенные миграции (если не задан MIGRATE_ON_START=false)
+// и не даёт запустить сервер на схеме старее кода
+func migrateOnStart(db datastorage.Postgres) error {
+	list, err := migrations.Load()
+	if err != nil {
+		return err
 	}
 
-	host := os.Getenv("POSTGRES_HOST")
-	dbPort := "5432"
-	user := os.Getenv("POSTGRES_USER")
-	password := os.Getenv("POSTGRES_PASSWORD")
-	dbName := os.Getenv("POSTGRES_DB")
+	if os.Getenv("MIGRATE_ON_START") != "false" {
+		applied, err := db.MigrateUp(list)
+		for _, migration := range applied {
+			log.Printf("migration applied: %d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
 
-	db, err := datastorage.NewPostgres(host, dbPort, user, password, dbName)
+	return db.CheckSchema(list)
+}
+
+func startServer() {
+	db, err := connectDB()
 
 	if err != nil {
-		log.Fatal(err)
+		fmt.Println(err)
 		return
 	}
 
-	server := server.Server{}
+	if err = migrateOnStart(db); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	server := server.Server{AdminToken: os.Getenv("ADMIN_TOKEN"), GRPCPort: os.Getenv("GRPC_PORT")}
+
+	if batchLimit := os.Getenv("BATCH_LIMIT"); batchLimit != "" {
+		server.BatchLimit, err = strconv.Atoi(batchLimit)
+
+		if err != nil {
+			log.Fatal("wrong BATCH_LIMIT: ", err)
+			return
+		}
+	}
+
+	if sinkName := os.Getenv("OUTBOX_SINK"); sinkName != "" {
+		sink, err := outbox.NewSink(sinkName, os.Getenv("OUTBOX_TARGET"))
+
+		if err != nil {
+			log.Fatal("wrong OUTBOX_SINK: ", err)
+			return
+		}
+
+		go outbox.NewRelay(db, sink).Run(context.Background())
+	}
+
+	go webhook.NewDispatcher(db).Run(context.Background())
+
+	snapshotter := ledger.NewSnapshotter(db)
+
+	if interval := os.Getenv("BALANCE_SNAPSHOT_INTERVAL"); interval != "" {
+		snapshotter.Interval, err = time.ParseDuration(interval)
+
+		if err != nil {
+			log.Fatal("wrong BALANCE_SNAPSHOT_INTERVAL: ", err)
+			return
+		}
+	}
+
+	// нулевой интервал отключает снимки, баланс на момент тогда считается по всей истории
+	if snapshotter.Interval > 0 {
+		go snapshotter.Run(context.Background())
+	}
+
+	reconciler := ledger.NewReconciler(db)
+	reconciler.Repair = os.Getenv("RECONCILE_REPAIR") == "true"
+
+	if interval := os.Getenv("RECONCILE_INTERVAL"); interval != "" {
+		reconciler.Interval, err = time.ParseDuration(interval)
+
+		if err != nil {
+			log.Fatal("wrong RECONCILE_INTERVAL: ", err)
+			return
+		}
+	}
+
+	if reconciler.Interval > 0 {
+		go reconciler.Run(context.Background())
+	}
+
+	operations := scheduler.NewScheduler(db)
+
+	if interval := os.Getenv("SCHEDULER_INTERVAL"); interval != "" {
+		operations.Interval, err = time.ParseDuration(interval)
+
+		if err != nil {
+			log.Fatal("wrong SCHEDULER_INTERVAL: ", err)
+			return
+		}
+	}
+
+	// нулевой интервал отключает выполнение запланированных операций на этой копии сервера
+	if operations.Interval > 0 {
+		go operations.Run(context.Background())
+	}
+
+	accruer := interest.NewAccruer(db)
+
+	if interval := os.Getenv("INTEREST_INTERVAL"); interval != "" {
+		accruer.Interval, err = time.ParseDuration(interval)
+
+		if err != nil {
+			log.Fatal("wrong INTEREST_INTERVAL: ", err)
+			return
+		}
+	}
+
+	// нулевой интервал отключает начисление процентов, его можно запускать командой accrue
+	if accruer.Interval > 0 {
+		go accruer.Run(context.Background())
+	}
 
 	servePort := os.Getenv("SERVER_PORT")
 
@@ -39,5 +157,20 @@ func startServer() {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
 	startServer()
 }
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage:
+  runServer                                          start the server
+  runServer import -format csv|jsonl -file FILE      import wallets with opening balances
+  runServer export -format csv|jsonl [-file FILE]    export all wallets and balances
+  runServer migrate up | down [-steps N] | status    apply, revert or show database migrations
+  runServer reconcile [-format json|csv] [-file FILE] [-repair -actor NAME -reason TEXT]
+                                                     compare wallet balances with the ledger
+  runServer accrue [-from YYYY-MM-DD] [-to YYYY-MM-DD] accrue interest for the days, yesterday by default`)
+}
@@ -1,67 +1,22 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"os"
 	"sync"
 	"testing"
 	"time"
+	"walletGolang/client"
 
 	"github.com/joho/godotenv"
 )
 
-type createWallet struct {
-	Id string `json:"walletId"`
-}
-
-type UpdateWallet struct {
-	WalletId      string  `json:"walletId"`
-	OperationType string  `json:"operationType"`
-	Amount        float32 `json:"amount"`
-}
-
-func createUser(name string) error {
-
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
-
-	createWallet := createWallet{
-		Id: name,
-	}
-
-	body, err := json.Marshal(createWallet)
-	if err != nil {
-		return err
-	}
-
-	url := "http://localhost:80/api/v1/wallets/wallet/create"
-
-	req, err := http.NewRequest(
-		http.MethodPost,
-		url,
-		bytes.NewReader(body),
-	)
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-
-	resp.Body.Close()
-
-	return nil
+func newClient() *client.Client {
+	return client.New("http://localhost"+os.Getenv("SERVER_PORT"), client.WithTimeout(5*time.Second))
 }
 
 func TestMain(m *testing.M) {
@@ -76,10 +31,10 @@ func TestMain(m *testing.M) {
 
 	time.Sleep(5 * time.Second)
 
-	err = createUser("asd1")
-
-	if err != nil {
+	err = newClient().CreateWallet(context.Background(), "asd1")
 
+	if err != nil && !errors.Is(err, client.ErrWalletExists) {
+		fmt.Println("create wallet:", err)
 	}
 
 	code := m.Run()
@@ -93,9 +48,7 @@ func TestManyGetRequest(t *testing.T) {
 	requests := 2000
 	var wg sync.WaitGroup
 
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
+	c := newClient()
 
 	start := time.Now()
 
@@ -106,15 +59,9 @@ func TestManyGetRequest(t *testing.T) {
 		wg.Go(func() {
 			defer func() { <-sem }()
 
-			resp, err := client.Get("http://localhost" + os.Getenv("SERVER_PORT") + "/api/v1/wallets/asd1")
+			_, err := c.GetBalance(context.Background(), "asd1")
 			if err != nil {
 				t.Error(err)
-				return
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusOK {
-				t.Errorf("status: %d", resp.StatusCode)
 			}
 		})
 	}
@@ -130,24 +77,11 @@ func TestManyGetRequest(t *testing.T) {
 
 func TestManyPostRequest(t *testing.T) {
 
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
+	c := newClient()
 
 	requests := 2000
 	var wg sync.WaitGroup
 
-	updateWallet := UpdateWallet{
-		WalletId:      "asd1",
-		OperationType: "DEPOSIT",
-		Amount:        1,
-	}
-
-	body, err := json.Marshal(updateWallet)
-	if err != nil {
-		panic(err)
-	}
-
 	start := time.Now()
 
 	sem := make(chan struct{}, 100)
@@ -157,16 +91,9 @@ func TestManyPostRequest(t *testing.T) {
 		wg.Go(func() {
 			defer func() { <-sem }()
 
-			resp, err := client.Post("http://localhost"+os.Getenv("SERVER_PORT")+"/api/v1/wallets/wallet",
-				"application/json", bytes.NewReader(body))
+			err := c.Deposit(context.Background(), "asd1", 1)
 			if err != nil {
 				t.Error(err)
-				return
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusOK {
-				t.Errorf("status: %d", resp.StatusCode)
 			}
 		})
 	}
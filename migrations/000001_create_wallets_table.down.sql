@@ -1 +1 @@
-DROP TABLE IF EXISTS wallets;
\ No newline at end of file
+DROP TABLE IF EXISTS wallets;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS operations;
+DROP TABLE IF EXISTS wallet_limits;
+ALTER TABLE wallets DROP COLUMN IF EXISTS tier;
+DROP TABLE IF EXISTS wallet_tiers;
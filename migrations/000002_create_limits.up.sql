@@ -0,0 +1,28 @@
+CREATE TABLE wallet_tiers (
+    name                  TEXT PRIMARY KEY CHECK (name != ''),
+    max_withdrawal        FLOAT   CHECK (max_withdrawal > 0),
+    daily_withdrawal      FLOAT   CHECK (daily_withdrawal > 0),
+    monthly_withdrawal    FLOAT   CHECK (monthly_withdrawal > 0),
+    operations_per_minute INTEGER CHECK (operations_per_minute > 0)
+);
+
+INSERT INTO wallet_tiers (name) VALUES ('default');
+
+ALTER TABLE wallets ADD COLUMN tier TEXT NOT NULL DEFAULT 'default' REFERENCES wallet_tiers (name);
+
+CREATE TABLE wallet_limits (
+    wallet_id             TEXT PRIMARY KEY REFERENCES wallets (id) ON DELETE CASCADE,
+    max_withdrawal        FLOAT   CHECK (max_withdrawal > 0),
+    daily_withdrawal      FLOAT   CHECK (daily_withdrawal > 0),
+    monthly_withdrawal    FLOAT   CHECK (monthly_withdrawal > 0),
+    operations_per_minute INTEGER CHECK (operations_per_minute > 0)
+);
+
+CREATE TABLE operations (
+    id         BIGSERIAL PRIMARY KEY,
+    wallet_id  TEXT NOT NULL REFERENCES wallets (id),
+    amount     FLOAT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX operations_wallet_created_idx ON operations (wallet_id, created_at);
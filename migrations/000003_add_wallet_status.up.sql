@@ -0,0 +1,14 @@
+ALTER TABLE wallets ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'frozen', 'closed'));
+ALTER TABLE wallets ADD COLUMN block_deposits BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE wallet_status_changes (
+    id             BIGSERIAL PRIMARY KEY,
+    wallet_id      TEXT NOT NULL REFERENCES wallets (id),
+    status         TEXT NOT NULL,
+    block_deposits BOOLEAN NOT NULL,
+    actor          TEXT NOT NULL,
+    reason         TEXT NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX wallet_status_changes_wallet_idx ON wallet_status_changes (wallet_id, created_at);
@@ -0,0 +1,17 @@
+-- без кредитной линии баланс не может быть отрицательным: откат возможен, только когда долгов нет,
+-- иначе их нужно сначала погасить (или списать) вручную
+DO $$
+DECLARE
+    negative BIGINT;
+BEGIN
+    SELECT count(*) INTO negative FROM wallets WHERE balance < 0;
+    IF negative > 0 THEN
+        RAISE EXCEPTION 'cannot revert credit limits: % wallets have negative balance', negative
+            USING ERRCODE = 'check_violation',
+                  HINT = 'settle them first: SELECT id, balance FROM wallets WHERE balance < 0';
+    END IF;
+END
+$$;
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS credit_limit;
+ALTER TABLE wallets ADD CONSTRAINT wallets_balance_check CHECK (balance >= 0);
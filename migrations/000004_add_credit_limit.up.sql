@@ -0,0 +1,2 @@
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_balance_check;
+ALTER TABLE wallets ADD COLUMN credit_limit FLOAT NOT NULL DEFAULT 0 CHECK (credit_limit >= 0);
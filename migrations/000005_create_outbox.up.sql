@@ -0,0 +1,15 @@
+CREATE TABLE outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    wallet_id       TEXT NOT NULL,
+    event_type      TEXT NOT NULL,
+    amount          FLOAT NOT NULL,
+    balance         FLOAT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at    TIMESTAMPTZ,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error      TEXT
+);
+
+CREATE INDEX outbox_pending_idx ON outbox (id) WHERE published_at IS NULL;
+CREATE INDEX outbox_wallet_idx ON outbox (wallet_id, id);
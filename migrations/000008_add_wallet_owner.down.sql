@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS wallets_status_idx;
+DROP INDEX IF EXISTS wallets_owner_idx;
+DROP INDEX IF EXISTS wallets_created_idx;
+DROP INDEX IF EXISTS wallets_balance_idx;
+DROP INDEX IF EXISTS wallets_id_prefix_idx;
+ALTER TABLE wallets DROP COLUMN IF EXISTS created_at;
+ALTER TABLE wallets DROP COLUMN IF EXISTS owner;
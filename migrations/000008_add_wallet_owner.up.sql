@@ -0,0 +1,8 @@
+ALTER TABLE wallets ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+ALTER TABLE wallets ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX wallets_id_prefix_idx ON wallets (id text_pattern_ops);
+CREATE INDEX wallets_balance_idx ON wallets (balance, id);
+CREATE INDEX wallets_created_idx ON wallets (created_at, id);
+CREATE INDEX wallets_owner_idx ON wallets (owner, id);
+CREATE INDEX wallets_status_idx ON wallets (status, id);
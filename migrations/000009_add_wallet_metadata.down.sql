@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS wallets_metadata_idx;
+ALTER TABLE wallets DROP COLUMN IF EXISTS metadata;
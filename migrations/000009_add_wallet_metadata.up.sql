@@ -0,0 +1,3 @@
+ALTER TABLE wallets ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}' CHECK (jsonb_typeof(metadata) = 'object');
+
+CREATE INDEX wallets_metadata_idx ON wallets USING GIN (metadata jsonb_path_ops);
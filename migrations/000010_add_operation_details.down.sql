@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS operations_reference_idx;
+DROP INDEX IF EXISTS operations_external_lookup_idx;
+DROP INDEX IF EXISTS operations_external_id_idx;
+ALTER TABLE operations DROP COLUMN IF EXISTS external_id;
+ALTER TABLE operations DROP COLUMN IF EXISTS description;
+ALTER TABLE operations DROP COLUMN IF EXISTS reference;
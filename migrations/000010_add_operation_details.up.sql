@@ -0,0 +1,7 @@
+ALTER TABLE operations ADD COLUMN reference TEXT;
+ALTER TABLE operations ADD COLUMN description TEXT;
+ALTER TABLE operations ADD COLUMN external_id TEXT;
+
+CREATE UNIQUE INDEX operations_external_id_idx ON operations (wallet_id, external_id) WHERE external_id IS NOT NULL;
+CREATE INDEX operations_external_lookup_idx ON operations (external_id) WHERE external_id IS NOT NULL;
+CREATE INDEX operations_reference_idx ON operations (reference) WHERE reference IS NOT NULL;
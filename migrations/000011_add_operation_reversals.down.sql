@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS operations_reversal_idx;
+DROP INDEX IF EXISTS operations_transfer_idx;
+ALTER TABLE operations DROP COLUMN IF EXISTS reversal_of;
+ALTER TABLE operations DROP COLUMN IF EXISTS transfer_id;
@@ -0,0 +1,5 @@
+ALTER TABLE operations ADD COLUMN transfer_id BIGINT REFERENCES operations (id);
+ALTER TABLE operations ADD COLUMN reversal_of BIGINT REFERENCES operations (id);
+
+CREATE INDEX operations_transfer_idx ON operations (transfer_id) WHERE transfer_id IS NOT NULL;
+CREATE INDEX operations_reversal_idx ON operations (reversal_of) WHERE reversal_of IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ledger_postings;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS ledger_accounts;
+DROP FUNCTION IF EXISTS ledger_forbid_changes();
+DROP FUNCTION IF EXISTS ledger_check_entries();
@@ -0,0 +1,109 @@
+CREATE TABLE ledger_accounts (
+    id        TEXT PRIMARY KEY CHECK (id != ''),
+    kind      TEXT NOT NULL CHECK (kind IN ('wallet', 'system')),
+    wallet_id TEXT UNIQUE REFERENCES wallets (id),
+    CHECK ((kind = 'wallet') = (wallet_id IS NOT NULL))
+);
+
+INSERT INTO ledger_accounts (id, kind) VALUES
+    ('system:cash_in', 'system'),
+    ('system:cash_out', 'system'),
+    ('system:fees', 'system'),
+    ('system:fx', 'system'),
+    ('system:opening', 'system');
+
+INSERT INTO ledger_accounts (id, kind, wallet_id) SELECT 'wallet:' || id, 'wallet', id FROM wallets;
+
+CREATE TABLE journal_entries (
+    id         BIGSERIAL PRIMARY KEY,
+    kind       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- amount > 0 - кредит счёта (деньги на счёт), amount < 0 - дебет
+CREATE TABLE ledger_postings (
+    id           BIGSERIAL PRIMARY KEY,
+    entry_id     BIGINT NOT NULL REFERENCES journal_entries (id),
+    account_id   TEXT NOT NULL REFERENCES ledger_accounts (id),
+    operation_id BIGINT REFERENCES operations (id),
+    amount       NUMERIC(20, 2) NOT NULL CHECK (amount != 0)
+);
+
+CREATE INDEX ledger_postings_entry_idx ON ledger_postings (entry_id);
+CREATE INDEX ledger_postings_account_idx ON ledger_postings (account_id, entry_id);
+CREATE INDEX ledger_postings_operation_idx ON ledger_postings (operation_id) WHERE operation_id IS NOT NULL;
+
+-- прошлые операции переносятся в журнал: id записи - id операции, для перевода - id списания
+INSERT INTO journal_entries (id, kind, created_at)
+SELECT id,
+       CASE WHEN transfer_id IS NOT NULL THEN 'transfer' WHEN amount > 0 THEN 'deposit' ELSE 'withdraw' END,
+       created_at
+  FROM operations
+ WHERE (transfer_id IS NULL OR transfer_id = id) AND amount::NUMERIC(20, 2) != 0;
+
+INSERT INTO ledger_postings (entry_id, account_id, operation_id, amount)
+SELECT COALESCE(transfer_id, id), 'wallet:' || wallet_id, id, amount::NUMERIC(20, 2)
+  FROM operations
+ WHERE amount::NUMERIC(20, 2) != 0
+ ORDER BY id;
+
+INSERT INTO ledger_postings (entry_id, account_id, amount)
+SELECT id, CASE WHEN amount > 0 THEN 'system:cash_in' ELSE 'system:cash_out' END, -amount::NUMERIC(20, 2)
+  FROM operations
+ WHERE transfer_id IS NULL AND amount::NUMERIC(20, 2) != 0
+ ORDER BY id;
+
+-- баланс, не объяснённый операциями (кошельки старше истории операций), становится начальным остатком
+CREATE TEMP TABLE opening_balances ON COMMIT DROP AS
+SELECT 'wallet:' || w.id AS account_id,
+       w.balance::NUMERIC(20, 2) - COALESCE(SUM(o.amount::NUMERIC(20, 2)), 0) AS amount
+  FROM wallets w LEFT JOIN operations o ON o.wallet_id = w.id
+ GROUP BY w.id, w.balance;
+
+DELETE FROM opening_balances WHERE amount = 0;
+
+INSERT INTO journal_entries (id, kind)
+SELECT COALESCE(MAX(id), 0) + 1, 'opening' FROM operations HAVING EXISTS (SELECT 1 FROM opening_balances);
+
+INSERT INTO ledger_postings (entry_id, account_id, amount)
+SELECT (SELECT MAX(id) FROM journal_entries WHERE kind = 'opening'), account_id, amount FROM opening_balances
+ UNION ALL
+SELECT (SELECT MAX(id) FROM journal_entries WHERE kind = 'opening'), 'system:opening', -SUM(amount) FROM opening_balances
+HAVING COUNT(*) > 0;
+
+SELECT setval('journal_entries_id_seq', COALESCE((SELECT MAX(id) FROM journal_entries), 0) + 1, false);
+
+-- сумма каждой записи журнала равна нулю; записи пишутся одним запросом и больше не меняются
+CREATE FUNCTION ledger_check_entries() RETURNS trigger AS $$
+DECLARE
+    unbalanced BIGINT;
+BEGIN
+    SELECT p.entry_id INTO unbalanced
+      FROM ledger_postings p
+     WHERE p.entry_id IN (SELECT entry_id FROM new_postings)
+     GROUP BY p.entry_id
+    HAVING SUM(p.amount) != 0
+     LIMIT 1;
+
+    IF unbalanced IS NOT NULL THEN
+        RAISE EXCEPTION 'journal entry % does not sum to zero', unbalanced USING ERRCODE = 'check_violation';
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION ledger_forbid_changes() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger postings can not be changed' USING ERRCODE = 'check_violation';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_postings_balanced
+    AFTER INSERT ON ledger_postings
+    REFERENCING NEW TABLE AS new_postings
+    FOR EACH STATEMENT EXECUTE FUNCTION ledger_check_entries();
+
+CREATE TRIGGER ledger_postings_immutable
+    BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_forbid_changes();
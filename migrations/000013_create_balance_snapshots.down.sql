@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS balance_snapshots;
+DROP INDEX IF EXISTS ledger_postings_account_created_idx;
+ALTER TABLE ledger_postings DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,19 @@
+-- время проводки копирует время записи журнала, чтобы баланс на момент читался по индексу счёта
+ALTER TABLE ledger_postings ADD COLUMN created_at TIMESTAMPTZ;
+
+ALTER TABLE ledger_postings DISABLE TRIGGER ledger_postings_immutable;
+UPDATE ledger_postings p SET created_at = e.created_at FROM journal_entries e WHERE e.id = p.entry_id;
+ALTER TABLE ledger_postings ENABLE TRIGGER ledger_postings_immutable;
+
+ALTER TABLE ledger_postings ALTER COLUMN created_at SET DEFAULT now();
+ALTER TABLE ledger_postings ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX ledger_postings_account_created_idx ON ledger_postings (account_id, created_at);
+
+-- balance - остаток счёта по всем проводкам с created_at <= taken_at
+CREATE TABLE balance_snapshots (
+    account_id TEXT NOT NULL REFERENCES ledger_accounts (id),
+    taken_at   TIMESTAMPTZ NOT NULL,
+    balance    NUMERIC(20, 2) NOT NULL,
+    PRIMARY KEY (account_id, taken_at)
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS ledger_corrections;
+DELETE FROM ledger_accounts a
+ WHERE a.id = 'system:corrections'
+   AND NOT EXISTS (SELECT 1 FROM ledger_postings p WHERE p.account_id = a.id);
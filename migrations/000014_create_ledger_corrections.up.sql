@@ -0,0 +1,15 @@
+INSERT INTO ledger_accounts (id, kind) VALUES ('system:corrections', 'system');
+
+-- журнал исправлений расхождений баланса кошелька с журналом проводок
+CREATE TABLE ledger_corrections (
+    id             BIGSERIAL PRIMARY KEY,
+    entry_id       BIGINT NOT NULL REFERENCES journal_entries (id),
+    wallet_id      TEXT NOT NULL REFERENCES wallets (id),
+    balance        NUMERIC(20, 2) NOT NULL,
+    ledger_balance NUMERIC(20, 2) NOT NULL,
+    actor          TEXT NOT NULL,
+    reason         TEXT NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ledger_corrections_wallet_idx ON ledger_corrections (wallet_id, created_at);
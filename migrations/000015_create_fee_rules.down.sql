@@ -0,0 +1,2 @@
+ALTER TABLE operations DROP COLUMN IF EXISTS fee;
+DROP TABLE IF EXISTS fee_rules;
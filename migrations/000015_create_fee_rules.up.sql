@@ -0,0 +1,15 @@
+-- tier NULL - правило для всех тарифов, правило тарифа важнее
+CREATE TABLE fee_rules (
+    tier           TEXT REFERENCES wallet_tiers (name) ON DELETE CASCADE,
+    operation_type TEXT NOT NULL CHECK (operation_type IN ('WITHDRAW', 'TRANSFER')),
+    flat           NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (flat >= 0),
+    percent        NUMERIC(7, 4) NOT NULL DEFAULT 0 CHECK (percent >= 0 AND percent <= 100),
+    min_fee        NUMERIC(20, 2) CHECK (min_fee >= 0),
+    max_fee        NUMERIC(20, 2) CHECK (max_fee >= 0),
+    CHECK (min_fee IS NULL OR max_fee IS NULL OR min_fee <= max_fee)
+);
+
+CREATE UNIQUE INDEX fee_rules_tier_type_idx ON fee_rules ((COALESCE(tier, '')), operation_type);
+
+-- комиссия удерживается из суммы списания (для перевода - из суммы, которую получит получатель)
+ALTER TABLE operations ADD COLUMN fee FLOAT NOT NULL DEFAULT 0 CHECK (fee >= 0);
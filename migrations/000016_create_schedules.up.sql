@@ -0,0 +1,37 @@
+-- запланированные операции: разовая (run_interval и cron пустые) или повторяющаяся.
+-- next_run_at NULL - запусков больше не будет
+CREATE TABLE schedules (
+    id             BIGSERIAL PRIMARY KEY,
+    wallet_id      TEXT NOT NULL REFERENCES wallets (id),
+    operation_type TEXT NOT NULL CHECK (operation_type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER')),
+    amount         FLOAT NOT NULL CHECK (amount > 0),
+    to_wallet_id   TEXT REFERENCES wallets (id),
+    reference      TEXT,
+    description    TEXT,
+    run_at         TIMESTAMPTZ NOT NULL,
+    run_interval   TEXT,
+    cron           TEXT,
+    ends_at        TIMESTAMPTZ,
+    status         TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'completed', 'cancelled')),
+    next_run_at    TIMESTAMPTZ,
+    runs           INTEGER NOT NULL DEFAULT 0,
+    actor          TEXT NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((operation_type = 'TRANSFER') = (to_wallet_id IS NOT NULL)),
+    CHECK (run_interval IS NULL OR cron IS NULL)
+);
+
+CREATE INDEX schedules_due_idx ON schedules (next_run_at) WHERE status = 'active';
+CREATE INDEX schedules_wallet_idx ON schedules (wallet_id, id);
+
+-- история запусков; один запуск на момент, чтобы операция не прошла дважды
+CREATE TABLE schedule_runs (
+    id            BIGSERIAL PRIMARY KEY,
+    schedule_id   BIGINT NOT NULL REFERENCES schedules (id),
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    status        TEXT NOT NULL CHECK (status IN ('succeeded', 'failed')),
+    operation_id  BIGINT REFERENCES operations (id),
+    error         TEXT,
+    ran_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (schedule_id, scheduled_for)
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS interest_accruals;
+DROP TABLE IF EXISTS interest_rules;
+DELETE FROM ledger_accounts a
+ WHERE a.id = 'system:interest'
+   AND NOT EXISTS (SELECT 1 FROM ledger_postings p WHERE p.account_id = a.id);
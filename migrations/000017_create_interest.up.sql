@@ -0,0 +1,26 @@
+INSERT INTO ledger_accounts (id, kind) VALUES ('system:interest', 'system');
+
+-- годовая ставка тарифа в процентах и способ подсчёта дней
+CREATE TABLE interest_rules (
+    tier      TEXT PRIMARY KEY REFERENCES wallet_tiers (name) ON DELETE CASCADE,
+    rate      NUMERIC(9, 6) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    day_count TEXT NOT NULL CHECK (day_count IN ('ACT/365', 'ACT/360', 'ACT/ACT', '30E/360'))
+);
+
+-- начисления процентов за день: одно на кошелёк и день, поэтому повторный запуск не начислит их дважды.
+-- interest - точная сумма за день, amount - зачисленная (до копеек), carry - остаток, который переходит на следующий день
+CREATE TABLE interest_accruals (
+    wallet_id    TEXT NOT NULL REFERENCES wallets (id),
+    period       DATE NOT NULL,
+    rate         NUMERIC(9, 6) NOT NULL,
+    day_count    TEXT NOT NULL,
+    balance      NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    interest     NUMERIC(30, 10) NOT NULL DEFAULT 0,
+    amount       NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    carry        NUMERIC(30, 10) NOT NULL DEFAULT 0,
+    operation_id BIGINT REFERENCES operations (id),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (wallet_id, period)
+);
+
+CREATE INDEX interest_accruals_period_idx ON interest_accruals (period);
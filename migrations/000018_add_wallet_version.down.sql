@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS wallets_version ON wallets;
+DROP FUNCTION IF EXISTS wallets_bump_version();
+ALTER TABLE wallets DROP COLUMN IF EXISTS version;
@@ -0,0 +1,14 @@
+ALTER TABLE wallets ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- версия растёт при любом изменении строки кошелька, в том числе в обход сервиса
+CREATE FUNCTION wallets_bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER wallets_version
+    BEFORE UPDATE ON wallets
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*)
+    EXECUTE FUNCTION wallets_bump_version();
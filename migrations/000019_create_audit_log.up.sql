@@ -0,0 +1,37 @@
+-- id идут подряд без пропусков, hash = sha256(запись + prev_hash): изменение или удаление записи рвёт цепочку
+CREATE TABLE audit_log (
+    id          BIGINT PRIMARY KEY,
+    created_at  TIMESTAMPTZ NOT NULL,
+    actor       TEXT NOT NULL DEFAULT '',
+    subject     TEXT NOT NULL DEFAULT '',
+    source_ip   TEXT NOT NULL DEFAULT '',
+    request_id  TEXT NOT NULL,
+    method      TEXT NOT NULL,
+    path        TEXT NOT NULL,
+    target      TEXT NOT NULL DEFAULT '',
+    status      INT NOT NULL,
+    reason      TEXT NOT NULL DEFAULT '',
+    request     JSONB,
+    before      JSONB,
+    after       JSONB,
+    prev_hash   TEXT NOT NULL,
+    hash        TEXT NOT NULL UNIQUE
+);
+
+CREATE INDEX audit_log_actor_idx ON audit_log (actor, id);
+CREATE INDEX audit_log_target_idx ON audit_log (target, id);
+CREATE INDEX audit_log_request_id_idx ON audit_log (request_id);
+
+CREATE FUNCTION audit_log_forbid_changes() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit log is append-only' USING ERRCODE = 'check_violation';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_immutable
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_forbid_changes();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_forbid_changes();
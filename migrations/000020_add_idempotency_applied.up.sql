@@ -0,0 +1,2 @@
+-- отметка ставится в транзакции операции: ключ проведённой операции не освобождается при ошибке ответа
+ALTER TABLE idempotency_keys ADD COLUMN applied_at TIMESTAMPTZ;
@@ -0,0 +1,5 @@
+-- запуск, прерванный сбоем базы, повторяется в retry_at; next_run_at остаётся моментом запуска
+ALTER TABLE schedules
+    ADD COLUMN attempts   INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT,
+    ADD COLUMN retry_at   TIMESTAMPTZ;
@@ -0,0 +1,109 @@
+// Package migrations содержит миграции схемы базы, встроенные в бинарник,
+// и команду migrate для их применения.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+var ErrUsage = errors.New("usage: migrate up | down [-steps N] | status")
+
+// Migrator - база, к которой применяются миграции
+type Migrator interface {
+	SchemaVersion() (int64, bool, error)
+	MigrateUp(migrations []datastorage.Migration) ([]datastorage.Migration, error)
+	MigrateDown(migrations []datastorage.Migration, steps int) ([]datastorage.Migration, error)
+}
+
+// Load возвращает встроенные миграции по возрастанию версии
+func Load() ([]datastorage.Migration, error) {
+	return datastorage.LoadMigrations(FS)
+}
+
+// Run выполняет команду migrate up, down или status
+func Run(db Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	migrations, err := Load()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+
+		applied, err := db.MigrateUp(migrations)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+		return err
+
+	case "down":
+		flags := flag.NewFlagSet("down", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		steps := flags.Int("steps", 1, "number of migrations to revert")
+
+		if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 0 || *steps <= 0 {
+			return ErrUsage
+		}
+
+		reverted, err := db.MigrateDown(migrations, *steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(out, "reverted %d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+
+	case "status":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+
+		version, dirty, err := db.SchemaVersion()
+		if err != nil {
+			return err
+		}
+
+		return printStatus(out, migrations, version, dirty)
+	}
+
+	return ErrUsage
+}
+
+func printStatus(out io.Writer, migrations []datastorage.Migration, version int64, dirty bool) error {
+	state := ""
+	if dirty {
+		state = " (dirty)"
+	}
+	fmt.Fprintf(out, "schema version: %d%s\n", version, state)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATE")
+
+	for _, migration := range migrations {
+		state := "pending"
+		if migration.Version <= version {
+			state = "applied"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", migration.Version, migration.Name, state)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMigrator хранит версию схемы в памяти
+type fakeMigrator struct {
+	version int64
+}
+
+func (f *fakeMigrator) SchemaVersion() (int64, bool, error) {
+	return f.version, false, nil
+}
+
+func (f *fakeMigrator) MigrateUp(migrations []datastorage.Migration) ([]datastorage.Migration, error) {
+	var applied []datastorage.Migration
+	for _, migration := range migrations {
+		if migration.Version > f.version {
+			applied = append(applied, migration)
+			f.version = migration.Version
+		}
+	}
+	return applied, nil
+}
+
+func (f *fakeMigrator) MigrateDown(migrations []datastorage.Migration, steps int) ([]datastorage.Migration, error) {
+	var reverted []datastorage.Migration
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		if migrations[i].Version <= f.version {
+			reverted = append(reverted, migrations[i])
+			f.version = 0
+			if i > 0 {
+				f.version = migrations[i-1].Version
+			}
+		}
+	}
+	return reverted, nil
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Load()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "migration versions must go without gaps")
+		assert.NotEmpty(t, migration.Down, "migration %d has no down file", migration.Version)
+	}
+}
+
+func TestRun(t *testing.T) {
+	migrations, err := Load()
+	require.NoError(t, err)
+	latest := migrations[len(migrations)-1]
+
+	db := &fakeMigrator{}
+	var out bytes.Buffer
+
+	require.NoError(t, Run(db, []string{"up"}, &out))
+	assert.Equal(t, latest.Version, db.version)
+	assert.Contains(t, out.String(), "applied 1_create_wallets_table\n")
+
+	out.Reset()
+	require.NoError(t, Run(db, []string{"up"}, &out))
+	assert.Equal(t, "schema is up to date\n", out.String())
+
+	out.Reset()
+	require.NoError(t, Run(db, []string{"down", "-steps", "2"}, &out))
+	assert.Equal(t, latest.Version-2, db.version)
+	assert.Equal(t, 2, bytes.Count(out.Bytes(), []byte("reverted ")))
+
+	out.Reset()
+	require.NoError(t, Run(db, []string{"status"}, &out))
+	assert.Contains(t, out.String(), fmt.Sprintf("schema version: %d\n", latest.Version-2))
+	assert.Contains(t, out.String(), "pending")
+}
+
+func TestWrongRun(t *testing.T) {
+	for _, args := range [][]string{nil, {"sideways"}, {"down", "-steps", "0"}, {"up", "extra"}} {
+		assert.ErrorIs(t, Run(&fakeMigrator{}, args, &bytes.Buffer{}), ErrUsage, args)
+	}
+}
@@ -0,0 +1,84 @@
+// Package outbox публикует события кошельков из таблицы outbox во внешний приёмник.
+// Доставка "хотя бы один раз": событие помечается опубликованным только после
+// успешной отправки, поэтому приёмник должен быть готов к повторам (по полю id).
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+type Store interface {
+	RelayEvents(limit int, publish func(event datastorage.Event) error, retryDelay func(attempts int) time.Duration) (int, error)
+}
+
+type Sink interface {
+	Publish(ctx context.Context, event datastorage.Event) error
+}
+
+type Relay struct {
+	Store      Store
+	Sink       Sink
+	Interval   time.Duration
+	BatchSize  int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func NewRelay(store Store, sink Sink) Relay {
+	return Relay{
+		Store:      store,
+		Sink:       sink,
+		Interval:   time.Second,
+		BatchSize:  100,
+		MinBackoff: time.Second,
+		MaxBackoff: 5 * time.Minute,
+	}
+}
+
+// Backoff возвращает задержку перед попыткой номер attempts+1: MinBackoff, удваиваясь до MaxBackoff
+func (relay Relay) Backoff(attempts int) time.Duration {
+	delay := relay.MinBackoff
+	for i := 1; i < attempts && delay < relay.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, relay.MaxBackoff)
+}
+
+// RunOnce публикует одну пачку событий и возвращает число опубликованных
+func (relay Relay) RunOnce(ctx context.Context) (int, error) {
+	return relay.Store.RelayEvents(relay.BatchSize, func(event datastorage.Event) error {
+		err := relay.Sink.Publish(ctx, event)
+		if err != nil {
+			log.Println("publish event", event.Id, "failed:", err)
+		}
+		return err
+	}, relay.Backoff)
+}
+
+// Run публикует события, пока не отменён ctx. Полная пачка забирается
+// следующей сразу, иначе relay ждёт Interval.
+func (relay Relay) Run(ctx context.Context) {
+	log.Println("outbox relay started")
+
+	for {
+		published, err := relay.RunOnce(ctx)
+		if err != nil {
+			log.Println("outbox relay error:", err)
+		}
+
+		if err == nil && published == relay.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("outbox relay stopped")
+			return
+		case <-time.After(relay.Interval):
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStore ведёт себя как RelayEvents: публикует по порядку и пропускает
+// события кошелька после первой неудачи
+type fakeStore struct {
+	events  []datastorage.Event
+	delays  []time.Duration
+	pending map[int64]int
+}
+
+func (store *fakeStore) RelayEvents(limit int, publish func(event datastorage.Event) error, retryDelay func(attempts int) time.Duration) (int, error) {
+	published := 0
+	blocked := map[string]bool{}
+	var rest []datastorage.Event
+
+	for _, event := range store.events {
+		if blocked[event.WalletId] || published >= limit {
+			rest = append(rest, event)
+			continue
+		}
+
+		if err := publish(event); err != nil {
+			blocked[event.WalletId] = true
+			store.pending[event.Id]++
+			store.delays = append(store.delays, retryDelay(store.pending[event.Id]))
+			rest = append(rest, event)
+			continue
+		}
+
+		published++
+	}
+
+	store.events = rest
+	return published, nil
+}
+
+type recordingSink struct {
+	published []int64
+	fail      map[int64]int
+}
+
+func (sink *recordingSink) Publish(_ context.Context, event datastorage.Event) error {
+	if sink.fail[event.Id] > 0 {
+		sink.fail[event.Id]--
+		return errors.New("sink is down")
+	}
+	sink.published = append(sink.published, event.Id)
+	return nil
+}
+
+func TestBackoff(t *testing.T) {
+	relay := Relay{MinBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	assert.Equal(t, time.Second, relay.Backoff(1))
+	assert.Equal(t, 2*time.Second, relay.Backoff(2))
+	assert.Equal(t, 8*time.Second, relay.Backoff(4))
+	assert.Equal(t, 10*time.Second, relay.Backoff(5))
+	assert.Equal(t, 10*time.Second, relay.Backoff(50))
+}
+
+func TestRelayKeepsOrderPerWallet(t *testing.T) {
+	store := &fakeStore{
+		events: []datastorage.Event{
+			{Id: 1, WalletId: "a"},
+			{Id: 2, WalletId: "b"},
+			{Id: 3, WalletId: "a"},
+			{Id: 4, WalletId: "b"},
+		},
+		pending: map[int64]int{},
+	}
+	sink := &recordingSink{fail: map[int64]int{1: 2}}
+
+	relay := NewRelay(store, sink)
+
+	for range 3 {
+		_, err := relay.RunOnce(context.Background())
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []int64{2, 4, 1, 3}, sink.published)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, store.delays)
+	assert.Empty(t, store.events)
+}
+
+func TestWriterSink(t *testing.T) {
+	var out bytes.Buffer
+	sink := NewWriterSink(&out)
+
+	event := datastorage.Event{Id: 7, WalletId: "a", Type: datastorage.EventDeposited, Amount: 10, Balance: 15,
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	assert.NoError(t, sink.Publish(context.Background(), event))
+	assert.JSONEq(t,
+		`{"id":7,"walletId":"a","type":"wallet.deposited","amount":10,"balance":15,"createdAt":"2026-01-02T03:04:05Z"}`,
+		out.String())
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	sink, err := NewSink(SinkFile, path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, sink.Publish(context.Background(), datastorage.Event{Id: 1, WalletId: "a"}))
+	assert.NoError(t, sink.Publish(context.Background(), datastorage.Event{Id: 2, WalletId: "a"}))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	var ids []int64
+	for {
+		var event datastorage.Event
+		if decoder.Decode(&event) == io.EOF {
+			break
+		}
+		ids = append(ids, event.Id)
+	}
+	assert.Equal(t, []int64{1, 2}, ids)
+}
+
+func TestHTTPSink(t *testing.T) {
+	var received []datastorage.Event
+	var keys []string
+	status := http.StatusServiceUnavailable
+
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event datastorage.Event
+		json.NewDecoder(r.Body).Decode(&event)
+		received = append(received, event)
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(status)
+	}))
+	defer stub.Close()
+
+	sink, err := NewSink(SinkHTTP, stub.URL)
+	assert.NoError(t, err)
+
+	event := datastorage.Event{Id: 42, WalletId: "a", Type: datastorage.EventWithdrawn, Amount: -5}
+
+	assert.EqualError(t, sink.Publish(context.Background(), event), "sink responded with status 503")
+
+	status = http.StatusOK
+	assert.NoError(t, sink.Publish(context.Background(), event))
+
+	assert.Len(t, received, 2)
+	assert.Equal(t, event, received[1])
+	assert.Equal(t, []string{"42", "42"}, keys)
+}
+
+func TestUnknownSink(t *testing.T) {
+	_, err := NewSink("kafka", "")
+	assert.EqualError(t, err, "unknown sink: kafka")
+}
@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+const (
+	SinkStdout = "stdout"
+	SinkFile   = "file"
+	SinkHTTP   = "http"
+)
+
+// NewSink создаёт приёмник по имени: stdout, file (target - путь к файлу) или http (target - URL)
+func NewSink(kind, target string) (Sink, error) {
+	switch kind {
+	case SinkStdout:
+		return NewWriterSink(os.Stdout), nil
+	case SinkFile:
+		if target == "" {
+			return nil, errors.New("file sink needs a path")
+		}
+		return FileSink{Path: target}, nil
+	case SinkHTTP:
+		if target == "" {
+			return nil, errors.New("http sink needs a URL")
+		}
+		return HTTPSink{URL: target, Client: &http.Client{Timeout: 5 * time.Second}}, nil
+	}
+
+	return nil, errors.New("unknown sink: " + kind)
+}
+
+// WriterSink пишет события построчно в JSON
+type WriterSink struct {
+	mu      *sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewWriterSink(w io.Writer) WriterSink {
+	return WriterSink{mu: &sync.Mutex{}, encoder: json.NewEncoder(w)}
+}
+
+func (sink WriterSink) Publish(_ context.Context, event datastorage.Event) error {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	return sink.encoder.Encode(event)
+}
+
+// FileSink дописывает события в файл, по строке JSON на событие
+type FileSink struct {
+	Path string
+}
+
+func (sink FileSink) Publish(_ context.Context, event datastorage.Event) error {
+	f, err := os.OpenFile(sink.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = json.NewEncoder(f).Encode(event)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// HTTPSink отправляет событие POST запросом в JSON. Ответ не 2xx считается ошибкой.
+// Заголовок Idempotency-Key содержит id события, чтобы приёмник мог отбросить повтор.
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (sink HTTPSink) Publish(ctx context.Context, event datastorage.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", strconv.FormatInt(event.Id, 10))
+
+	resp, err := sink.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sink responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
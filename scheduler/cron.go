@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron - расписание в формате crontab из 5 полей: минута, час, день месяца, месяц, день недели
+// (0 и 7 - воскресенье). Поле - это *, число, диапазон a-b или их список через запятую,
+// с шагом /n. Время считается в UTC.
+type Cron struct {
+	minute, hour, day, month, weekday uint64
+	// если день месяца и день недели заданы оба, подходит любой из них, как в cron
+	anyDay, anyWeekday bool
+}
+
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+func ParseCron(spec string) (Cron, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return Cron{}, fmt.Errorf("cron must have 5 fields: minute hour day month weekday")
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return Cron{}, fmt.Errorf("wrong cron %s %q: %w", cronFields[i].name, field, err)
+		}
+		sets[i] = set
+	}
+
+	// 7 - то же воскресенье, что и 0
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return Cron{
+		minute:     sets[0],
+		hour:       sets[1],
+		day:        sets[2],
+		month:      sets[3],
+		weekday:    sets[4],
+		anyDay:     strings.HasPrefix(fields[2], "*"),
+		anyWeekday: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(field, ",") {
+		values, stepText, stepped := strings.Cut(part, "/")
+
+		step := 1
+		if stepped {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+				return 0, fmt.Errorf("step must be a positive number")
+			}
+		}
+
+		low, high := min, max
+		if values != "*" {
+			from, to, isRange := strings.Cut(values, "-")
+
+			var err error
+			if low, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("%q is not a number", from)
+			}
+
+			// a/n - от a до конца поля с шагом n
+			high = max
+			if isRange {
+				if high, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("%q is not a number", to)
+				}
+			} else if !stepped {
+				high = low
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("values must be from %d to %d", min, max)
+		}
+
+		for value := low; value <= high; value += step {
+			set |= 1 << value
+		}
+	}
+
+	return set, nil
+}
+
+func (cron Cron) matchDay(t time.Time) bool {
+	day := cron.day&(1<<t.Day()) != 0
+	weekday := cron.weekday&(1<<int(t.Weekday())) != 0
+
+	if cron.anyDay || cron.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// Next возвращает первый подходящий момент позже after или false,
+// если за пять лет такого нет (например, 30 февраля)
+func (cron Cron) Next(after time.Time) (time.Time, bool) {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case cron.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !cron.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case cron.hour&(1<<t.Hour()) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case cron.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		spec  string
+		after time.Time
+		next  time.Time
+	}{
+		{"* * * * *", date(2026, 10, 1, 10, 0).Add(30 * time.Second), date(2026, 10, 1, 10, 1)},
+		{"0 9 * * *", date(2026, 10, 1, 9, 0), date(2026, 10, 2, 9, 0)},
+		{"*/15 * * * *", date(2026, 10, 1, 10, 16), date(2026, 10, 1, 10, 30)},
+		{"30 8 1 * *", date(2026, 10, 15, 0, 0), date(2026, 11, 1, 8, 30)},
+		{"0 0 1 1 *", date(2026, 10, 1, 0, 0), date(2027, 1, 1, 0, 0)},
+		// 2026-10-01 - четверг
+		{"0 12 * * 1-5", date(2026, 10, 2, 13, 0), date(2026, 10, 5, 12, 0)},
+		{"0 12 * * 7", date(2026, 10, 1, 0, 0), date(2026, 10, 4, 12, 0)},
+		// день месяца и день недели заданы оба - подходит любой
+		{"0 0 15 * 0", date(2026, 10, 1, 0, 0), date(2026, 10, 4, 0, 0)},
+		{"0 0 29 2 *", date(2026, 3, 1, 0, 0), date(2028, 2, 29, 0, 0)},
+		{"5,10-12/2 * * * *", date(2026, 10, 1, 10, 5), date(2026, 10, 1, 10, 10)},
+	}
+
+	for _, test := range tests {
+		cron, err := ParseCron(test.spec)
+		require.NoError(t, err, test.spec)
+
+		next, ok := cron.Next(test.after)
+		assert.True(t, ok, test.spec)
+		assert.Equal(t, test.next, next, test.spec)
+	}
+
+	cron, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+
+	_, ok := cron.Next(date(2026, 1, 1, 0, 0))
+	assert.False(t, ok)
+}
+
+func TestWrongCron(t *testing.T) {
+	tests := map[string]string{
+		"* * * *":     "cron must have 5 fields: minute hour day month weekday",
+		"60 * * * *":  `wrong cron minute "60": values must be from 0 to 59`,
+		"* 5-1 * * *": `wrong cron hour "5-1": values must be from 0 to 23`,
+		"* * 0 * *":   `wrong cron day of month "0": values must be from 1 to 31`,
+		"* * * jan *": `wrong cron month "jan": "jan" is not a number`,
+		"*/0 * * * *": `wrong cron minute "*/0": step must be a positive number`,
+		"* * * * 1,8": `wrong cron day of week "1,8": values must be from 0 to 7`,
+	}
+
+	for spec, message := range tests {
+		_, err := ParseCron(spec)
+		assert.EqualError(t, err, message, spec)
+	}
+}
@@ -0,0 +1,164 @@
+// Package scheduler выполняет запланированные операции кошельков.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+// MinInterval - наименьший интервал повторяющейся операции
+const MinInterval = time.Minute
+
+type Store interface {
+	RunDueSchedules(limit int, next func(schedule datastorage.Schedule) (time.Time, bool)) (int, error)
+}
+
+// FirstRun проверяет расписание операции и возвращает момент первого запуска:
+// RunAt, если он задан (для Cron - первый подходящий момент не раньше RunAt), иначе для Cron -
+// ближайший подходящий момент после now, а для Interval - сразу now. Разовой операции RunAt обязателен.
+func FirstRun(schedule datastorage.Schedule, now time.Time) (time.Time, error) {
+	first := schedule.RunAt
+
+	switch {
+	case schedule.Interval != "" && schedule.Cron != "":
+		return time.Time{}, errors.New("interval and cron are mutually exclusive")
+
+	case schedule.Interval != "":
+		every, err := time.ParseDuration(schedule.Interval)
+		if err != nil {
+			return time.Time{}, errors.New("interval must be a duration like 30m or 24h")
+		}
+
+		if every < MinInterval {
+			return time.Time{}, errors.New("interval must be at least " + MinInterval.String())
+		}
+
+		if first.IsZero() {
+			first = now
+		}
+
+	case schedule.Cron != "":
+		cron, err := ParseCron(schedule.Cron)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		from := now
+		if !first.IsZero() {
+			from = first.Add(-time.Nanosecond)
+		}
+
+		var ok bool
+		if first, ok = cron.Next(from); !ok {
+			return time.Time{}, errors.New("cron never matches")
+		}
+
+	case first.IsZero():
+		return time.Time{}, errors.New("runAt is required for one-off operation")
+	}
+
+	if schedule.EndsAt != nil && first.After(*schedule.EndsAt) {
+		return time.Time{}, errors.New("endsAt must not be before the first run")
+	}
+
+	return first, nil
+}
+
+// Next возвращает первый запуск повторяющейся операции позже after или false, если операция
+// разовая или этот запуск позже EndsAt. Интервалы отсчитываются от RunAt; пропущенные запуски
+// (например, пока сервер был остановлен) не навёрстываются.
+func Next(schedule datastorage.Schedule, after time.Time) (time.Time, bool) {
+	var next time.Time
+
+	switch {
+	case schedule.Interval != "":
+		every, err := time.ParseDuration(schedule.Interval)
+		if err != nil || every <= 0 {
+			return time.Time{}, false
+		}
+
+		next = schedule.RunAt
+		if !next.After(after) {
+			next = next.Add((after.Sub(next)/every + 1) * every)
+		}
+
+	case schedule.Cron != "":
+		cron, err := ParseCron(schedule.Cron)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		var ok bool
+		if next, ok = cron.Next(after); !ok {
+			return time.Time{}, false
+		}
+
+	default:
+		return time.Time{}, false
+	}
+
+	if schedule.EndsAt != nil && next.After(*schedule.EndsAt) {
+		return time.Time{}, false
+	}
+
+	return next, true
+}
+
+type Scheduler struct {
+	Store     Store
+	Interval  time.Duration
+	BatchSize int
+	Now       func() time.Time
+}
+
+func NewScheduler(store Store) Scheduler {
+	return Scheduler{
+		Store:     store,
+		Interval:  5 * time.Second,
+		BatchSize: 50,
+		Now:       time.Now,
+	}
+}
+
+// next - следующий запуск после выполненного: позже и его момента, и текущего времени
+func (scheduler Scheduler) next(schedule datastorage.Schedule) (time.Time, bool) {
+	after := scheduler.Now()
+	if schedule.NextRunAt != nil && schedule.NextRunAt.After(after) {
+		after = *schedule.NextRunAt
+	}
+	return Next(schedule, after)
+}
+
+// RunOnce выполняет операции, время которых пришло, и возвращает их число
+func (scheduler Scheduler) RunOnce() (int, error) {
+	return scheduler.Store.RunDueSchedules(scheduler.BatchSize, scheduler.next)
+}
+
+// Run выполняет запланированные операции, пока не отменён ctx
+func (scheduler Scheduler) Run(ctx context.Context) {
+	log.Println("scheduler started")
+
+	for {
+		ran, err := scheduler.RunOnce()
+		if err != nil {
+			log.Println("scheduler error:", err)
+		} else if ran > 0 {
+			log.Println("scheduled operations run:", ran)
+		}
+
+		if err == nil && ran == scheduler.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("scheduler stopped")
+			return
+		case <-time.After(scheduler.Interval):
+		}
+	}
+}
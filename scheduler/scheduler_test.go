@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	due  []datastorage.Schedule
+	next []*time.Time
+}
+
+func (store *fakeStore) RunDueSchedules(limit int, next func(schedule datastorage.Schedule) (time.Time, bool)) (int, error) {
+	ran := 0
+	for _, schedule := range store.due {
+		if ran == limit {
+			break
+		}
+
+		var at *time.Time
+		if t, ok := next(schedule); ok {
+			at = &t
+		}
+		store.next = append(store.next, at)
+		ran++
+	}
+	return ran, nil
+}
+
+func TestFirstRun(t *testing.T) {
+	now := date(2026, 10, 1, 10, 20)
+	runAt := date(2026, 10, 5, 0, 0)
+	endsAt := date(2026, 10, 3, 0, 0)
+
+	tests := []struct {
+		schedule datastorage.Schedule
+		first    time.Time
+		err      string
+	}{
+		{datastorage.Schedule{RunAt: runAt}, runAt, ""},
+		{datastorage.Schedule{}, time.Time{}, "runAt is required for one-off operation"},
+		{datastorage.Schedule{Interval: "24h"}, now, ""},
+		{datastorage.Schedule{Interval: "24h", RunAt: runAt}, runAt, ""},
+		{datastorage.Schedule{Interval: "30s"}, time.Time{}, "interval must be at least 1m0s"},
+		{datastorage.Schedule{Interval: "month"}, time.Time{}, "interval must be a duration like 30m or 24h"},
+		{datastorage.Schedule{Cron: "0 9 * * *"}, date(2026, 10, 2, 9, 0), ""},
+		{datastorage.Schedule{Cron: "0 0 * * *", RunAt: runAt}, runAt, ""},
+		{datastorage.Schedule{Cron: "0 9 * * *", Interval: "1h"}, time.Time{}, "interval and cron are mutually exclusive"},
+		{datastorage.Schedule{Cron: "0 0 31 2 *"}, time.Time{}, "cron never matches"},
+		{datastorage.Schedule{RunAt: runAt, EndsAt: &endsAt}, time.Time{}, "endsAt must not be before the first run"},
+	}
+
+	for _, test := range tests {
+		first, err := FirstRun(test.schedule, now)
+
+		if test.err != "" {
+			assert.EqualError(t, err, test.err)
+			continue
+		}
+
+		require.NoError(t, err)
+		assert.Equal(t, test.first, first)
+	}
+}
+
+func TestNext(t *testing.T) {
+	runAt := date(2026, 10, 1, 10, 0)
+	endsAt := date(2026, 10, 1, 13, 0)
+
+	schedule := datastorage.Schedule{RunAt: runAt, Interval: "1h", EndsAt: &endsAt}
+
+	next, ok := Next(schedule, runAt)
+	assert.True(t, ok)
+	assert.Equal(t, date(2026, 10, 1, 11, 0), next)
+
+	// пропущенные запуски не навёрстываются
+	next, ok = Next(schedule, date(2026, 10, 1, 12, 30))
+	assert.True(t, ok)
+	assert.Equal(t, date(2026, 10, 1, 13, 0), next)
+
+	_, ok = Next(schedule, endsAt)
+	assert.False(t, ok)
+
+	next, ok = Next(datastorage.Schedule{RunAt: runAt, Cron: "0 9 1 * *"}, runAt)
+	assert.True(t, ok)
+	assert.Equal(t, date(2026, 11, 1, 9, 0), next)
+
+	_, ok = Next(datastorage.Schedule{RunAt: runAt}, runAt)
+	assert.False(t, ok)
+}
+
+func TestRunOnce(t *testing.T) {
+	now := date(2026, 10, 1, 10, 0).Add(3 * time.Second)
+	late := date(2026, 10, 1, 10, 0)
+	early := date(2026, 10, 1, 11, 0)
+
+	store := &fakeStore{due: []datastorage.Schedule{
+		{Id: 1, RunAt: late, NextRunAt: &late, Interval: "1h"},
+		{Id: 2, RunAt: late, NextRunAt: &late},
+		{Id: 3, RunAt: early, NextRunAt: &early, Interval: "1h"},
+	}}
+
+	scheduler := NewScheduler(store)
+	scheduler.BatchSize = 2
+	scheduler.Now = func() time.Time { return now }
+
+	ran, err := scheduler.RunOnce()
+	require.NoError(t, err)
+	assert.Equal(t, 2, ran)
+
+	require.Len(t, store.next, 2)
+	assert.Equal(t, date(2026, 10, 1, 11, 0), *store.next[0])
+	assert.Nil(t, store.next[1])
+
+	store.due, store.next = store.due[2:], nil
+
+	_, err = scheduler.RunOnce()
+	require.NoError(t, err)
+	assert.Equal(t, date(2026, 10, 1, 12, 0), *store.next[0])
+}
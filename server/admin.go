@@ -0,0 +1,36 @@
+package server
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+)
+
+// withAdminAuth пропускает запрос только с заголовком Authorization: Bearer {ADMIN_TOKEN}.
+// Если токен не задан, административные запросы запрещены.
+func withAdminAuth(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			log.Println("admin request while admin token is not configured:", r.URL.Path)
+			http.Error(w, "admin API is disabled", http.StatusForbidden)
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			log.Println("unauthorized admin request:", r.URL.Path)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// adminActor возвращает имя администратора из заголовка X-Actor
+func adminActor(r *http.Request) string {
+	actor := r.Header.Get("X-Actor")
+	if actor == "" {
+		return "admin"
+	}
+	return actor
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+func TestAdminAuth(t *testing.T) {
+	cases := []struct {
+		name   string
+		token  string
+		header string
+		status int
+	}{
+		{"disabled", "", "Bearer ", http.StatusForbidden},
+		{"no header", "secret", "", http.StatusUnauthorized},
+		{"wrong token", "secret", "Bearer wrong", http.StatusUnauthorized},
+		{"good token", "secret", "Bearer secret", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			handler := withAdminAuth(c.token, okHandler)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/tiers/gold", nil)
+			if c.header != "" {
+				req.Header.Set("Authorization", c.header)
+			}
+
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, c.status, rec.Result().StatusCode)
+		})
+	}
+}
@@ -0,0 +1,461 @@
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+const (
+	requestIdHeader  = "X-Request-Id"
+	reasonHeader     = "X-Reason"
+	maxRequestId     = 128
+	maxAuditedBody   = 64 << 10
+	defaultAuditPage = 100
+	maxAuditPage     = 1000
+)
+
+type AuditStorage interface {
+	AppendAudit(entry datastorage.AuditEntry) (datastorage.AuditEntry, error)
+	ListAudit(filter datastorage.AuditFilter) ([]datastorage.AuditEntry, error)
+	VerifyAudit() (datastorage.AuditVerification, error)
+	LockAuditTarget(target string) (func(), error)
+}
+
+// secretFields не попадают в журнал аудита: их значения заменяются на ***
+var secretFields = map[string]bool{"secret": true, "token": true, "password": true}
+
+// requestId возвращает переданный вызывающим id запроса или новый, если он пустой или слишком длинный
+func requestId(given string) string {
+	if id := strings.TrimSpace(given); id != "" && len(id) <= maxRequestId {
+		return id
+	}
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// tokenSubject - кем подписан запрос: отпечаток токена из заголовка Authorization, сам токен не сохраняется.
+// admin: - токен администратора, unknown: - любой другой.
+func tokenSubject(authorization, adminToken string) string {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || token == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	fingerprint := hex.EncodeToString(sum[:6])
+
+	if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+		return "admin:" + fingerprint
+	}
+	return "unknown:" + fingerprint
+}
+
+// auditActor - имя из X-Actor, если запрос подписан токеном администратора.
+// Неподтверждённый вызывающий может назваться кем угодно, поэтому для него записывается только Subject.
+func auditActor(subject, actor string) string {
+	if !strings.HasPrefix(subject, "admin:") {
+		return ""
+	}
+	if actor == "" {
+		return "admin"
+	}
+	return actor
+}
+
+// targetLocks выполняет изменяющие запросы к одному объекту в этом процессе по очереди,
+// чтобы за блокировкой в базе (см. lockTarget) ждал не больше чем один запрос к объекту
+type targetLocks struct {
+	mu    sync.Mutex
+	locks map[string]*targetLock
+}
+
+type targetLock struct {
+	sync.Mutex
+	holders int
+}
+
+var auditLocks = targetLocks{locks: map[string]*targetLock{}}
+
+// lock блокирует объект target и возвращает функцию, снимающую блокировку
+func (l *targetLocks) lock(target string) func() {
+	l.mu.Lock()
+	lock := l.locks[target]
+	if lock == nil {
+		lock = &targetLock{}
+		l.locks[target] = lock
+	}
+	lock.holders++
+	l.mu.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		lock.holders--
+		if lock.holders == 0 {
+			delete(l.locks, target)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// lockTarget блокирует объект target в процессе и в базе, чтобы в Before и After записи аудита
+// попадало только изменение этого запроса, сколько бы копий сервера и walletctl ни работали
+func lockTarget(audit AuditStorage, target string) (func(), error) {
+	unlock := auditLocks.lock(target)
+
+	unlockDB, err := audit.LockAuditTarget(target)
+	if err != nil {
+		unlock()
+		log.Println("audit lock error, request rejected:", err, target)
+		return nil, err
+	}
+
+	return func() {
+		unlockDB()
+		unlock()
+	}, nil
+}
+
+// appendStarted записывает в журнал, что запрос начат (Status 0). Запрос выполняется, только если
+// запись удалась: так не бывает изменений без записи в журнале, даже если итог записать не получится.
+func appendStarted(audit AuditStorage, entry datastorage.AuditEntry) error {
+	entry.Status = 0
+	entry.After = nil
+
+	saved, err := audit.AppendAudit(entry)
+	if err != nil {
+		log.Println("audit error, request rejected:", err, entry.RequestId)
+		return err
+	}
+
+	log.Println("audit entry", saved.Id, saved.Method, saved.Path, "started")
+	return nil
+}
+
+// appendFinished записывает итог запроса. Если это не удалось, в журнале остаётся запись о начале.
+func appendFinished(audit AuditStorage, entry datastorage.AuditEntry) {
+	saved, err := audit.AppendAudit(entry)
+	if err != nil {
+		log.Println("audit error, only start of request is recorded:", err, entry.RequestId)
+		return
+	}
+
+	log.Println("audit entry", saved.Id, saved.Method, saved.Path, saved.Status)
+}
+
+// sourceIP - адрес, с которого пришёл запрос
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// auditTarget определяет, какой объект меняет запрос: wallet:{id}, fee, tier:{name} и т.п.
+func auditTarget(path string, body map[string]any) string {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/v1/"), "/"), "/")
+
+	switch {
+	case parts[0] == "wallets" && (len(parts) == 1 || parts[1] == "wallet" || parts[1] == "batch" || parts[1] == "import"):
+		if id, ok := body["walletId"].(string); ok && id != "" {
+			return "wallet:" + id
+		}
+		return "wallets"
+	case parts[0] == "wallets":
+		return "wallet:" + parts[1]
+	case parts[0] == "transactions" && len(parts) > 1:
+		return "operation:" + parts[1]
+	case len(parts) > 1:
+		return strings.TrimSuffix(parts[0], "s") + ":" + parts[1]
+	}
+
+	return strings.TrimSuffix(parts[0], "s")
+}
+
+// redact заменяет значения секретных полей
+func redact(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if secretFields[strings.ToLower(key)] {
+				value[key] = "***"
+			} else {
+				value[key] = redact(field)
+			}
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = redact(item)
+		}
+	}
+	return value
+}
+
+// auditJSON возвращает JSON без секретов или nil, если data не JSON
+func auditJSON(data []byte) json.RawMessage {
+	var value any
+	if len(data) == 0 || len(data) > maxAuditedBody || json.Unmarshal(data, &value) != nil || value == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(redact(value))
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// auditState возвращает функцию, снимающую состояние цели запроса,
+// или nil - тогда состоянием после запроса считается ответ сервера
+func auditState(ds WalletStorage, target string) func() json.RawMessage {
+	snapshot := func(get func() (any, error)) func() json.RawMessage {
+		return func() json.RawMessage {
+			value, err := get()
+			if err != nil {
+				log.Println("audit snapshot error:", err)
+				return nil
+			}
+
+			raw, _ := json.Marshal(value)
+			return auditJSON(raw)
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(target, "wallet:"):
+		uuid := strings.TrimPrefix(target, "wallet:")
+
+		return snapshot(func() (any, error) {
+			got, wallet, err := ds.GetWallet(uuid)
+			if !got {
+				return nil, err
+			}
+			return wallet, err
+		})
+
+	case target == "fee":
+		if fs, ok := ds.(FeeStorage); ok {
+			return snapshot(func() (any, error) { return fs.ListFeeRules() })
+		}
+
+	case target == "interest":
+		if is, ok := ds.(InterestStorage); ok {
+			return snapshot(func() (any, error) { return is.ListInterestRules() })
+		}
+	}
+
+	return nil
+}
+
+// withAudit записывает в журнал аудита каждый изменяющий запрос: кто, откуда, с каким id запроса,
+// что менял и каким был объект до и после. Всем запросам в ответе отдаётся X-Request-Id.
+// Перед выполнением запроса пишется запись о его начале: если журнал недоступен, запрос отклоняется
+// с 503 и ничего не меняет. После выполнения пишется вторая запись с итогом.
+func withAudit(audit AuditStorage, ds WalletStorage, adminToken string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestId(r.Header.Get(requestIdHeader))
+		w.Header().Set(requestIdHeader, id)
+
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentRequest+1))
+		if err != nil {
+			log.Println("error reading request body:", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+
+		entry := datastorage.AuditEntry{
+			Subject:   tokenSubject(r.Header.Get("Authorization"), adminToken),
+			SourceIP:  sourceIP(r),
+			RequestId: id,
+			Method:    r.Method,
+			Path:      r.URL.RequestURI(),
+			Reason:    r.Header.Get(reasonHeader),
+			Request:   auditJSON(body),
+		}
+		entry.Actor = auditActor(entry.Subject, r.Header.Get("X-Actor"))
+
+		var fields map[string]any
+		json.Unmarshal(entry.Request, &fields)
+
+		if reason, ok := fields["reason"].(string); ok && entry.Reason == "" {
+			entry.Reason = reason
+		}
+
+		entry.Target = auditTarget(r.URL.Path, fields)
+		state := auditState(ds, entry.Target)
+
+		if state != nil {
+			unlock, err := lockTarget(audit, entry.Target)
+			if err != nil {
+				http.Error(w, "AUDIT_UNAVAILABLE: request was not executed", http.StatusServiceUnavailable)
+				return
+			}
+			defer unlock()
+		}
+
+		dbSem <- struct{}{}
+		if state != nil {
+			entry.Before = state()
+		}
+		err = appendStarted(audit, entry)
+		<-dbSem
+
+		if err != nil {
+			http.Error(w, "AUDIT_UNAVAILABLE: request was not executed", http.StatusServiceUnavailable)
+			return
+		}
+
+		rec := &recordingWriter{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		entry.Status = rec.status
+		if entry.Status == 0 {
+			entry.Status = http.StatusOK
+		}
+
+		dbSem <- struct{}{}
+		defer func() { <-dbSem }()
+
+		if state != nil {
+			entry.After = state()
+		} else {
+			entry.After = auditJSON(rec.body.Bytes())
+		}
+
+		appendFinished(audit, entry)
+	})
+}
+
+// newAuditHandler выдаёт записи журнала аудита, начиная с последних:
+// /api/v1/audit?actor=&target=&requestId=&from=&to=&before=&limit=
+func newAuditHandler(ds AuditStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.URL.Path != "/api/v1/audit" {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		query := r.URL.Query()
+
+		filter := datastorage.AuditFilter{
+			Actor:     query.Get("actor"),
+			Target:    query.Get("target"),
+			RequestId: query.Get("requestId"),
+		}
+
+		var reason string
+		if filter.Limit, reason = pageLimit(query.Get("limit"), defaultAuditPage, maxAuditPage); reason != "" {
+			log.Println("wrong limit:", query.Get("limit"))
+			http.Error(w, reason, http.StatusBadRequest)
+			return
+		}
+
+		if value := query.Get("before"); value != "" {
+			before, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || before <= 0 {
+				log.Println("wrong before:", value)
+				http.Error(w, "before must be an entry id", http.StatusBadRequest)
+				return
+			}
+			filter.BeforeId = before
+		}
+
+		for _, bound := range []struct {
+			name  string
+			value **time.Time
+		}{{"from", &filter.From}, {"to", &filter.To}} {
+			value := query.Get(bound.name)
+			if value == "" {
+				continue
+			}
+
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				log.Println("wrong", bound.name+":", value)
+				http.Error(w, bound.name+" must be RFC 3339 time", http.StatusBadRequest)
+				return
+			}
+			*bound.value = &at
+		}
+
+		entries, err := ds.ListAudit(filter)
+
+		if err != nil {
+			log.Println("error in list audit method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if entries == nil {
+			entries = []datastorage.AuditEntry{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	}
+}
+
+// newAuditVerifyHandler проверяет цепочку hash журнала аудита
+func newAuditVerifyHandler(ds AuditStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.URL.Path != "/api/v1/audit/verify" {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		result, err := ds.VerifyAudit()
+
+		if err != nil {
+			log.Println("error in verify audit method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !result.Valid {
+			log.Println("audit chain broken at", *result.BrokenAt, result.Reason)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
@@ -0,0 +1,288 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditTarget(t *testing.T) {
+	tests := []struct {
+		path, body, target string
+	}{
+		{"/api/v1/wallets/wallet", `{"walletId":"asd1","operationType":"DEPOSIT"}`, "wallet:asd1"},
+		{"/api/v1/wallets/wallet/create", `{"walletId":"asd2"}`, "wallet:asd2"},
+		{"/api/v1/wallets/batch", `{"operations":[]}`, "wallets"},
+		{"/api/v1/wallets/asd1", `{"metadata":{}}`, "wallet:asd1"},
+		{"/api/v1/wallets/asd1/freeze", `{"reason":"fraud"}`, "wallet:asd1"},
+		{"/api/v1/transactions/7/reverse", `{}`, "operation:7"},
+		{"/api/v1/tiers/gold", `{}`, "tier:gold"},
+		{"/api/v1/fees", `{}`, "fee"},
+		{"/api/v1/interest", `{}`, "interest"},
+		{"/api/v1/webhooks", `{}`, "webhook"},
+		{"/api/v1/schedules/3", ``, "schedule:3"},
+	}
+
+	for _, test := range tests {
+		var body map[string]any
+		json.Unmarshal([]byte(test.body), &body)
+
+		assert.Equal(t, test.target, auditTarget(test.path, body), test.path)
+	}
+}
+
+func TestAuditJSON(t *testing.T) {
+	assert.JSONEq(t, `{"url":"http://a","secret":"***","nested":[{"Token":"***","id":1}]}`,
+		string(auditJSON([]byte(`{"url":"http://a","secret":"0123456789abcdef","nested":[{"Token":"x","id":1}]}`))))
+	assert.Nil(t, auditJSON([]byte("walletId,balance\n")))
+	assert.Nil(t, auditJSON([]byte("null")))
+	assert.Nil(t, auditJSON(nil))
+}
+
+func TestWithAudit(t *testing.T) {
+	audit := NewMockAuditStorage(t)
+	ds := NewMockWalletStorage(t)
+
+	ds.EXPECT().GetWallet("asd1").Return(true, datastorage.Wallet{Id: "asd1", Status: datastorage.StatusActive, Version: 3}, nil).Once()
+	ds.EXPECT().GetWallet("asd1").Return(true, datastorage.Wallet{Id: "asd1", Status: datastorage.StatusFrozen, Version: 4}, nil).Once()
+
+	var entries []datastorage.AuditEntry
+	audit.EXPECT().AppendAudit(mock.Anything).
+		Run(func(entry datastorage.AuditEntry) { entries = append(entries, entry) }).
+		Return(datastorage.AuditEntry{Id: 1}, nil).
+		Times(4)
+	audit.EXPECT().LockAuditTarget("wallet:asd1").Return(func() {}, nil).Once()
+
+	handler := withAudit(audit, ds, "secret-token", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/webhooks" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id":5,"url":"http://hooks.example/wallets","secret":"0123456789abcdef"}`)
+			return
+		}
+		fmt.Fprintln(w, "Wallet frozen")
+	}))
+
+	// чтение не записывается, но получает id запроса
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/wallets/asd1", nil))
+	assert.Len(t, rec.Header().Get("X-Request-Id"), 32)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/asd1/freeze", strings.NewReader(`{"reason":"fraud check"}`))
+	req.RemoteAddr = "10.0.0.7:51234"
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("X-Actor", "ivan")
+	req.Header.Set("X-Request-Id", "req-1")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "req-1", rec.Header().Get("X-Request-Id"))
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/webhooks",
+		strings.NewReader(`{"url":"http://hooks.example/wallets","secret":"0123456789abcdef"}`))
+	req.Header.Set("Authorization", "Bearer wrong")
+	req.Header.Set("X-Actor", "ivan")
+	req.Header.Set("X-Reason", "new partner")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	// на каждый запрос две записи: о начале (Status 0) и с итогом
+	require.Len(t, entries, 4)
+
+	started := entries[0]
+	assert.Equal(t, 0, started.Status)
+	assert.Equal(t, "req-1", started.RequestId)
+	assert.Contains(t, string(started.Before), `"status":"active"`)
+	assert.Nil(t, started.After)
+
+	freeze := entries[1]
+	assert.Equal(t, "ivan", freeze.Actor)
+	assert.True(t, strings.HasPrefix(freeze.Subject, "admin:"), freeze.Subject)
+	assert.NotContains(t, freeze.Subject, "secret-token")
+	assert.Equal(t, "10.0.0.7", freeze.SourceIP)
+	assert.Equal(t, "req-1", freeze.RequestId)
+	assert.Equal(t, http.MethodPost, freeze.Method)
+	assert.Equal(t, "/api/v1/wallets/asd1/freeze", freeze.Path)
+	assert.Equal(t, "wallet:asd1", freeze.Target)
+	assert.Equal(t, http.StatusOK, freeze.Status)
+	assert.Equal(t, "fraud check", freeze.Reason)
+	assert.JSONEq(t, `{"reason":"fraud check"}`, string(freeze.Request))
+	assert.Contains(t, string(freeze.Before), `"status":"active"`)
+	assert.Contains(t, string(freeze.After), `"status":"frozen"`)
+
+	assert.Equal(t, 0, entries[2].Status)
+
+	webhook := entries[3]
+	// неподтверждённый вызывающий не может назваться чужим именем
+	assert.Empty(t, webhook.Actor)
+	assert.True(t, strings.HasPrefix(webhook.Subject, "unknown:"), webhook.Subject)
+	assert.Equal(t, "webhook", webhook.Target)
+	assert.Equal(t, http.StatusCreated, webhook.Status)
+	assert.Equal(t, "new partner", webhook.Reason)
+	assert.JSONEq(t, `{"url":"http://hooks.example/wallets","secret":"***"}`, string(webhook.Request))
+	assert.Nil(t, webhook.Before)
+	assert.JSONEq(t, `{"id":5,"url":"http://hooks.example/wallets","secret":"***"}`, string(webhook.After))
+}
+
+func TestWithAuditUnavailable(t *testing.T) {
+	audit := NewMockAuditStorage(t)
+	audit.EXPECT().AppendAudit(mock.Anything).Return(datastorage.AuditEntry{}, datastorage.DBError{}).Once()
+
+	handler := withAudit(audit, NewMockWalletStorage(t), "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request must not be executed without audit entry")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(`{}`)))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "AUDIT_UNAVAILABLE: request was not executed\n", rec.Body.String())
+
+	// без блокировки объекта в базе запрос тоже не выполняется
+	audit.EXPECT().LockAuditTarget("wallet:asd1").Return(nil, datastorage.DBError{}).Once()
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/wallets/asd1/freeze", strings.NewReader(`{}`)))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Empty(t, auditLocks.locks)
+}
+
+func TestWithAuditSerializesTarget(t *testing.T) {
+	audit := NewMockAuditStorage(t)
+	audit.EXPECT().AppendAudit(mock.Anything).Return(datastorage.AuditEntry{Id: 1}, nil)
+	audit.EXPECT().LockAuditTarget("wallet:asd1").Return(func() {}, nil).Times(5)
+
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().GetWallet("asd1").Return(true, datastorage.Wallet{Id: "asd1"}, nil)
+
+	var running, overlapped atomic.Int32
+	handler := withAudit(audit, ds, "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if running.Add(1) > 1 {
+			overlapped.Add(1)
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+	}))
+
+	// запросы к одному кошельку выполняются по очереди, чтобы Before и After не смешивались
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/wallets/asd1/freeze", strings.NewReader(`{}`)))
+		})
+	}
+	wg.Wait()
+
+	assert.Zero(t, overlapped.Load())
+	assert.Empty(t, auditLocks.locks)
+}
+
+func TestAuditActor(t *testing.T) {
+	assert.Equal(t, "ivan", auditActor(tokenSubject("Bearer secret", "secret"), "ivan"))
+	assert.Equal(t, "admin", auditActor(tokenSubject("Bearer secret", "secret"), ""))
+	assert.Empty(t, auditActor(tokenSubject("Bearer other", "secret"), "ivan"))
+	assert.Empty(t, auditActor(tokenSubject("", "secret"), "ivan"))
+	// без настроенного токена администратора никто не подтверждён
+	assert.Empty(t, auditActor(tokenSubject("Bearer ", ""), "ivan"))
+}
+
+func TestGoodAuditHandler(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	entry := datastorage.AuditEntry{
+		Id:        8,
+		CreatedAt: time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC),
+		Actor:     "ivan",
+		RequestId: "req-1",
+		Method:    http.MethodPost,
+		Path:      "/api/v1/wallets/asd1/freeze",
+		Target:    "wallet:asd1",
+		Status:    http.StatusOK,
+		PrevHash:  "a",
+		Hash:      "b",
+	}
+
+	ds := NewMockAuditStorage(t)
+	ds.EXPECT().
+		ListAudit(datastorage.AuditFilter{Actor: "ivan", Target: "wallet:asd1", From: &from, BeforeId: 9, Limit: 10}).
+		Return([]datastorage.AuditEntry{entry}, nil).
+		Once()
+	ds.EXPECT().ListAudit(datastorage.AuditFilter{Limit: defaultAuditPage}).Return(nil, nil).Once()
+
+	handler := newAuditHandler(ds)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+		"/api/v1/audit?actor=ivan&target=wallet:asd1&from=2026-10-01T00:00:00Z&before=9&limit=10", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"id":8,"createdAt":"2026-10-02T09:00:00Z","actor":"ivan","requestId":"req-1","method":"POST",
+		"path":"/api/v1/wallets/asd1/freeze","target":"wallet:asd1","status":200,"prevHash":"a","hash":"b"}]`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/audit", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "[]\n", rec.Body.String())
+}
+
+func TestWrongAuditHandler(t *testing.T) {
+	handler := newAuditHandler(NewMockAuditStorage(t))
+
+	tests := []struct {
+		method, target string
+		status         int
+	}{
+		{http.MethodPost, "/api/v1/audit", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/v1/audit/7", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/audit?limit=0", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/audit?before=x", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/audit?to=yesterday", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(test.method, test.target, nil))
+
+		assert.Equal(t, test.status, rec.Code, test.method+" "+test.target)
+	}
+}
+
+func TestAuditVerifyHandler(t *testing.T) {
+	broken := int64(4)
+
+	ds := NewMockAuditStorage(t)
+	ds.EXPECT().VerifyAudit().
+		Return(datastorage.AuditVerification{Entries: 3, Head: "c", BrokenAt: &broken, Reason: "entry was modified"}, nil).
+		Once()
+
+	handler := newAuditVerifyHandler(ds)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/audit/verify", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"entries":3,"head":"c","valid":false,"brokenAt":4,"reason":"entry was modified"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/audit/verify", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
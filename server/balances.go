@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+const maxBalancesWallets = 1000
+
+type BalanceHistoryStorage interface {
+	BalancesAt(uuids []string, at time.Time) ([]datastorage.WalletBalance, error)
+}
+
+type balanceAt struct {
+	WalletId string    `json:"walletId"`
+	Balance  float64   `json:"balance"`
+	At       time.Time `json:"at"`
+}
+
+type balancesMessage struct {
+	At        string   `json:"at"`
+	WalletIds []string `json:"walletIds"`
+}
+
+// balancesResponse - балансы кошельков на момент at; Missing - кошельки, которых нет
+type balancesResponse struct {
+	At       time.Time                   `json:"at"`
+	Balances []datastorage.WalletBalance `json:"balances"`
+	Missing  []string                    `json:"missing"`
+}
+
+// writeBalanceAt отдаёт баланс кошелька uuid на момент value для GET /api/v1/wallets/{WALLET_UUID}?at=
+func writeBalanceAt(w http.ResponseWriter, r *http.Request, ds BalanceHistoryStorage, uuid, value string) {
+	if ds == nil {
+		log.Println("balance history is not supported")
+		http.Error(w, "at is not supported", http.StatusBadRequest)
+		return
+	}
+
+	at, err := parseTime(value)
+	if err != nil {
+		log.Println("wrong at:", value)
+		http.Error(w, "at must be RFC 3339 time or date", http.StatusBadRequest)
+		return
+	}
+
+	balances, err := ds.BalancesAt([]string{uuid}, at)
+
+	if err != nil {
+		log.Println("error in balances at method:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if len(balances) == 0 {
+		log.Println("uuid undefined")
+		http.Error(w, "uuid undefined", http.StatusBadRequest)
+		return
+	}
+
+	balance := math.Floor(balances[0].Balance*100) / 100
+
+	log.Println("Operation is done")
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(balanceAt{WalletId: uuid, Balance: balance, At: at})
+		return
+	}
+
+	fmt.Fprintln(w, balance)
+}
+
+// newBalancesHandler отдаёт балансы нескольких кошельков на один момент времени
+func newBalancesHandler(ds BalanceHistoryStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var msg balancesMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			log.Println("wrong json")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		at, err := parseTime(msg.At)
+		if err != nil {
+			log.Println("wrong at:", msg.At)
+			http.Error(w, "at must be RFC 3339 time or date", http.StatusBadRequest)
+			return
+		}
+
+		if len(msg.WalletIds) == 0 || len(msg.WalletIds) > maxBalancesWallets {
+			log.Println("wrong wallets count:", len(msg.WalletIds))
+			http.Error(w, "walletIds must contain from 1 to "+strconv.Itoa(maxBalancesWallets)+" wallets", http.StatusBadRequest)
+			return
+		}
+
+		log.Println("balances of", len(msg.WalletIds), "wallets at", at)
+
+		balances, err := ds.BalancesAt(msg.WalletIds, at)
+
+		if err != nil {
+			log.Println("error in balances at method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := balancesResponse{At: at, Balances: balances, Missing: []string{}}
+
+		if response.Balances == nil {
+			response.Balances = []datastorage.WalletBalance{}
+		}
+
+		found := map[string]bool{}
+		for i := range response.Balances {
+			response.Balances[i].Balance = math.Floor(response.Balances[i].Balance*100) / 100
+			found[response.Balances[i].WalletId] = true
+		}
+
+		for _, uuid := range msg.WalletIds {
+			if !found[uuid] {
+				response.Missing = append(response.Missing, uuid)
+				found[uuid] = true
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
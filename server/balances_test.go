@@ -0,0 +1,115 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// historyStorage - кошельки вместе с балансами на момент времени
+type historyStorage struct {
+	*MockWalletStorage
+	*MockBalanceHistoryStorage
+}
+
+func TestGoodBalanceAt(t *testing.T) {
+	at := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	history := NewMockBalanceHistoryStorage(t)
+	history.EXPECT().
+		BalancesAt([]string{"asd1"}, at).
+		Return([]datastorage.WalletBalance{{WalletId: "asd1", Balance: 120.509}}, nil).
+		Twice()
+
+	handler := newWalletHandler(historyStorage{NewMockWalletStorage(t), history})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/wallets/asd1?at=2026-10-01", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "120.5\n", rec.Body.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/asd1?at=2026-10-01T00:00:00Z", nil)
+	req.Header.Set("Accept", "application/json")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"walletId":"asd1","balance":120.5,"at":"2026-10-01T00:00:00Z"}`, rec.Body.String())
+}
+
+func TestWrongBalanceAt(t *testing.T) {
+	history := NewMockBalanceHistoryStorage(t)
+	history.EXPECT().BalancesAt([]string{"asd2"}, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)).Return(nil, nil).Once()
+
+	cases := []struct {
+		handler http.HandlerFunc
+		path    string
+		status  int
+		body    string
+	}{
+		{newWalletHandler(NewMockWalletStorage(t)), "/api/v1/wallets/asd1?at=2026-10-01", http.StatusBadRequest, "at is not supported\n"},
+		{newWalletHandler(historyStorage{NewMockWalletStorage(t), history}), "/api/v1/wallets/asd1?at=october", http.StatusBadRequest,
+			"at must be RFC 3339 time or date\n"},
+		{newWalletHandler(historyStorage{NewMockWalletStorage(t), history}), "/api/v1/wallets/asd2?at=2026-10-01", http.StatusBadRequest,
+			"uuid undefined\n"},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		c.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+
+		assert.Equal(t, c.status, rec.Code, c.path)
+		assert.Equal(t, c.body, rec.Body.String(), c.path)
+	}
+}
+
+func TestGoodBalances(t *testing.T) {
+	at := time.Date(2026, 9, 30, 21, 0, 0, 0, time.UTC)
+
+	ds := NewMockBalanceHistoryStorage(t)
+	ds.EXPECT().
+		BalancesAt([]string{"asd1", "asd2", "asd3", "asd2"}, mock.MatchedBy(at.Equal)).
+		Return([]datastorage.WalletBalance{{WalletId: "asd1", Balance: 10}, {WalletId: "asd3", Balance: 0.129}}, nil).
+		Once()
+
+	rec := httptest.NewRecorder()
+	newBalancesHandler(ds).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/wallets/balances",
+		strings.NewReader(`{"at":"2026-10-01T00:00:00+03:00","walletIds":["asd1","asd2","asd3","asd2"]}`)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"at":"2026-10-01T00:00:00+03:00",
+		"balances":[{"walletId":"asd1","balance":10},{"walletId":"asd3","balance":0.12}],
+		"missing":["asd2"]}`, rec.Body.String())
+}
+
+func TestWrongBalances(t *testing.T) {
+	handler := newBalancesHandler(NewMockBalanceHistoryStorage(t))
+
+	cases := []struct {
+		method, body string
+		status       int
+		response     string
+	}{
+		{http.MethodGet, ``, http.StatusMethodNotAllowed, "Invalid request method\n"},
+		{http.MethodPost, `{`, http.StatusBadRequest, "unexpected EOF\n"},
+		{http.MethodPost, `{"walletIds":["asd1"]}`, http.StatusBadRequest, "at must be RFC 3339 time or date\n"},
+		{http.MethodPost, `{"at":"2026-10-01","walletIds":[]}`, http.StatusBadRequest, "walletIds must contain from 1 to 1000 wallets\n"},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(c.method, "/api/v1/wallets/balances", strings.NewReader(c.body)))
+
+		assert.Equal(t, c.status, rec.Code, c.body)
+		assert.Equal(t, c.response, rec.Body.String(), c.body)
+	}
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+const defaultBatchLimit = 1000
+
+const (
+	batchModeAtomic     = "atomic"
+	batchModeBestEffort = "best_effort"
+)
+
+type BatchStorage interface {
+	Batch(ops []datastorage.Operation, atomic bool) ([]error, error)
+}
+
+type batchMessage struct {
+	Mode       string                  `json:"mode"`
+	Operations []datastorage.Operation `json:"operations"`
+}
+
+// batchItemResult - результат одной операции пакета.
+// Status: ok, failed или not_applied (операция откатилась вместе с пакетом).
+type batchItemResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Code   string `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Mode    string            `json:"mode"`
+	Results []batchItemResult `json:"results"`
+}
+
+// validOperation проверяет операцию до обращения к хранилищу
+func validOperation(op datastorage.Operation) string {
+	if op.Amount <= 0 {
+		return "sum must be more 0"
+	}
+
+	if reason := validDetails(op.OperationDetails); reason != "" {
+		return reason
+	}
+
+	switch op.OperationType {
+	case datastorage.OperationDeposit, datastorage.OperationWithdraw:
+		return ""
+	case datastorage.OperationTransfer:
+		if op.ToWalletId == "" || op.ToWalletId == op.WalletId {
+			return "toWalletId must be another wallet"
+		}
+		return ""
+	}
+
+	return "wrong operation type"
+}
+
+func newBatchHandler(ds BatchStorage, limit int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.URL.Path != "/api/v1/wallets/batch" {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		var msg batchMessage
+		err := json.NewDecoder(r.Body).Decode(&msg)
+		if err != nil {
+			log.Println("wrong json")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if msg.Mode == "" {
+			msg.Mode = batchModeAtomic
+		}
+
+		if msg.Mode != batchModeAtomic && msg.Mode != batchModeBestEffort {
+			log.Println("wrong batch mode:", msg.Mode)
+			http.Error(w, "mode must be atomic or best_effort", http.StatusBadRequest)
+			return
+		}
+
+		if len(msg.Operations) == 0 || len(msg.Operations) > limit {
+			log.Println("wrong batch size:", len(msg.Operations))
+			http.Error(w, fmt.Sprintf("batch must contain from 1 to %d operations", limit), http.StatusBadRequest)
+			return
+		}
+
+		log.Println("batch of", len(msg.Operations), "operations, mode:", msg.Mode)
+
+		response := batchResponse{Mode: msg.Mode, Results: make([]batchItemResult, len(msg.Operations))}
+
+		var valid []datastorage.Operation
+		var validIndex []int
+
+		for i, op := range msg.Operations {
+			op.Amount = math.Floor(op.Amount*100) / 100
+			response.Results[i] = batchItemResult{Index: i, Status: "ok"}
+
+			if reason := validOperation(op); reason != "" {
+				response.Results[i] = batchItemResult{Index: i, Status: "failed", Code: "WRONG_OPERATION", Error: reason}
+				continue
+			}
+
+			op.IdempotencyKey = r.Header.Get(idempotencyHeader)
+			valid = append(valid, op)
+			validIndex = append(validIndex, i)
+		}
+
+		atomic := msg.Mode == batchModeAtomic
+		status := http.StatusOK
+
+		if atomic && len(valid) != len(msg.Operations) {
+			log.Println("batch rejected: wrong operations")
+			for _, i := range validIndex {
+				response.Results[i].Status = "not_applied"
+			}
+			writeBatchResponse(w, http.StatusBadRequest, response)
+			return
+		}
+
+		if len(valid) > 0 {
+			errs, err := ds.Batch(valid, atomic)
+
+			if err != nil {
+				log.Println("error in batch method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			for j, opErr := range errs {
+				if opErr == nil {
+					continue
+				}
+
+				code, _ := operationError(opErr)
+				response.Results[validIndex[j]] = batchItemResult{Index: validIndex[j], Status: "failed", Code: code, Error: opErr.Error()}
+
+				if atomic {
+					status = http.StatusBadRequest
+				}
+			}
+
+			if status != http.StatusOK {
+				for i := range response.Results {
+					if response.Results[i].Status == "ok" {
+						response.Results[i].Status = "not_applied"
+					}
+				}
+			}
+		}
+
+		log.Println("Batch complit")
+		writeBatchResponse(w, status, response)
+	}
+}
+
+func writeBatchResponse(w http.ResponseWriter, status int, response batchResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
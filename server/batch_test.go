@@ -0,0 +1,206 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoodAtomicBatch(t *testing.T) {
+	ds := NewMockBatchStorage(t)
+
+	ops := []datastorage.Operation{
+		{WalletId: "1", OperationType: "DEPOSIT", Amount: 10},
+		{WalletId: "1", OperationType: "TRANSFER", Amount: 5.55, ToWalletId: "2"},
+	}
+
+	ds.EXPECT().
+		Batch(ops, true).
+		Return([]error{nil, nil}, nil).
+		Once()
+
+	handler := newBatchHandler(ds, 10)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/batch",
+		strings.NewReader(`{"operations":[
+			{"walletId":"1","operationType":"DEPOSIT","amount":10},
+			{"walletId":"1","operationType":"TRANSFER","amount":5.559,"toWalletId":"2"}]}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t,
+		`{"mode":"atomic","results":[{"index":0,"status":"ok"},{"index":1,"status":"ok"}]}`,
+		rec.Body.String())
+}
+
+func TestFailedAtomicBatch(t *testing.T) {
+	ds := NewMockBatchStorage(t)
+
+	ops := []datastorage.Operation{
+		{WalletId: "1", OperationType: "DEPOSIT", Amount: 10},
+		{WalletId: "2", OperationType: "WITHDRAW", Amount: 100},
+	}
+
+	ds.EXPECT().
+		Batch(ops, true).
+		Return([]error{nil, datastorage.InsufficientFunds{}}, nil).
+		Once()
+
+	handler := newBatchHandler(ds, 10)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/batch",
+		strings.NewReader(`{"mode":"atomic","operations":[
+			{"walletId":"1","operationType":"DEPOSIT","amount":10},
+			{"walletId":"2","operationType":"WITHDRAW","amount":100}]}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.JSONEq(t,
+		`{"mode":"atomic","results":[
+			{"index":0,"status":"not_applied"},
+			{"index":1,"status":"failed","code":"INSUFFICIENT_FUNDS","error":"balance small for operation"}]}`,
+		rec.Body.String())
+}
+
+func TestWrongOperationAtomicBatch(t *testing.T) {
+	ds := NewMockBatchStorage(t)
+
+	handler := newBatchHandler(ds, 10)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/batch",
+		strings.NewReader(`{"operations":[
+			{"walletId":"1","operationType":"DEPOSIT","amount":10},
+			{"walletId":"1","operationType":"TRANSFER","amount":10,"toWalletId":"1"}]}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.JSONEq(t,
+		`{"mode":"atomic","results":[
+			{"index":0,"status":"not_applied"},
+			{"index":1,"status":"failed","code":"WRONG_OPERATION","error":"toWalletId must be another wallet"}]}`,
+		rec.Body.String())
+}
+
+func TestBestEffortBatch(t *testing.T) {
+	ds := NewMockBatchStorage(t)
+
+	ops := []datastorage.Operation{
+		{WalletId: "1", OperationType: "DEPOSIT", Amount: 10},
+		{WalletId: "2", OperationType: "WITHDRAW", Amount: 5},
+	}
+
+	ds.EXPECT().
+		Batch(ops, false).
+		Return([]error{nil, datastorage.WalletNotActive{Status: datastorage.StatusFrozen}}, nil).
+		Once()
+
+	handler := newBatchHandler(ds, 10)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/batch",
+		strings.NewReader(`{"mode":"best_effort","operations":[
+			{"walletId":"1","operationType":"DEPOSIT","amount":10},
+			{"walletId":"3","operationType":"SEND","amount":1},
+			{"walletId":"2","operationType":"WITHDRAW","amount":5}]}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t,
+		`{"mode":"best_effort","results":[
+			{"index":0,"status":"ok"},
+			{"index":1,"status":"failed","code":"WRONG_OPERATION","error":"wrong operation type"},
+			{"index":2,"status":"failed","code":"WALLET_NOT_ACTIVE","error":"wallet is frozen"}]}`,
+		rec.Body.String())
+}
+
+func TestTooBigBatch(t *testing.T) {
+	ds := NewMockBatchStorage(t)
+
+	handler := newBatchHandler(ds, 1)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/batch",
+		strings.NewReader(`{"operations":[
+			{"walletId":"1","operationType":"DEPOSIT","amount":10},
+			{"walletId":"1","operationType":"DEPOSIT","amount":10}]}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "batch must contain from 1 to 1 operations\n", rec.Body.String())
+}
+
+func TestDBErrorBatch(t *testing.T) {
+	ds := NewMockBatchStorage(t)
+
+	ops := []datastorage.Operation{
+		{WalletId: "1", OperationType: "DEPOSIT", Amount: 10},
+	}
+
+	ds.EXPECT().
+		Batch(ops, true).
+		Return(nil, errors.New("DB error")).
+		Once()
+
+	handler := newBatchHandler(ds, 10)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/batch",
+		strings.NewReader(`{"operations":[{"walletId":"1","operationType":"DEPOSIT","amount":10}]}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"walletGolang/bulk"
+	datastorage "walletGolang/dataStorage"
+)
+
+const (
+	maxImportSize = 32 << 20
+	// bulkTimeout - сколько может идти импорт или выгрузка: ReadTimeout и WriteTimeout сервера
+	// (5 секунд) рассчитаны на обычные запросы и обрывают передачу больших файлов
+	bulkTimeout = 10 * time.Minute
+)
+
+type BulkStorage interface {
+	ImportWallets(records []datastorage.WalletRecord) error
+	ExportWallets(each func(record datastorage.WalletRecord) error) error
+}
+
+type importResponse struct {
+	Imported int              `json:"imported"`
+	Errors   []bulk.LineError `json:"errors,omitempty"`
+}
+
+// bulkFormat берёт формат файла из параметра format (по умолчанию csv)
+func bulkFormat(r *http.Request) string {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		return bulk.FormatCSV
+	}
+	return format
+}
+
+func newImportHandler(ds BulkStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.URL.Path != "/api/v1/wallets/import" {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		format := bulkFormat(r)
+		if !bulk.ValidFormat(format) {
+			log.Println("wrong format:", format)
+			http.Error(w, "format must be csv or jsonl", http.StatusBadRequest)
+			return
+		}
+
+		log.Println("import wallets, format:", format)
+
+		controller := http.NewResponseController(w)
+		controller.SetReadDeadline(time.Now().Add(bulkTimeout))
+		controller.SetWriteDeadline(time.Now().Add(bulkTimeout))
+
+		imported, lineErrors, err := bulk.Import(ds, http.MaxBytesReader(w, r.Body, maxImportSize), format)
+
+		if err != nil {
+			log.Println("error in import method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if len(lineErrors) > 0 {
+			log.Println("import rejected, wrong lines:", len(lineErrors))
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			log.Println("Wallets imported:", imported)
+		}
+
+		json.NewEncoder(w).Encode(importResponse{Imported: imported, Errors: lineErrors})
+	}
+}
+
+func newExportHandler(ds BulkStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.URL.Path != "/api/v1/wallets/export" {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		format := bulkFormat(r)
+		if !bulk.ValidFormat(format) {
+			log.Println("wrong format:", format)
+			http.Error(w, "format must be csv or jsonl", http.StatusBadRequest)
+			return
+		}
+
+		log.Println("export wallets, format:", format)
+
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(bulkTimeout))
+
+		if format == bulk.FormatCSV {
+			w.Header().Set("Content-Type", "text/csv")
+		} else {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		}
+
+		writer, err := bulk.NewWriter(w, format)
+		if err == nil {
+			err = ds.ExportWallets(writer.Write)
+		}
+		if err == nil {
+			err = writer.Flush()
+		}
+
+		// заголовки уже отправлены, поэтому ошибку можно только залогировать
+		if err != nil {
+			log.Println("error in export method:", err)
+			return
+		}
+
+		log.Println("Wallets exported")
+	}
+}
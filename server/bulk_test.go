@@ -0,0 +1,149 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoodImportMethod(t *testing.T) {
+	ds := NewMockBulkStorage(t)
+
+	ds.EXPECT().
+		ImportWallets([]datastorage.WalletRecord{{WalletId: "a1", Balance: 10}, {WalletId: "a2", Balance: 0}}).
+		Return(nil).
+		Once()
+
+	handler := newImportHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/import?format=jsonl",
+		strings.NewReader("{\"walletId\":\"a1\",\"balance\":10}\n{\"walletId\":\"a2\",\"balance\":0}\n"),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{"imported":2}`, rec.Body.String())
+}
+
+func TestWrongLinesImportMethod(t *testing.T) {
+	ds := NewMockBulkStorage(t)
+
+	handler := newImportHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/import",
+		strings.NewReader("walletId,balance\na1,10\na1,5\n"),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.JSONEq(t,
+		`{"imported":0,"errors":[{"line":3,"error":"walletId a1 is duplicated, first seen on line 2"}]}`,
+		rec.Body.String())
+}
+
+func TestWrongFormatImportMethod(t *testing.T) {
+	ds := NewMockBulkStorage(t)
+
+	handler := newImportHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/import?format=xml",
+		strings.NewReader(""),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "format must be csv or jsonl\n", rec.Body.String())
+}
+
+func TestGoodExportMethod(t *testing.T) {
+	ds := NewMockBulkStorage(t)
+
+	ds.EXPECT().
+		ExportWallets(mock.Anything).
+		RunAndReturn(func(each func(record datastorage.WalletRecord) error) error {
+			each(datastorage.WalletRecord{WalletId: "a1", Balance: 1.5})
+			return each(datastorage.WalletRecord{WalletId: "a2", Balance: 0})
+		}).
+		Once()
+
+	handler := newExportHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodGet,
+		"/api/v1/wallets/export?format=csv",
+		nil,
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/csv", res.Header.Get("Content-Type"))
+	assert.Equal(t, "walletId,balance\na1,1.5\na2,0\n", rec.Body.String())
+}
+
+func TestSlowExportMethod(t *testing.T) {
+	ds := NewMockBulkStorage(t)
+
+	ds.EXPECT().
+		ExportWallets(mock.Anything).
+		RunAndReturn(func(each func(record datastorage.WalletRecord) error) error {
+			each(datastorage.WalletRecord{WalletId: "a1", Balance: 1.5})
+			time.Sleep(200 * time.Millisecond)
+			return each(datastorage.WalletRecord{WalletId: "a2", Balance: 0})
+		}).
+		Once()
+
+	// выгрузка дольше WriteTimeout сервера не обрывается
+	srv := httptest.NewUnstartedServer(newExportHandler(ds))
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/api/v1/wallets/export")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "walletId,balance\na1,1.5\na2,0\n", string(body))
+}
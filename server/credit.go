@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+type CreditStorage interface {
+	SetCreditLimit(uuid string, creditLimit float64) error
+}
+
+type creditLimitMessage struct {
+	CreditLimit float64 `json:"creditLimit"`
+}
+
+func newSetCreditLimitHandler(ds CreditStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		uuid, ok := walletPathId(r.URL.Path, "credit") // проверяем, что запрос имеет вид /api/v1/wallets/{WALLET_UUID}/credit
+		if !ok {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		var msg creditLimitMessage
+		err := json.NewDecoder(r.Body).Decode(&msg)
+		if err != nil {
+			log.Println("wrong json")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if msg.CreditLimit < 0 {
+			log.Println("wrong credit limit:", msg.CreditLimit)
+			http.Error(w, "credit limit must not be less 0", http.StatusBadRequest)
+			return
+		}
+
+		err = ds.SetCreditLimit(uuid, msg.CreditLimit)
+
+		if errors.As(err, &datastorage.UUIDUndefined{}) {
+			log.Println("uuid undefined")
+			http.Error(w, "uuid undefined", http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			log.Println("error in set credit limit method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		log.Println("Credit limit updated")
+		fmt.Fprintln(w, "Credit limit updated")
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoodSetCreditLimitMethod(t *testing.T) {
+	ds := NewMockCreditStorage(t)
+
+	uuid := "1"
+
+	ds.EXPECT().
+		SetCreditLimit(uuid, 500.0).
+		Return(nil).
+		Once()
+
+	handler := newSetCreditLimitHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/api/v1/wallets/"+uuid+"/credit",
+		strings.NewReader(`{"creditLimit":500}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Credit limit updated\n", rec.Body.String())
+}
+
+func TestNegativeSetCreditLimitMethod(t *testing.T) {
+	ds := NewMockCreditStorage(t)
+
+	handler := newSetCreditLimitHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/api/v1/wallets/1/credit",
+		strings.NewReader(`{"creditLimit":-1}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "credit limit must not be less 0\n", rec.Body.String())
+}
+
+func TestUUIDUndefinedSetCreditLimitMethod(t *testing.T) {
+	ds := NewMockCreditStorage(t)
+
+	uuid := "1"
+
+	ds.EXPECT().
+		SetCreditLimit(uuid, 10.0).
+		Return(datastorage.UUIDUndefined{}).
+		Once()
+
+	handler := newSetCreditLimitHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/api/v1/wallets/"+uuid+"/credit",
+		strings.NewReader(`{"creditLimit":10}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "uuid undefined\n", rec.Body.String())
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+	"walletGolang/events"
+)
+
+const (
+	heartbeatInterval = 15 * time.Second
+	fallbackHistory   = 100
+	maxResumeEvents   = 1000
+)
+
+// EventListener - хранилище, которое само уведомляет о событиях кошельков (Postgres LISTEN/NOTIFY)
+type EventListener interface {
+	ListenEvents(ctx context.Context, publish func(event datastorage.Event)) error
+}
+
+// EventHistory отдаёт пропущенные события для возобновления потока по Last-Event-ID
+type EventHistory interface {
+	EventsAfter(uuid string, afterId int64, limit int) ([]datastorage.Event, error)
+}
+
+// broadcastingStorage публикует события после успешных операций хранилища,
+// которое не умеет уведомлять о них само
+type broadcastingStorage struct {
+	WalletStorage
+	broadcaster *events.Broadcaster
+}
+
+func (s broadcastingStorage) publish(uuid, eventType string, amount float64) {
+	got, balance, err := s.Get(uuid)
+	if err != nil || !got {
+		return
+	}
+
+	s.broadcaster.Publish(datastorage.Event{
+		Id:        s.broadcaster.NextId(),
+		WalletId:  uuid,
+		Type:      eventType,
+		Amount:    amount,
+		Balance:   balance,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s broadcastingStorage) ChangeBalance(sum float64, uuid string, details datastorage.OperationDetails) (bool, datastorage.Receipt, error) {
+	changed, receipt, err := s.WalletStorage.ChangeBalance(sum, uuid, details)
+
+	if changed && err == nil {
+		eventType := datastorage.EventDeposited
+		if sum < 0 {
+			eventType = datastorage.EventWithdrawn
+		}
+		s.publish(uuid, eventType, sum)
+	}
+
+	return changed, receipt, err
+}
+
+func (s broadcastingStorage) Transfer(sum float64, from, to string, details datastorage.OperationDetails) (bool, datastorage.Receipt, error) {
+	changed, receipt, err := s.WalletStorage.Transfer(sum, from, to, details)
+
+	if changed && err == nil {
+		s.publish(from, datastorage.EventWithdrawn, -sum)
+		s.publish(to, datastorage.EventDeposited, sum-receipt.Fee)
+	}
+
+	return changed, receipt, err
+}
+
+func (s broadcastingStorage) CreateWallet(uuid string, options datastorage.WalletOptions) error {
+	err := s.WalletStorage.CreateWallet(uuid, options)
+
+	if err == nil {
+		s.publish(uuid, datastorage.EventWalletCreated, 0)
+	}
+
+	return err
+}
+
+// listenEvents передаёт события хранилища в broadcaster, переподключаясь при обрыве
+func listenEvents(listener EventListener, broadcaster *events.Broadcaster) {
+	for {
+		err := listener.ListenEvents(context.Background(), broadcaster.Publish)
+		log.Println("events listener stopped:", err)
+		time.Sleep(time.Second)
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event datastorage.Event) {
+	data, _ := json.Marshal(event)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+}
+
+// newWalletEventsHandler отдаёт изменения баланса кошелька потоком Server-Sent Events.
+// С заголовком Last-Event-ID сначала отправляются пропущенные события из history.
+func newWalletEventsHandler(ds WalletStorage, broadcaster *events.Broadcaster, history EventHistory, heartbeat time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		uuid, ok := walletPathId(r.URL.Path, "events") // проверяем, что запрос имеет вид /api/v1/wallets/{WALLET_UUID}/events
+		if !ok {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		var lastId int64
+		if lastEventId := r.Header.Get("Last-Event-ID"); lastEventId != "" {
+			var err error
+			lastId, err = strconv.ParseInt(lastEventId, 10, 64)
+			if err != nil {
+				log.Println("wrong Last-Event-ID:", lastEventId)
+				http.Error(w, "wrong Last-Event-ID", http.StatusBadRequest)
+				return
+			}
+		}
+
+		check, err := ds.Check(uuid)
+
+		if err != nil {
+			log.Println("error in check method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !check {
+			log.Println("uuid undefined")
+			http.Error(w, "uuid undefined", http.StatusBadRequest)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			log.Println("streaming unsupported")
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		// подписываемся до чтения истории, чтобы не потерять события между ними
+		stream, unsubscribe := broadcaster.Subscribe(uuid)
+		defer unsubscribe()
+
+		var missed []datastorage.Event
+		if lastId > 0 {
+			missed, err = history.EventsAfter(uuid, lastId, maxResumeEvents)
+			if err != nil {
+				log.Println("error in events after method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// поток живёт дольше WriteTimeout сервера
+		http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		log.Println("events stream started:", uuid)
+
+		fmt.Fprint(w, "retry: 3000\n\n")
+
+		for _, event := range missed {
+			writeEvent(w, event)
+			lastId = event.Id
+		}
+		flusher.Flush()
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				log.Println("events stream closed:", uuid)
+				return
+
+			case event, ok := <-stream:
+				if !ok {
+					// клиент не успевал читать события: закрываем поток, и он переподключится с Last-Event-ID
+					log.Println("events stream dropped:", uuid)
+					return
+				}
+
+				if event.Id <= lastId {
+					continue
+				}
+
+				writeEvent(w, event)
+				lastId = event.Id
+				flusher.Flush()
+
+			case <-ticker.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+				flusher.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+	"walletGolang/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readEvent читает из потока строки до пустой строки, пропуская комментарии
+func readEvent(t *testing.T, reader *bufio.Reader) []string {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(lines) > 0 {
+				return lines
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") || strings.HasPrefix(line, "retry:") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+}
+
+func startEventsStream(t *testing.T, handler http.Handler, lastEventId string) (*bufio.Reader, context.CancelFunc) {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/wallets/asd1/events", nil)
+	require.NoError(t, err)
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	return bufio.NewReader(res.Body), cancel
+}
+
+func TestGoodWalletEvents(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Check("asd1").Return(true, nil).Once()
+
+	broadcaster := events.NewBroadcaster(0)
+	handler := newWalletEventsHandler(ds, broadcaster, NewMockEventHistory(t), time.Hour)
+
+	reader, cancel := startEventsStream(t, handler, "")
+	defer cancel()
+
+	// ответ начинается после подписки, поэтому событие не потеряется
+	broadcaster.Publish(datastorage.Event{Id: 5, WalletId: "asd2", Type: datastorage.EventDeposited})
+	broadcaster.Publish(datastorage.Event{Id: 7, WalletId: "asd1", Type: datastorage.EventDeposited, Amount: 100, Balance: 100})
+
+	lines := readEvent(t, reader)
+	assert.Equal(t, "id: 7", lines[0])
+	assert.Equal(t, "event: wallet.deposited", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], `data: {"id":7,"walletId":"asd1","type":"wallet.deposited","amount":100,"balance":100`))
+}
+
+func TestResumeWalletEvents(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Check("asd1").Return(true, nil).Once()
+
+	history := NewMockEventHistory(t)
+	history.EXPECT().
+		EventsAfter("asd1", int64(3), maxResumeEvents).
+		Return([]datastorage.Event{
+			{Id: 4, WalletId: "asd1", Type: datastorage.EventDeposited},
+			{Id: 6, WalletId: "asd1", Type: datastorage.EventWithdrawn},
+		}, nil).
+		Once()
+
+	broadcaster := events.NewBroadcaster(0)
+	handler := newWalletEventsHandler(ds, broadcaster, history, time.Hour)
+
+	reader, cancel := startEventsStream(t, handler, "3")
+	defer cancel()
+
+	// событие 6 уже отправлено из истории и не должно повториться
+	broadcaster.Publish(datastorage.Event{Id: 6, WalletId: "asd1", Type: datastorage.EventWithdrawn})
+	broadcaster.Publish(datastorage.Event{Id: 8, WalletId: "asd1", Type: datastorage.EventDeposited})
+
+	assert.Equal(t, "id: 4", readEvent(t, reader)[0])
+	assert.Equal(t, "id: 6", readEvent(t, reader)[0])
+	assert.Equal(t, "id: 8", readEvent(t, reader)[0])
+}
+
+func TestSlowWalletEventsStream(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Check("asd1").Return(true, nil).Once()
+
+	broadcaster := events.NewBroadcaster(0)
+	handler := newWalletEventsHandler(ds, broadcaster, NewMockEventHistory(t), time.Hour)
+
+	reader, cancel := startEventsStream(t, handler, "")
+	defer cancel()
+
+	// клиент не читает, пока буферы соединения и подписки не переполнятся
+	const published = 100000
+	for i := 1; i <= published; i++ {
+		broadcaster.Publish(datastorage.Event{Id: int64(i), WalletId: "asd1", Type: datastorage.EventDeposited})
+	}
+
+	// поток обрывается, но в нём нет пропусков: клиент продолжит с последнего полученного id
+	var lastId int
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		if id, ok := strings.CutPrefix(strings.TrimSpace(line), "id: "); ok {
+			lastId++
+			require.Equal(t, strconv.Itoa(lastId), id)
+		}
+	}
+
+	assert.Less(t, lastId, published)
+}
+
+func TestWalletEventsHeartbeat(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Check("asd1").Return(true, nil).Once()
+
+	handler := newWalletEventsHandler(ds, events.NewBroadcaster(0), NewMockEventHistory(t), 10*time.Millisecond)
+
+	reader, cancel := startEventsStream(t, handler, "")
+	defer cancel()
+
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line == ": heartbeat\n" {
+			break
+		}
+	}
+}
+
+func TestWrongWalletEvents(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Check("asd1").Return(false, nil).Once()
+
+	handler := newWalletEventsHandler(ds, events.NewBroadcaster(0), NewMockEventHistory(t), time.Hour)
+
+	cases := []struct {
+		method, lastEventId string
+		status              int
+		body                string
+	}{
+		{http.MethodPost, "", http.StatusMethodNotAllowed, "Invalid request method\n"},
+		{http.MethodGet, "abc", http.StatusBadRequest, "wrong Last-Event-ID\n"},
+		{http.MethodGet, "", http.StatusBadRequest, "uuid undefined\n"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, "/api/v1/wallets/asd1/events", nil)
+		if c.lastEventId != "" {
+			req.Header.Set("Last-Event-ID", c.lastEventId)
+		}
+
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		res := rec.Result()
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		assert.Equal(t, c.status, res.StatusCode)
+		assert.Equal(t, c.body, string(body))
+	}
+}
+
+func TestBroadcastingStorage(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().ChangeBalance(float64(100), "asd1", datastorage.OperationDetails{}).Return(true, datastorage.Receipt{}, nil).Once()
+	ds.EXPECT().ChangeBalance(float64(-500), "asd1", datastorage.OperationDetails{}).Return(false, datastorage.Receipt{}, nil).Once()
+	ds.EXPECT().Get("asd1").Return(true, 100, nil).Once()
+
+	broadcaster := events.NewBroadcaster(fallbackHistory)
+	storage := broadcastingStorage{WalletStorage: ds, broadcaster: broadcaster}
+
+	stream, unsubscribe := broadcaster.Subscribe("asd1")
+	defer unsubscribe()
+
+	storage.ChangeBalance(100, "asd1", datastorage.OperationDetails{})
+	storage.ChangeBalance(-500, "asd1", datastorage.OperationDetails{})
+
+	assert.Len(t, stream, 1)
+
+	event := <-stream
+	assert.Equal(t, int64(1), event.Id)
+	assert.Equal(t, datastorage.EventDeposited, event.Type)
+	assert.Equal(t, float64(100), event.Balance)
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+type FeeStorage interface {
+	ListFeeRules() ([]datastorage.FeeRule, error)
+	SetFeeRule(rule datastorage.FeeRule) error
+	DeleteFeeRule(tier, operationType string) error
+}
+
+// newFeeRulesHandler обслуживает /api/v1/fees: GET отдаёт правила комиссий,
+// PUT создаёт или заменяет правило, DELETE удаляет правило ?operationType=&tier=
+func newFeeRulesHandler(ds FeeStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/fees" {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			rules, err := ds.ListFeeRules()
+
+			if err != nil {
+				log.Println("error in list fee rules method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if rules == nil {
+				rules = []datastorage.FeeRule{}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(rules)
+
+		case http.MethodPut:
+			var rule datastorage.FeeRule
+			if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+				log.Println("wrong json")
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if err := datastorage.ValidateFeeRule(rule); err != nil {
+				log.Println("wrong fee rule:", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			log.Println("set fee rule", rule.OperationType, rule.Tier, "by", adminActor(r))
+
+			err := ds.SetFeeRule(rule)
+
+			if errors.As(err, &datastorage.TierUndefined{}) {
+				log.Println("tier undefined:", rule.Tier)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if err != nil {
+				log.Println("error in set fee rule method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			log.Println("Fee rule updated")
+			fmt.Fprintln(w, "Fee rule updated")
+
+		case http.MethodDelete:
+			query := r.URL.Query()
+			tier, operationType := query.Get("tier"), query.Get("operationType")
+
+			log.Println("delete fee rule", operationType, tier, "by", adminActor(r))
+
+			err := ds.DeleteFeeRule(tier, operationType)
+
+			if errors.As(err, &datastorage.FeeRuleUndefined{}) {
+				log.Println("fee rule undefined")
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			if err != nil {
+				log.Println("error in delete fee rule method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			log.Println("Fee rule deleted")
+			fmt.Fprintln(w, "Fee rule deleted")
+
+		default:
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoodFeeRules(t *testing.T) {
+	low := 1.0
+	rule := datastorage.FeeRule{Tier: "gold", OperationType: datastorage.OperationWithdraw, Flat: 0.5, Percent: 1, MinFee: &low}
+
+	ds := NewMockFeeStorage(t)
+	ds.EXPECT().ListFeeRules().Return([]datastorage.FeeRule{rule}, nil).Once()
+	ds.EXPECT().SetFeeRule(rule).Return(nil).Once()
+	ds.EXPECT().DeleteFeeRule("", datastorage.OperationTransfer).Return(nil).Once()
+
+	handler := newFeeRulesHandler(ds)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/fees", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"tier":"gold","operationType":"WITHDRAW","flat":0.5,"percent":1,"minFee":1}]`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/v1/fees",
+		strings.NewReader(`{"tier":"gold","operationType":"WITHDRAW","flat":0.5,"percent":1,"minFee":1}`)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Fee rule updated\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/fees?operationType=TRANSFER", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Fee rule deleted\n", rec.Body.String())
+}
+
+func TestWrongFeeRules(t *testing.T) {
+	ds := NewMockFeeStorage(t)
+	ds.EXPECT().SetFeeRule(datastorage.FeeRule{Tier: "none", OperationType: datastorage.OperationTransfer, Flat: 1}).
+		Return(datastorage.TierUndefined{}).Once()
+	ds.EXPECT().DeleteFeeRule("gold", datastorage.OperationWithdraw).Return(datastorage.FeeRuleUndefined{}).Once()
+
+	handler := newFeeRulesHandler(ds)
+
+	tests := []struct {
+		method, target, body string
+		status               int
+	}{
+		{http.MethodPost, "/api/v1/fees", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/v1/fees/gold", "", http.StatusNotFound},
+		{http.MethodPut, "/api/v1/fees", "{", http.StatusBadRequest},
+		{http.MethodPut, "/api/v1/fees", `{"operationType":"DEPOSIT","flat":1}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/v1/fees", `{"operationType":"WITHDRAW","percent":-1}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/v1/fees", `{"tier":"none","operationType":"TRANSFER","flat":1}`, http.StatusBadRequest},
+		{http.MethodDelete, "/api/v1/fees?tier=gold&operationType=WITHDRAW", "", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+
+		assert.Equal(t, test.status, rec.Code, test.method+" "+test.target+" "+test.body)
+	}
+}
+
+func TestReceiptChangeBalanceMethod(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+
+	ds.EXPECT().Check("1").Return(true, nil).Twice()
+	ds.EXPECT().
+		ChangeBalance(-100.0, "1", datastorage.OperationDetails{}).
+		Return(true, datastorage.Receipt{OperationId: 7, Gross: 100, Fee: 1.5, Net: 98.5}, nil).
+		Once()
+	ds.EXPECT().
+		Transfer(1.0, "1", "2", datastorage.OperationDetails{}).
+		Return(false, datastorage.Receipt{}, datastorage.FeeExceedsAmount{Fee: 1}).
+		Once()
+
+	handler := newChangeBalanceHandler(ds)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/wallet",
+		strings.NewReader(`{"walletId":"1","operationType":"WITHDRAW","amount":100}`))
+	req.Header.Set("Accept", "application/json")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"operationId":7,"gross":100,"fee":1.5,"net":98.5}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/wallets/wallet",
+		strings.NewReader(`{"walletId":"1","operationType":"TRANSFER","amount":1,"toWalletId":"2"}`)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "FEE_EXCEEDS_AMOUNT: fee 1 is not less than amount\n", rec.Body.String())
+}
@@ -0,0 +1,326 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+
+	datastorage "walletGolang/dataStorage"
+	"walletGolang/events"
+	"walletGolang/walletpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcMutating - изменяющие методы gRPC, которые записываются в журнал аудита
+var grpcMutating = map[string]bool{
+	"CreateWallet": true,
+	"Deposit":      true,
+	"Withdraw":     true,
+}
+
+// grpcCodes - статусы gRPC для кодов ошибок операций из operationError
+var grpcCodes = map[string]codes.Code{
+	"LIMIT_EXCEEDED":        codes.ResourceExhausted,
+	"WALLET_NOT_ACTIVE":     codes.FailedPrecondition,
+	"INSUFFICIENT_FUNDS":    codes.FailedPrecondition,
+	"UUID_UNDEFINED":        codes.InvalidArgument,
+	"WRONG_OPERATION":       codes.InvalidArgument,
+	"DUPLICATE_EXTERNAL_ID": codes.AlreadyExists,
+	"OPERATION_UNDEFINED":   codes.NotFound,
+	"ALREADY_REVERSED":      codes.FailedPrecondition,
+	"REVERSAL_EXCEEDED":     codes.InvalidArgument,
+	"NOT_REVERSIBLE":        codes.FailedPrecondition,
+	"FEE_EXCEEDS_AMOUNT":    codes.InvalidArgument,
+}
+
+// grpcError переводит ошибку операции в статус gRPC.
+// Как и в REST, отказы по правилам кошелька содержат код ошибки в начале сообщения.
+func grpcError(err error) error {
+	code, httpStatus := operationError(err)
+
+	if httpStatus == http.StatusInternalServerError {
+		log.Println("wrong server behaviour")
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	grpcCode, ok := grpcCodes[code]
+	if !ok {
+		// codes.OK вернул бы вызывающему успешный ответ без данных
+		log.Println("no grpc code for", code)
+		grpcCode = codes.FailedPrecondition
+	}
+
+	log.Println("operation rejected:", code, err)
+	return status.Error(grpcCode, code+": "+err.Error())
+}
+
+type grpcServer struct {
+	walletpb.UnimplementedWalletServiceServer
+	storage     WalletStorage
+	broadcaster *events.Broadcaster
+}
+
+// newGRPCServer создаёт gRPC сервер кошельков поверх того же хранилища, что и REST.
+// Если audit не nil, изменяющие вызовы записываются в журнал аудита.
+func newGRPCServer(ds WalletStorage, broadcaster *events.Broadcaster, audit AuditStorage, adminToken string) *grpc.Server {
+	// аудит снаружи ограничения: как и в REST, объект блокируется до того, как занят семафор базы
+	var interceptors []grpc.UnaryServerInterceptor
+	if audit != nil {
+		interceptors = append(interceptors, grpcAudit(audit, ds, adminToken))
+	}
+	interceptors = append(interceptors, grpcDBLimit)
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	walletpb.RegisterWalletServiceServer(srv, grpcServer{storage: ds, broadcaster: broadcaster})
+	return srv
+}
+
+// grpcDBLimit ограничивает число одновременных обращений к базе общим с REST семафором
+func grpcDBLimit(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	dbSem <- struct{}{}
+	defer func() { <-dbSem }()
+
+	log.Println("grpc request", info.FullMethod)
+	return handler(ctx, req)
+}
+
+// grpcAudit записывает изменяющие вызовы в журнал аудита, как withAudit - запросы REST:
+// запись о начале до вызова (без неё вызов отклоняется с UNAVAILABLE) и запись с итогом после.
+// Subject - отпечаток токена из метаданных authorization, id запроса и причина - из x-request-id и x-reason,
+// actor из x-actor записывается только для токена администратора. Status - код gRPC (0 - OK).
+func grpcAudit(audit AuditStorage, ds WalletStorage, adminToken string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		walletReq, ok := req.(interface{ GetWalletId() string })
+		if !ok || !grpcMutating[info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		first := func(key string) string {
+			if values := md.Get(key); len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		}
+
+		entry := datastorage.AuditEntry{
+			Subject:   tokenSubject(first("authorization"), adminToken),
+			RequestId: requestId(first("x-request-id")),
+			Method:    "GRPC",
+			Path:      info.FullMethod,
+			Target:    "wallet:" + walletReq.GetWalletId(),
+			Reason:    first("x-reason"),
+		}
+		entry.Actor = auditActor(entry.Subject, first("x-actor"))
+
+		if p, ok := peer.FromContext(ctx); ok {
+			entry.SourceIP = p.Addr.String()
+			if host, _, err := net.SplitHostPort(entry.SourceIP); err == nil {
+				entry.SourceIP = host
+			}
+		}
+
+		raw, _ := json.Marshal(req)
+		entry.Request = auditJSON(raw)
+
+		state := auditState(ds, entry.Target)
+		unlock, err := lockTarget(audit, entry.Target)
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, "AUDIT_UNAVAILABLE: request was not executed")
+		}
+		defer unlock()
+
+		dbSem <- struct{}{}
+		entry.Before = state()
+		err = appendStarted(audit, entry)
+		<-dbSem
+
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, "AUDIT_UNAVAILABLE: request was not executed")
+		}
+
+		resp, err := handler(ctx, req)
+
+		entry.Status = int(status.Code(err))
+
+		dbSem <- struct{}{}
+		defer func() { <-dbSem }()
+
+		entry.After = state()
+		appendFinished(audit, entry)
+
+		return resp, err
+	}
+}
+
+func (s grpcServer) balance(uuid string) (*walletpb.BalanceResponse, error) {
+	got, sum, err := s.storage.Get(uuid)
+
+	if err != nil {
+		log.Println("error get request:", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if !got {
+		log.Println("uuid undefined")
+		return nil, status.Error(codes.InvalidArgument, "uuid undefined")
+	}
+
+	return &walletpb.BalanceResponse{WalletId: uuid, Balance: math.Floor(sum*100) / 100}, nil
+}
+
+func (s grpcServer) GetBalance(ctx context.Context, req *walletpb.GetBalanceRequest) (*walletpb.BalanceResponse, error) {
+	log.Println("uuid:", req.WalletId)
+	return s.balance(req.WalletId)
+}
+
+func (s grpcServer) CreateWallet(ctx context.Context, req *walletpb.CreateWalletRequest) (*walletpb.CreateWalletResponse, error) {
+	log.Println("uuid:", req.WalletId)
+	check, err := s.storage.Check(req.WalletId)
+
+	if err != nil {
+		log.Println("error in check method")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if check {
+		log.Println("UUID is actually exist:", req.WalletId)
+		return nil, status.Error(codes.AlreadyExists, "UUID is actually exist")
+	}
+
+	err = s.storage.CreateWallet(req.WalletId, datastorage.WalletOptions{Owner: req.Owner})
+
+	if err != nil {
+		log.Println("error in create method: ", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	log.Println("Wallet created")
+	return &walletpb.CreateWalletResponse{WalletId: req.WalletId}, nil
+}
+
+func (s grpcServer) Deposit(ctx context.Context, req *walletpb.ChangeBalanceRequest) (*walletpb.BalanceResponse, error) {
+	return s.changeBalance(req, 1)
+}
+
+func (s grpcServer) Withdraw(ctx context.Context, req *walletpb.ChangeBalanceRequest) (*walletpb.BalanceResponse, error) {
+	return s.changeBalance(req, -1)
+}
+
+// changeBalance проводит пополнение (sign = 1) или списание (sign = -1) и возвращает новый баланс
+// вместе с суммой операции, удержанной комиссией и суммой за её вычетом
+func (s grpcServer) changeBalance(req *walletpb.ChangeBalanceRequest, sign float64) (*walletpb.BalanceResponse, error) {
+	if req.Amount <= 0 {
+		log.Println("wrong amount:", req.Amount)
+		return nil, status.Error(codes.InvalidArgument, "sum must be more 0")
+	}
+
+	check, err := s.storage.Check(req.WalletId)
+
+	if err != nil {
+		log.Println("wrong server behaviour")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if !check {
+		log.Println("wrong uuid:", req.WalletId)
+		return nil, status.Error(codes.InvalidArgument, "UUID is wrong")
+	}
+
+	details := datastorage.OperationDetails{Reference: req.Reference, Description: req.Description, ExternalId: req.ExternalId}
+
+	if reason := validDetails(details); reason != "" {
+		log.Println("wrong operation details:", reason)
+		return nil, status.Error(codes.InvalidArgument, reason)
+	}
+
+	amount := math.Floor(req.Amount*100) / 100
+
+	changed, receipt, err := s.storage.ChangeBalance(sign*amount, req.WalletId, details)
+
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	if !changed {
+		return nil, grpcError(datastorage.InsufficientFunds{})
+	}
+
+	log.Println("Operation complit")
+
+	response, err := s.balance(req.WalletId)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Gross, response.Fee, response.Net = receipt.Gross, receipt.Fee, receipt.Net
+	return response, nil
+}
+
+func (s grpcServer) WatchBalance(req *walletpb.WatchBalanceRequest, stream grpc.ServerStreamingServer[walletpb.BalanceResponse]) error {
+	// подписываемся до чтения баланса, чтобы не потерять изменения между ними
+	updates, unsubscribe := s.broadcaster.Subscribe(req.WalletId)
+	defer unsubscribe()
+
+	current, err := s.balance(req.WalletId)
+	if err != nil {
+		return err
+	}
+
+	if err = stream.Send(current); err != nil {
+		return err
+	}
+
+	log.Println("balance watch started:", req.WalletId)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Println("balance watch closed:", req.WalletId)
+			return nil
+
+		case event, ok := <-updates:
+			if !ok {
+				// клиент не успевал читать изменения, продолжать поток с пропусками нельзя
+				log.Println("balance watch dropped:", req.WalletId)
+				return status.Error(codes.Unavailable, "balance watch fell behind, watch again")
+			}
+
+			err = stream.Send(&walletpb.BalanceResponse{
+				WalletId: event.WalletId,
+				Balance:  math.Floor(event.Balance*100) / 100,
+				EventId:  event.Id,
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// startGRPC запускает gRPC API на отдельном порту
+func (server *Server) startGRPC(broadcaster *events.Broadcaster, audit AuditStorage) {
+	lis, err := net.Listen("tcp", server.GRPCPort)
+
+	if err != nil {
+		fmt.Println("Error starting the grpc server:", err)
+		return
+	}
+
+	fmt.Println("Starting grpc server at port", server.GRPCPort)
+	err = newGRPCServer(server.storage, broadcaster, audit, server.AdminToken).Serve(lis)
+	if err != nil {
+		fmt.Println("Error starting the grpc server:", err)
+	}
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+	"walletGolang/events"
+	"walletGolang/walletpb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newGRPCClient(t *testing.T, ds WalletStorage, broadcaster *events.Broadcaster) walletpb.WalletServiceClient {
+	return dialGRPC(t, newGRPCServer(ds, broadcaster, nil, ""))
+}
+
+func dialGRPC(t *testing.T, srv *grpc.Server) walletpb.WalletServiceClient {
+	lis := bufconn.Listen(1024 * 1024)
+
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return walletpb.NewWalletServiceClient(conn)
+}
+
+func TestGRPCGetBalance(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Get("asd1").Return(true, 100.129, nil).Once()
+	ds.EXPECT().Get("asd2").Return(false, 0, nil).Once()
+
+	client := newGRPCClient(t, ds, events.NewBroadcaster(0))
+
+	res, err := client.GetBalance(context.Background(), &walletpb.GetBalanceRequest{WalletId: "asd1"})
+	require.NoError(t, err)
+	assert.Equal(t, 100.12, res.Balance)
+
+	_, err = client.GetBalance(context.Background(), &walletpb.GetBalanceRequest{WalletId: "asd2"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCCreateWallet(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Check("asd1").Return(false, nil).Once()
+	ds.EXPECT().CreateWallet("asd1", datastorage.WalletOptions{Owner: "ivan"}).Return(nil).Once()
+	ds.EXPECT().Check("asd2").Return(true, nil).Once()
+
+	client := newGRPCClient(t, ds, events.NewBroadcaster(0))
+
+	res, err := client.CreateWallet(context.Background(), &walletpb.CreateWalletRequest{WalletId: "asd1", Owner: "ivan"})
+	require.NoError(t, err)
+	assert.Equal(t, "asd1", res.WalletId)
+
+	_, err = client.CreateWallet(context.Background(), &walletpb.CreateWalletRequest{WalletId: "asd2"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestGRPCDeposit(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Check("asd1").Return(true, nil).Once()
+	ds.EXPECT().ChangeBalance(100.12, "asd1", datastorage.OperationDetails{Reference: "order-7", ExternalId: "pay-7"}).Return(true, datastorage.Receipt{}, nil).Once()
+	ds.EXPECT().Get("asd1").Return(true, 150.12, nil).Once()
+
+	client := newGRPCClient(t, ds, events.NewBroadcaster(0))
+
+	res, err := client.Deposit(context.Background(), &walletpb.ChangeBalanceRequest{WalletId: "asd1", Amount: 100.129, Reference: "order-7", ExternalId: "pay-7"})
+	require.NoError(t, err)
+	assert.Equal(t, 150.12, res.Balance)
+}
+
+func TestGRPCWrongWithdraw(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Check("asd1").Return(true, nil)
+	ds.EXPECT().Check("asd2").Return(false, nil).Once()
+	ds.EXPECT().ChangeBalance(float64(-100), "asd1", datastorage.OperationDetails{}).Return(false, datastorage.Receipt{}, nil).Once()
+	ds.EXPECT().ChangeBalance(float64(-200), "asd1", datastorage.OperationDetails{}).Return(false, datastorage.Receipt{}, datastorage.LimitExceeded{Limit: datastorage.LimitDailyWithdrawal, Value: 150}).Once()
+	ds.EXPECT().ChangeBalance(float64(-300), "asd1", datastorage.OperationDetails{}).Return(false, datastorage.Receipt{}, datastorage.WalletNotActive{Status: datastorage.StatusFrozen}).Once()
+	ds.EXPECT().ChangeBalance(float64(-400), "asd1", datastorage.OperationDetails{}).Return(false, datastorage.Receipt{}, datastorage.DBError{}).Once()
+
+	client := newGRPCClient(t, ds, events.NewBroadcaster(0))
+
+	cases := []struct {
+		walletId string
+		amount   float64
+		code     codes.Code
+		message  string
+	}{
+		{"asd1", 0, codes.InvalidArgument, "sum must be more 0"},
+		{"asd2", 100, codes.InvalidArgument, "UUID is wrong"},
+		{"asd1", 100, codes.FailedPrecondition, "INSUFFICIENT_FUNDS: " + datastorage.InsufficientFunds{}.Error()},
+		{"asd1", 200, codes.ResourceExhausted, "LIMIT_EXCEEDED: daily_withdrawal limit 150 exceeded"},
+		{"asd1", 300, codes.FailedPrecondition, "WALLET_NOT_ACTIVE: wallet is frozen"},
+		{"asd1", 400, codes.Internal, datastorage.DBError{}.Error()},
+	}
+
+	for _, c := range cases {
+		_, err := client.Withdraw(context.Background(), &walletpb.ChangeBalanceRequest{WalletId: c.walletId, Amount: c.amount})
+
+		s, _ := status.FromError(err)
+		assert.Equal(t, c.code, s.Code())
+		assert.Equal(t, c.message, s.Message())
+	}
+}
+
+func TestGRPCWatchBalance(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Get("asd1").Return(true, 100, nil).Once()
+
+	broadcaster := events.NewBroadcaster(0)
+	client := newGRPCClient(t, ds, broadcaster)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.WatchBalance(ctx, &walletpb.WatchBalanceRequest{WalletId: "asd1"})
+	require.NoError(t, err)
+
+	// текущий баланс приходит после подписки, поэтому следующее событие не потеряется
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, float64(100), res.Balance)
+
+	broadcaster.Publish(datastorage.Event{Id: 3, WalletId: "asd1", Type: datastorage.EventDeposited, Amount: 50, Balance: 150})
+
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, float64(150), res.Balance)
+	assert.Equal(t, int64(3), res.EventId)
+}
+
+func TestGRPCWatchBalanceFellBehind(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Get("asd1").Return(true, 100, nil).Once()
+
+	broadcaster := events.NewBroadcaster(0)
+	client := newGRPCClient(t, ds, broadcaster)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.WatchBalance(ctx, &walletpb.WatchBalanceRequest{WalletId: "asd1"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	// клиент не читает, пока буферы не переполнятся
+	for i := 1; i <= 100000; i++ {
+		broadcaster.Publish(datastorage.Event{Id: int64(i), WalletId: "asd1", Balance: float64(i)})
+	}
+
+	// поток завершается ошибкой, а не продолжается с пропусками
+	var lastId int64
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			assert.Equal(t, codes.Unavailable, status.Code(err))
+			break
+		}
+		lastId++
+		require.Equal(t, lastId, res.EventId)
+	}
+}
+
+func TestGRPCErrorCodes(t *testing.T) {
+	errs := []error{
+		datastorage.LimitExceeded{Limit: "daily", Value: 10},
+		datastorage.WalletNotActive{Status: datastorage.StatusFrozen},
+		datastorage.InsufficientFunds{},
+		datastorage.UUIDUndefined{},
+		datastorage.WrongOperation{OperationType: "X"},
+		datastorage.DuplicateExternalId{ExternalId: "ext"},
+		datastorage.OperationUndefined{},
+		datastorage.AlreadyReversed{},
+		datastorage.FeeExceedsAmount{Fee: 1},
+		datastorage.ReversalExceeded{Remaining: 1},
+		datastorage.NotReversible{Reason: "transfer"},
+	}
+
+	for _, err := range errs {
+		code, _ := operationError(err)
+		assert.Contains(t, grpcCodes, code)
+
+		st, ok := status.FromError(grpcError(err))
+		require.True(t, ok, code)
+		assert.NotEqual(t, codes.OK, st.Code(), code)
+		assert.Equal(t, code+": "+err.Error(), st.Message())
+	}
+
+	// код ошибки без статуса gRPC не превращается в успешный ответ
+	delete(grpcCodes, "NOT_REVERSIBLE")
+	defer func() { grpcCodes["NOT_REVERSIBLE"] = codes.FailedPrecondition }()
+
+	assert.Equal(t, codes.FailedPrecondition, status.Code(grpcError(datastorage.NotReversible{})))
+	assert.Equal(t, codes.Internal, status.Code(grpcError(datastorage.DBError{})))
+}
+
+func TestGRPCAudit(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().GetWallet("asd1").Return(false, datastorage.Wallet{}, nil).Once()
+	ds.EXPECT().Check("asd1").Return(false, nil).Once()
+	ds.EXPECT().CreateWallet("asd1", datastorage.WalletOptions{}).Return(nil).Once()
+	ds.EXPECT().GetWallet("asd1").Return(true, datastorage.Wallet{Id: "asd1", Status: datastorage.StatusActive}, nil).Once()
+
+	var entries []datastorage.AuditEntry
+	audit := NewMockAuditStorage(t)
+	audit.EXPECT().AppendAudit(mock.Anything).
+		Run(func(entry datastorage.AuditEntry) { entries = append(entries, entry) }).
+		Return(datastorage.AuditEntry{Id: 1}, nil).
+		Twice()
+	audit.EXPECT().AppendAudit(mock.Anything).Return(datastorage.AuditEntry{}, datastorage.DBError{}).Once()
+	audit.EXPECT().LockAuditTarget(mock.Anything).Return(func() {}, nil).Twice()
+
+	client := dialGRPC(t, newGRPCServer(ds, events.NewBroadcaster(0), audit, "secret"))
+
+	// x-actor без токена администратора не записывается
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-actor", "ivan", "x-request-id", "req-1")
+
+	_, err := client.CreateWallet(ctx, &walletpb.CreateWalletRequest{WalletId: "asd1"})
+	require.NoError(t, err)
+
+	require.Len(t, entries, 2)
+	assert.Equal(t, 0, entries[0].Status)
+	assert.Equal(t, int(codes.OK), entries[1].Status)
+	assert.Equal(t, "req-1", entries[1].RequestId)
+	assert.Equal(t, "wallet:asd1", entries[1].Target)
+	assert.Empty(t, entries[1].Actor)
+	assert.Empty(t, entries[1].Subject)
+	assert.Contains(t, string(entries[1].After), `"status":"active"`)
+
+	// без записи о начале вызов не выполняется
+	ds.EXPECT().GetWallet("asd2").Return(false, datastorage.Wallet{}, nil).Once()
+
+	_, err = client.CreateWallet(context.Background(), &walletpb.CreateWalletRequest{WalletId: "asd2"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+const (
+	defaultHistoryPage = 100
+	maxHistoryPage     = 1000
+)
+
+// newWalletHistoryHandler отдаёт события кошелька по возрастанию id.
+// Параметр after - id последнего полученного события, limit - размер страницы.
+func newWalletHistoryHandler(ds WalletStorage, history EventHistory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		uuid, ok := walletPathId(r.URL.Path, "history") // проверяем, что запрос имеет вид /api/v1/wallets/{WALLET_UUID}/history
+		if !ok {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		var after int64
+		limit := defaultHistoryPage
+
+		if value := r.URL.Query().Get("after"); value != "" {
+			var err error
+			after, err = strconv.ParseInt(value, 10, 64)
+			if err != nil || after < 0 {
+				log.Println("wrong after:", value)
+				http.Error(w, "after must be event id", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if value := r.URL.Query().Get("limit"); value != "" {
+			var err error
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit <= 0 || limit > maxHistoryPage {
+				log.Println("wrong limit:", value)
+				http.Error(w, "limit must be from 1 to "+strconv.Itoa(maxHistoryPage), http.StatusBadRequest)
+				return
+			}
+		}
+
+		check, err := ds.Check(uuid)
+
+		if err != nil {
+			log.Println("error in check method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !check {
+			log.Println("uuid undefined")
+			http.Error(w, "uuid undefined", http.StatusBadRequest)
+			return
+		}
+
+		events, err := history.EventsAfter(uuid, after, limit)
+
+		if err != nil {
+			log.Println("error in events after method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if events == nil {
+			events = []datastorage.Event{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(events)
+	}
+}
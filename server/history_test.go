@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoodWalletHistory(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Check("asd1").Return(true, nil).Twice()
+
+	history := NewMockEventHistory(t)
+	history.EXPECT().
+		EventsAfter("asd1", int64(5), 2).
+		Return([]datastorage.Event{{Id: 6, WalletId: "asd1"}, {Id: 7, WalletId: "asd1"}}, nil).
+		Once()
+	history.EXPECT().EventsAfter("asd1", int64(0), defaultHistoryPage).Return(nil, nil).Once()
+
+	handler := newWalletHistoryHandler(ds, history)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/wallets/asd1/history?after=5&limit=2", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var events []datastorage.Event
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&events))
+	assert.Len(t, events, 2)
+	assert.Equal(t, int64(7), events[1].Id)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/wallets/asd1/history", nil))
+
+	assert.Equal(t, "[]\n", rec.Body.String())
+}
+
+func TestWrongWalletHistory(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().Check("asd2").Return(false, nil).Once()
+
+	handler := newWalletHistoryHandler(ds, NewMockEventHistory(t))
+
+	cases := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{http.MethodPost, "/api/v1/wallets/asd1/history", http.StatusMethodNotAllowed, "Invalid request method\n"},
+		{http.MethodGet, "/api/v1/wallets/asd1/history?after=x", http.StatusBadRequest, "after must be event id\n"},
+		{http.MethodGet, "/api/v1/wallets/asd1/history?limit=5000", http.StatusBadRequest, "limit must be from 1 to 1000\n"},
+		{http.MethodGet, "/api/v1/wallets/asd2/history", http.StatusBadRequest, "uuid undefined\n"},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+
+		assert.Equal(t, c.status, rec.Code, c.path)
+		assert.Equal(t, c.body, rec.Body.String(), c.path)
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+const (
+	idempotencyHeader    = "Idempotency-Key"
+	maxIdempotencyKey    = 255
+	maxIdempotentRequest = 1 << 20
+)
+
+type IdempotencyStorage interface {
+	ReserveIdempotencyKey(key, requestHash string) (bool, datastorage.IdempotentResponse, error)
+	SaveIdempotentResponse(key string, response datastorage.IdempotentResponse) error
+	ReleaseIdempotencyKey(key string) (bool, error)
+}
+
+// recordingWriter запоминает ответ обработчика, чтобы сохранить его для повторов
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// withIdempotency выполняет запрос с заголовком Idempotency-Key не больше одного раза:
+// повтор с тем же ключом получает сохранённый ответ первого запроса.
+// Ответы 5xx не сохраняются: ключ освобождается, и повтор выполняется заново,
+// если операция не была проведена (отметка ставится в её транзакции).
+// Если хранилище не поддерживает ключи (ds == nil), заголовок игнорируется.
+func withIdempotency(ds IdempotencyStorage, next http.HandlerFunc) http.HandlerFunc {
+	if ds == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKey {
+			log.Println("too long idempotency key")
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequest))
+		if err != nil {
+			log.Println("error reading body:", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "\n" + string(body)))
+		requestHash := hex.EncodeToString(hash[:])
+
+		reserved, saved, err := ds.ReserveIdempotencyKey(key, requestHash)
+
+		if err != nil {
+			log.Println("error in reserve idempotency key method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !reserved {
+			if saved.RequestHash != requestHash {
+				log.Println("idempotency key reused:", key)
+				http.Error(w, "IDEMPOTENCY_KEY_REUSED: key was used for another request", http.StatusUnprocessableEntity)
+				return
+			}
+
+			if saved.Status == 0 {
+				log.Println("idempotent request in progress:", key)
+				http.Error(w, "IDEMPOTENCY_IN_PROGRESS: request with this key is in progress", http.StatusConflict)
+				return
+			}
+
+			log.Println("idempotent replay:", key)
+			if saved.ContentType != "" {
+				w.Header().Set("Content-Type", saved.ContentType)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(saved.Status)
+			w.Write(saved.Body)
+			return
+		}
+
+		rec := &recordingWriter{ResponseWriter: w}
+		next(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		if rec.status >= http.StatusInternalServerError {
+			// временная ошибка не должна на сутки стать ответом на все повторы;
+			// ключ проведённой операции (например, ответ не дошёл после commit) не освобождается,
+			// чтобы повтор не провёл её второй раз
+			released, err := ds.ReleaseIdempotencyKey(key)
+			if err != nil {
+				log.Println("error in release idempotency key method:", err)
+			} else if !released {
+				log.Println("idempotent request applied but failed, key stays in progress:", key)
+			}
+			return
+		}
+
+		err = ds.SaveIdempotentResponse(key, datastorage.IdempotentResponse{
+			RequestHash: requestHash,
+			Status:      rec.status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		})
+
+		// ключ остаётся занятым без ответа, и повторы получат
+		// IDEMPOTENCY_IN_PROGRESS вместо второго проведения
+		if err != nil {
+			log.Println("error in save idempotent response method:", err)
+		}
+	}
+}
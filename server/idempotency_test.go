@@ -0,0 +1,221 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func idempotentRequest(key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/wallet", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotencyHeader, key)
+	}
+	return req
+}
+
+func TestIdempotencyFirstRequest(t *testing.T) {
+	ds := NewMockIdempotencyStorage(t)
+
+	var hash string
+	ds.EXPECT().
+		ReserveIdempotencyKey("key1", mock.Anything).
+		RunAndReturn(func(key, requestHash string) (bool, datastorage.IdempotentResponse, error) {
+			hash = requestHash
+			return true, datastorage.IdempotentResponse{RequestHash: requestHash}, nil
+		}).
+		Once()
+	ds.EXPECT().
+		SaveIdempotentResponse("key1", mock.MatchedBy(func(r datastorage.IdempotentResponse) bool {
+			return r.RequestHash == hash && r.Status == http.StatusOK && string(r.Body) == "Operation complit\n" &&
+				strings.HasPrefix(r.ContentType, "text/plain")
+		})).
+		Return(nil).
+		Once()
+
+	calls := 0
+	handler := withIdempotency(ds, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"amount":1}`, string(body))
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "Operation complit")
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, idempotentRequest("key1", `{"amount":1}`))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Operation complit\n", rec.Body.String())
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	ds := NewMockIdempotencyStorage(t)
+
+	var hash string
+	ds.EXPECT().
+		ReserveIdempotencyKey("key1", mock.Anything).
+		RunAndReturn(func(key, requestHash string) (bool, datastorage.IdempotentResponse, error) {
+			hash = requestHash
+			return true, datastorage.IdempotentResponse{RequestHash: requestHash}, nil
+		}).
+		Once()
+	ds.EXPECT().SaveIdempotentResponse("key1", mock.Anything).Return(nil).Once()
+
+	handler := withIdempotency(ds, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "INSUFFICIENT_FUNDS: insufficient funds", http.StatusBadRequest)
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key1", `{"amount":1}`))
+
+	ds.EXPECT().
+		ReserveIdempotencyKey("key1", mock.Anything).
+		RunAndReturn(func(key, requestHash string) (bool, datastorage.IdempotentResponse, error) {
+			assert.Equal(t, hash, requestHash)
+			return false, datastorage.IdempotentResponse{
+				RequestHash: hash,
+				Status:      http.StatusBadRequest,
+				ContentType: "text/plain; charset=utf-8",
+				Body:        []byte("INSUFFICIENT_FUNDS: insufficient funds\n"),
+			}, nil
+		}).
+		Once()
+
+	handler = withIdempotency(ds, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler must not be called on replay")
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, idempotentRequest("key1", `{"amount":1}`))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "INSUFFICIENT_FUNDS: insufficient funds\n", rec.Body.String())
+}
+
+func TestIdempotencyServerError(t *testing.T) {
+	ds := NewMockIdempotencyStorage(t)
+	ds.EXPECT().
+		ReserveIdempotencyKey("key1", mock.Anything).
+		RunAndReturn(func(key, requestHash string) (bool, datastorage.IdempotentResponse, error) {
+			return true, datastorage.IdempotentResponse{RequestHash: requestHash}, nil
+		}).
+		Twice()
+	// ответ 5xx не сохраняется, ключ освобождается для повтора
+	ds.EXPECT().ReleaseIdempotencyKey("key1").Return(true, nil).Once()
+	ds.EXPECT().SaveIdempotentResponse("key1", mock.Anything).Return(nil).Once()
+
+	calls := 0
+	handler := withIdempotency(ds, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, datastorage.DBError{}.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintln(w, "Operation complit")
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, idempotentRequest("key1", `{"amount":1}`))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, idempotentRequest("key1", `{"amount":1}`))
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotencyAppliedServerError(t *testing.T) {
+	ds := NewMockIdempotencyStorage(t)
+	ds.EXPECT().
+		ReserveIdempotencyKey("key1", mock.Anything).
+		Return(true, datastorage.IdempotentResponse{}, nil).
+		Once()
+	// операция проведена, но ответ - 5xx: ключ не освобождается, повтор получает IN_PROGRESS
+	ds.EXPECT().ReleaseIdempotencyKey("key1").Return(false, nil).Once()
+	ds.EXPECT().
+		ReserveIdempotencyKey("key1", mock.Anything).
+		RunAndReturn(func(key, requestHash string) (bool, datastorage.IdempotentResponse, error) {
+			return false, datastorage.IdempotentResponse{RequestHash: requestHash}, nil
+		}).
+		Once()
+
+	calls := 0
+	handler := withIdempotency(ds, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, datastorage.DBError{}.Error(), http.StatusInternalServerError)
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, idempotentRequest("key1", `{"amount":1}`))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, idempotentRequest("key1", `{"amount":1}`))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestWrongIdempotency(t *testing.T) {
+	ds := NewMockIdempotencyStorage(t)
+	ds.EXPECT().
+		ReserveIdempotencyKey("in-progress", mock.Anything).
+		RunAndReturn(func(key, requestHash string) (bool, datastorage.IdempotentResponse, error) {
+			return false, datastorage.IdempotentResponse{RequestHash: requestHash}, nil
+		}).
+		Once()
+	ds.EXPECT().
+		ReserveIdempotencyKey("reused", mock.Anything).
+		Return(false, datastorage.IdempotentResponse{RequestHash: "other", Status: http.StatusOK}, nil).
+		Once()
+	ds.EXPECT().
+		ReserveIdempotencyKey("db-error", mock.Anything).
+		Return(false, datastorage.IdempotentResponse{}, datastorage.DBError{}).
+		Once()
+
+	handler := withIdempotency(ds, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler must not be called")
+	})
+
+	cases := []struct {
+		key    string
+		status int
+		body   string
+	}{
+		{"in-progress", http.StatusConflict, "IDEMPOTENCY_IN_PROGRESS: request with this key is in progress\n"},
+		{"reused", http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED: key was used for another request\n"},
+		{"db-error", http.StatusInternalServerError, datastorage.DBError{}.Error() + "\n"},
+		{strings.Repeat("k", maxIdempotencyKey+1), http.StatusBadRequest, "Idempotency-Key is too long\n"},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest(c.key, `{"amount":1}`))
+
+		assert.Equal(t, c.status, rec.Code, c.key)
+		assert.Equal(t, c.body, rec.Body.String(), c.key)
+	}
+}
+
+func TestWithoutIdempotencyKey(t *testing.T) {
+	calls := 0
+	next := func(w http.ResponseWriter, r *http.Request) { calls++ }
+
+	// без заголовка и без поддержки в хранилище запрос проходит как есть
+	withIdempotency(NewMockIdempotencyStorage(t), next).ServeHTTP(httptest.NewRecorder(), idempotentRequest("", "{}"))
+	withIdempotency(nil, next).ServeHTTP(httptest.NewRecorder(), idempotentRequest("key1", "{}"))
+
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+type InterestStorage interface {
+	ListInterestRules() ([]datastorage.InterestRule, error)
+	SetInterestRule(rule datastorage.InterestRule) error
+	DeleteInterestRule(tier string) error
+}
+
+// newInterestRulesHandler обслуживает /api/v1/interest: GET отдаёт ставки тарифов,
+// PUT создаёт или заменяет ставку, DELETE удаляет ставку тарифа ?tier=
+func newInterestRulesHandler(ds InterestStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/interest" {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			rules, err := ds.ListInterestRules()
+
+			if err != nil {
+				log.Println("error in list interest rules method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if rules == nil {
+				rules = []datastorage.InterestRule{}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(rules)
+
+		case http.MethodPut:
+			var rule datastorage.InterestRule
+			if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+				log.Println("wrong json")
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if err := datastorage.ValidateInterestRule(rule); err != nil {
+				log.Println("wrong interest rule:", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			log.Println("set interest rule", rule.Tier, "by", adminActor(r))
+
+			err := ds.SetInterestRule(rule)
+
+			if errors.As(err, &datastorage.TierUndefined{}) {
+				log.Println("tier undefined:", rule.Tier)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if err != nil {
+				log.Println("error in set interest rule method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			log.Println("Interest rule updated")
+			fmt.Fprintln(w, "Interest rule updated")
+
+		case http.MethodDelete:
+			tier := r.URL.Query().Get("tier")
+
+			log.Println("delete interest rule", tier, "by", adminActor(r))
+
+			err := ds.DeleteInterestRule(tier)
+
+			if errors.As(err, &datastorage.InterestRuleUndefined{}) {
+				log.Println("interest rule undefined")
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			if err != nil {
+				log.Println("error in delete interest rule method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			log.Println("Interest rule deleted")
+			fmt.Fprintln(w, "Interest rule deleted")
+
+		default:
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoodInterestRules(t *testing.T) {
+	rule := datastorage.InterestRule{Tier: "savings", Rate: 4.5, DayCount: datastorage.DayCountActual365}
+
+	ds := NewMockInterestStorage(t)
+	ds.EXPECT().ListInterestRules().Return([]datastorage.InterestRule{rule}, nil).Once()
+	ds.EXPECT().SetInterestRule(rule).Return(nil).Once()
+	ds.EXPECT().DeleteInterestRule("savings").Return(nil).Once()
+
+	handler := newInterestRulesHandler(ds)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/interest", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"tier":"savings","rate":4.5,"dayCount":"ACT/365"}]`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/v1/interest",
+		strings.NewReader(`{"tier":"savings","rate":4.5,"dayCount":"ACT/365"}`)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Interest rule updated\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/interest?tier=savings", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Interest rule deleted\n", rec.Body.String())
+}
+
+func TestWrongInterestRules(t *testing.T) {
+	ds := NewMockInterestStorage(t)
+	ds.EXPECT().SetInterestRule(datastorage.InterestRule{Tier: "none", Rate: 1, DayCount: datastorage.DayCount30E360}).
+		Return(datastorage.TierUndefined{}).Once()
+	ds.EXPECT().DeleteInterestRule("gold").Return(datastorage.InterestRuleUndefined{}).Once()
+
+	handler := newInterestRulesHandler(ds)
+
+	tests := []struct {
+		method, target, body string
+		status               int
+	}{
+		{http.MethodPost, "/api/v1/interest", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/v1/interest/gold", "", http.StatusNotFound},
+		{http.MethodPut, "/api/v1/interest", "{", http.StatusBadRequest},
+		{http.MethodPut, "/api/v1/interest", `{"rate":1,"dayCount":"ACT/360"}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/v1/interest", `{"tier":"gold","rate":101,"dayCount":"ACT/360"}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/v1/interest", `{"tier":"gold","rate":1,"dayCount":"30/360"}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/v1/interest", `{"tier":"none","rate":1,"dayCount":"30E/360"}`, http.StatusBadRequest},
+		{http.MethodDelete, "/api/v1/interest?tier=gold", "", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+
+		assert.Equal(t, test.status, rec.Code, test.method+" "+test.target+" "+test.body)
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+type LedgerStorage interface {
+	TrialBalance() (datastorage.TrialBalance, error)
+}
+
+// newTrialBalanceHandler отдаёт оборотную ведомость журнала проводок
+func newTrialBalanceHandler(ds LedgerStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		log.Println("trial balance by", adminActor(r))
+
+		report, err := ds.TrialBalance()
+
+		if err != nil {
+			log.Println("error in trial balance method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if report.Accounts == nil {
+			report.Accounts = []datastorage.AccountBalance{}
+		}
+
+		if !report.Balanced {
+			log.Println("ledger is not balanced: debit", report.Debit, "credit", report.Credit)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
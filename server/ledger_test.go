@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoodTrialBalance(t *testing.T) {
+	ds := NewMockLedgerStorage(t)
+	ds.EXPECT().TrialBalance().Return(datastorage.TrialBalance{
+		Accounts: []datastorage.AccountBalance{
+			{Account: datastorage.LedgerCashIn, Kind: datastorage.AccountSystem, Accounts: 1, Debit: 150, Balance: -150},
+			{Account: "wallets", Kind: datastorage.AccountWallet, Accounts: 2, Credit: 150, Balance: 150},
+		},
+		Debit:    150,
+		Credit:   150,
+		Balanced: true,
+	}, nil).Once()
+
+	rec := httptest.NewRecorder()
+	newTrialBalanceHandler(ds).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/ledger/trial-balance", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"accounts":[
+		{"account":"system:cash_in","kind":"system","accounts":1,"debit":150,"credit":0,"balance":-150},
+		{"account":"wallets","kind":"wallet","accounts":2,"debit":0,"credit":150,"balance":150}
+	],"debit":150,"credit":150,"balanced":true}`, rec.Body.String())
+}
+
+func TestWrongTrialBalance(t *testing.T) {
+	ds := NewMockLedgerStorage(t)
+	ds.EXPECT().TrialBalance().Return(datastorage.TrialBalance{}, datastorage.DBError{}).Once()
+
+	handler := newTrialBalanceHandler(ds)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/ledger/trial-balance", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/ledger/trial-balance", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
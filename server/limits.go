@@ -0,0 +1,151 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+type LimitStorage interface {
+	GetLimits(uuid string) (bool, datastorage.Limits, error)
+	SetWalletLimits(uuid string, limits datastorage.Limits) error
+	SetTierLimits(tier string, limits datastorage.Limits) error
+}
+
+func validLimits(limits datastorage.Limits) bool {
+	for _, value := range []*float64{limits.MaxWithdrawal, limits.DailyWithdrawal, limits.MonthlyWithdrawal} {
+		if value != nil && *value <= 0 {
+			return false
+		}
+	}
+
+	return limits.OperationsPerMinute == nil || *limits.OperationsPerMinute > 0
+}
+
+func newWalletLimitsHandler(ds LimitStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		uuid, ok := walletPathId(r.URL.Path, "limits") // проверяем, что запрос имеет вид /api/v1/wallets/{WALLET_UUID}/limits
+		if !ok {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			log.Println("get limits:", uuid)
+
+			got, limits, err := ds.GetLimits(uuid)
+
+			if err != nil {
+				log.Println("error in get limits method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if !got {
+				log.Println("uuid undefined")
+				http.Error(w, "uuid undefined", http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(limits)
+
+		case http.MethodPut:
+			log.Println("set limits:", uuid)
+
+			var limits datastorage.Limits
+			err := json.NewDecoder(r.Body).Decode(&limits)
+			if err != nil {
+				log.Println("wrong json")
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if !validLimits(limits) {
+				log.Println("wrong limits:", limits)
+				http.Error(w, "limits must be more 0", http.StatusBadRequest)
+				return
+			}
+
+			err = ds.SetWalletLimits(uuid, limits)
+
+			if errors.As(err, &datastorage.UUIDUndefined{}) {
+				log.Println("uuid undefined")
+				http.Error(w, "uuid undefined", http.StatusBadRequest)
+				return
+			}
+
+			if errors.As(err, &datastorage.TierUndefined{}) {
+				log.Println("tier undefined:", limits.Tier)
+				http.Error(w, "tier undefined", http.StatusBadRequest)
+				return
+			}
+
+			if err != nil {
+				log.Println("error in set limits method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			log.Println("Limits updated")
+			fmt.Fprintln(w, "Limits updated")
+
+		default:
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func newTierLimitsHandler(ds LimitStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+		if len(parts) != 4 || r.URL.Path != "/api/v1/tiers/"+parts[3] { // проверяем, что запрос имеет вид /api/v1/tiers/{TIER}
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		tier := parts[3]
+
+		var limits datastorage.Limits
+		err := json.NewDecoder(r.Body).Decode(&limits)
+		if err != nil {
+			log.Println("wrong json")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !validLimits(limits) {
+			log.Println("wrong limits:", limits)
+			http.Error(w, "limits must be more 0", http.StatusBadRequest)
+			return
+		}
+
+		err = ds.SetTierLimits(tier, limits)
+
+		if err != nil {
+			log.Println("error in set tier limits method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		log.Println("Tier limits updated:", tier)
+		fmt.Fprintln(w, "Tier limits updated")
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoodGetLimits(t *testing.T) {
+	ds := NewMockLimitStorage(t)
+
+	uuid := "1"
+	daily := 1000.0
+
+	ds.EXPECT().
+		GetLimits(uuid).
+		Return(true, datastorage.Limits{Tier: "default", DailyWithdrawal: &daily}, nil).
+		Once()
+
+	handler := newWalletLimitsHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodGet,
+		"/api/v1/wallets/"+uuid+"/limits",
+		nil,
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t,
+		`{"tier":"default","maxWithdrawal":null,"dailyWithdrawal":1000,"monthlyWithdrawal":null,"operationsPerMinute":null}`,
+		rec.Body.String())
+}
+
+func TestGoodSetWalletLimits(t *testing.T) {
+	ds := NewMockLimitStorage(t)
+
+	uuid := "1"
+	max := 100.0
+	perMinute := 10
+
+	ds.EXPECT().
+		SetWalletLimits(uuid, datastorage.Limits{MaxWithdrawal: &max, OperationsPerMinute: &perMinute}).
+		Return(nil).
+		Once()
+
+	handler := newWalletLimitsHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/api/v1/wallets/"+uuid+"/limits",
+		strings.NewReader(`{"maxWithdrawal":100,"operationsPerMinute":10}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Limits updated\n", rec.Body.String())
+}
+
+func TestWrongValueSetWalletLimits(t *testing.T) {
+	ds := NewMockLimitStorage(t)
+
+	handler := newWalletLimitsHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/api/v1/wallets/1/limits",
+		strings.NewReader(`{"dailyWithdrawal":-5}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "limits must be more 0\n", rec.Body.String())
+}
+
+func TestUUIDUndefinedSetWalletLimits(t *testing.T) {
+	ds := NewMockLimitStorage(t)
+
+	uuid := "1"
+
+	ds.EXPECT().
+		SetWalletLimits(uuid, datastorage.Limits{Tier: "gold"}).
+		Return(datastorage.UUIDUndefined{}).
+		Once()
+
+	handler := newWalletLimitsHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/api/v1/wallets/"+uuid+"/limits",
+		strings.NewReader(`{"tier":"gold"}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "uuid undefined\n", rec.Body.String())
+}
+
+func TestGoodSetTierLimits(t *testing.T) {
+	ds := NewMockLimitStorage(t)
+
+	monthly := 5000.0
+
+	ds.EXPECT().
+		SetTierLimits("gold", datastorage.Limits{MonthlyWithdrawal: &monthly}).
+		Return(nil).
+		Once()
+
+	handler := newTierLimitsHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/api/v1/tiers/gold",
+		strings.NewReader(`{"monthlyWithdrawal":5000}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Tier limits updated\n", rec.Body.String())
+}
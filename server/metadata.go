@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+type updateWalletMessage struct {
+	Metadata map[string]*string `json:"metadata"`
+}
+
+// newWalletHandler обслуживает /api/v1/wallets/{WALLET_UUID}: GET отдаёт баланс, PATCH меняет метаданные
+func newWalletHandler(ds WalletStorage) http.HandlerFunc {
+	get := newGetBalanceHandler(ds)
+	patch := newUpdateMetadataHandler(ds)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			patch(w, r)
+			return
+		}
+		get(w, r)
+	}
+}
+
+// newUpdateMetadataHandler меняет метаданные кошелька: ключ со строкой задаётся,
+// ключ с null удаляется, остальные ключи не меняются
+func newUpdateMetadataHandler(ds WalletStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		log.Println("update wallet request '", r.URL.Path, "'")
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+		if len(parts) != 4 || r.URL.Path != "/api/v1/wallets/"+parts[3] { // проверяем, что запрос имеет вид /api/v1/wallets/{WALLET_UUID}
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		uuid := parts[3]
+
+		var msg updateWalletMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			log.Println("wrong json")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if msg.Metadata == nil {
+			log.Println("no metadata in update")
+			http.Error(w, "metadata is required", http.StatusBadRequest)
+			return
+		}
+
+		for key := range msg.Metadata {
+			if err := datastorage.ValidateMetadataKey(key); err != nil {
+				log.Println("wrong metadata:", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		wallet, err := ds.UpdateMetadata(uuid, msg.Metadata)
+
+		if errors.As(err, &datastorage.UUIDUndefined{}) {
+			log.Println("uuid undefined")
+			http.Error(w, "uuid undefined", http.StatusBadRequest)
+			return
+		}
+
+		if errors.As(err, &datastorage.WrongMetadata{}) {
+			log.Println("wrong metadata:", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			log.Println("error in update metadata method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		log.Println("Metadata updated")
+		encodeWallet(w, wallet)
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoodUpdateMetadata(t *testing.T) {
+	campaign := "spring"
+
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().
+		UpdateMetadata("asd1", map[string]*string{"campaign": &campaign, "tag": nil}).
+		Return(datastorage.Wallet{Id: "asd1", Balance: 10.129, Metadata: map[string]string{"campaign": "spring"}}, nil).
+		Once()
+
+	handler := newWalletHandler(ds)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/api/v1/wallets/asd1",
+		strings.NewReader(`{"metadata":{"campaign":"spring","tag":null}}`)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t,
+		`{"walletId":"asd1","balance":10.12,"creditLimit":0,"availableCredit":0,"status":"","tier":"","createdAt":"0001-01-01T00:00:00Z","metadata":{"campaign":"spring"},"version":0}`,
+		rec.Body.String())
+}
+
+func TestWrongUpdateMetadata(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().UpdateMetadata("asd2", map[string]*string{}).Return(datastorage.Wallet{}, datastorage.UUIDUndefined{}).Once()
+	ds.EXPECT().
+		UpdateMetadata("asd3", map[string]*string{"a": nil}).
+		Return(datastorage.Wallet{}, datastorage.WrongMetadata{Reason: "more than 50 keys"}).
+		Once()
+
+	handler := newWalletHandler(ds)
+
+	cases := []struct {
+		path, body string
+		status     int
+		response   string
+	}{
+		{"/api/v1/wallets/asd1/x", `{"metadata":{}}`, http.StatusNotFound, "404 page not found\n"},
+		{"/api/v1/wallets/asd1", `{`, http.StatusBadRequest, "unexpected EOF\n"},
+		{"/api/v1/wallets/asd1", `{}`, http.StatusBadRequest, "metadata is required\n"},
+		{"/api/v1/wallets/asd1", `{"metadata":{"a b":"c"}}`, http.StatusBadRequest,
+			"wrong metadata: key \"a b\" must be 1 to 40 letters, digits, _ or -\n"},
+		{"/api/v1/wallets/asd2", `{"metadata":{}}`, http.StatusBadRequest, "uuid undefined\n"},
+		{"/api/v1/wallets/asd3", `{"metadata":{"a":null}}`, http.StatusBadRequest, "wrong metadata: more than 50 keys\n"},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, c.path, strings.NewReader(c.body)))
+
+		assert.Equal(t, c.status, rec.Code, c.body)
+		assert.Equal(t, c.response, rec.Body.String(), c.body)
+	}
+}
+
+func TestWrongMetadataCreateWallet(t *testing.T) {
+	handler := newCreateWalletHandler(NewMockWalletStorage(t))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/wallets/wallet/create",
+		strings.NewReader(`{"walletId":"asd1","metadata":{"campaign":"`+strings.Repeat("a", 501)+`"}}`)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "wrong metadata: value of \"campaign\" is longer than 500 characters\n", rec.Body.String())
+}
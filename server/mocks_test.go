@@ -5,75 +5,2896 @@
 package server
 
 import (
+	context "context"
+	time "time"
+
 	mock "github.com/stretchr/testify/mock"
+	datastorage "walletGolang/dataStorage"
 )
 
-// NewMockWalletStorage creates a new instance of MockWalletStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// NewMockAuditStorage creates a new instance of MockAuditStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
-func NewMockWalletStorage(t interface {
+func NewMockAuditStorage(t interface {
 	mock.TestingT
 	Cleanup(func())
-}) *MockWalletStorage {
-	mock := &MockWalletStorage{}
+}) *MockAuditStorage {
+	mock := &MockAuditStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockAuditStorage is an autogenerated mock type for the AuditStorage type
+type MockAuditStorage struct {
+	mock.Mock
+}
+
+type MockAuditStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockAuditStorage) EXPECT() *MockAuditStorage_Expecter {
+	return &MockAuditStorage_Expecter{mock: &_m.Mock}
+}
+
+// AppendAudit provides a mock function for the type MockAuditStorage
+func (_mock *MockAuditStorage) AppendAudit(entry datastorage.AuditEntry) (datastorage.AuditEntry, error) {
+	ret := _mock.Called(entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AppendAudit")
+	}
+
+	var r0 datastorage.AuditEntry
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(datastorage.AuditEntry) (datastorage.AuditEntry, error)); ok {
+		return returnFunc(entry)
+	}
+	if returnFunc, ok := ret.Get(0).(func(datastorage.AuditEntry) datastorage.AuditEntry); ok {
+		r0 = returnFunc(entry)
+	} else {
+		r0 = ret.Get(0).(datastorage.AuditEntry)
+	}
+	if returnFunc, ok := ret.Get(1).(func(datastorage.AuditEntry) error); ok {
+		r1 = returnFunc(entry)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockAuditStorage_AppendAudit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AppendAudit'
+type MockAuditStorage_AppendAudit_Call struct {
+	*mock.Call
+}
+
+// AppendAudit is a helper method to define mock.On call
+//   - entry datastorage.AuditEntry
+func (_e *MockAuditStorage_Expecter) AppendAudit(entry interface{}) *MockAuditStorage_AppendAudit_Call {
+	return &MockAuditStorage_AppendAudit_Call{Call: _e.mock.On("AppendAudit", entry)}
+}
+
+func (_c *MockAuditStorage_AppendAudit_Call) Run(run func(entry datastorage.AuditEntry)) *MockAuditStorage_AppendAudit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 datastorage.AuditEntry
+		if args[0] != nil {
+			arg0 = args[0].(datastorage.AuditEntry)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockAuditStorage_AppendAudit_Call) Return(auditEntry datastorage.AuditEntry, err error) *MockAuditStorage_AppendAudit_Call {
+	_c.Call.Return(auditEntry, err)
+	return _c
+}
+
+func (_c *MockAuditStorage_AppendAudit_Call) RunAndReturn(run func(entry datastorage.AuditEntry) (datastorage.AuditEntry, error)) *MockAuditStorage_AppendAudit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAudit provides a mock function for the type MockAuditStorage
+func (_mock *MockAuditStorage) ListAudit(filter datastorage.AuditFilter) ([]datastorage.AuditEntry, error) {
+	ret := _mock.Called(filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAudit")
+	}
+
+	var r0 []datastorage.AuditEntry
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(datastorage.AuditFilter) ([]datastorage.AuditEntry, error)); ok {
+		return returnFunc(filter)
+	}
+	if returnFunc, ok := ret.Get(0).(func(datastorage.AuditFilter) []datastorage.AuditEntry); ok {
+		r0 = returnFunc(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]datastorage.AuditEntry)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(datastorage.AuditFilter) error); ok {
+		r1 = returnFunc(filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockAuditStorage_ListAudit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAudit'
+type MockAuditStorage_ListAudit_Call struct {
+	*mock.Call
+}
+
+// ListAudit is a helper method to define mock.On call
+//   - filter datastorage.AuditFilter
+func (_e *MockAuditStorage_Expecter) ListAudit(filter interface{}) *MockAuditStorage_ListAudit_Call {
+	return &MockAuditStorage_ListAudit_Call{Call: _e.mock.On("ListAudit", filter)}
+}
+
+func (_c *MockAuditStorage_ListAudit_Call) Run(run func(filter datastorage.AuditFilter)) *MockAuditStorage_ListAudit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 datastorage.AuditFilter
+		if args[0] != nil {
+			arg0 = args[0].(datastorage.AuditFilter)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockAuditStorage_ListAudit_Call) Return(auditEntrys []datastorage.AuditEntry, err error) *MockAuditStorage_ListAudit_Call {
+	_c.Call.Return(auditEntrys, err)
+	return _c
+}
+
+func (_c *MockAuditStorage_ListAudit_Call) RunAndReturn(run func(filter datastorage.AuditFilter) ([]datastorage.AuditEntry, error)) *MockAuditStorage_ListAudit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LockAuditTarget provides a mock function for the type MockAuditStorage
+func (_mock *MockAuditStorage) LockAuditTarget(target string) (func(), error) {
+	ret := _mock.Called(target)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockAuditTarget")
+	}
+
+	var r0 func()
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (func(), error)); ok {
+		return returnFunc(target)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) func()); ok {
+		r0 = returnFunc(target)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(func())
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(target)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockAuditStorage_LockAuditTarget_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LockAuditTarget'
+type MockAuditStorage_LockAuditTarget_Call struct {
+	*mock.Call
+}
+
+// LockAuditTarget is a helper method to define mock.On call
+//   - target string
+func (_e *MockAuditStorage_Expecter) LockAuditTarget(target interface{}) *MockAuditStorage_LockAuditTarget_Call {
+	return &MockAuditStorage_LockAuditTarget_Call{Call: _e.mock.On("LockAuditTarget", target)}
+}
+
+func (_c *MockAuditStorage_LockAuditTarget_Call) Run(run func(target string)) *MockAuditStorage_LockAuditTarget_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockAuditStorage_LockAuditTarget_Call) Return(fn func(), err error) *MockAuditStorage_LockAuditTarget_Call {
+	_c.Call.Return(fn, err)
+	return _c
+}
+
+func (_c *MockAuditStorage_LockAuditTarget_Call) RunAndReturn(run func(target string) (func(), error)) *MockAuditStorage_LockAuditTarget_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// VerifyAudit provides a mock function for the type MockAuditStorage
+func (_mock *MockAuditStorage) VerifyAudit() (datastorage.AuditVerification, error) {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyAudit")
+	}
+
+	var r0 datastorage.AuditVerification
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func() (datastorage.AuditVerification, error)); ok {
+		return returnFunc()
+	}
+	if returnFunc, ok := ret.Get(0).(func() datastorage.AuditVerification); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(datastorage.AuditVerification)
+	}
+	if returnFunc, ok := ret.Get(1).(func() error); ok {
+		r1 = returnFunc()
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockAuditStorage_VerifyAudit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'VerifyAudit'
+type MockAuditStorage_VerifyAudit_Call struct {
+	*mock.Call
+}
+
+// VerifyAudit is a helper method to define mock.On call
+func (_e *MockAuditStorage_Expecter) VerifyAudit() *MockAuditStorage_VerifyAudit_Call {
+	return &MockAuditStorage_VerifyAudit_Call{Call: _e.mock.On("VerifyAudit")}
+}
+
+func (_c *MockAuditStorage_VerifyAudit_Call) Run(run func()) *MockAuditStorage_VerifyAudit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockAuditStorage_VerifyAudit_Call) Return(auditVerification datastorage.AuditVerification, err error) *MockAuditStorage_VerifyAudit_Call {
+	_c.Call.Return(auditVerification, err)
+	return _c
+}
+
+func (_c *MockAuditStorage_VerifyAudit_Call) RunAndReturn(run func() (datastorage.AuditVerification, error)) *MockAuditStorage_VerifyAudit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockBalanceHistoryStorage creates a new instance of MockBalanceHistoryStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockBalanceHistoryStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockBalanceHistoryStorage {
+	mock := &MockBalanceHistoryStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockBalanceHistoryStorage is an autogenerated mock type for the BalanceHistoryStorage type
+type MockBalanceHistoryStorage struct {
+	mock.Mock
+}
+
+type MockBalanceHistoryStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockBalanceHistoryStorage) EXPECT() *MockBalanceHistoryStorage_Expecter {
+	return &MockBalanceHistoryStorage_Expecter{mock: &_m.Mock}
+}
+
+// BalancesAt provides a mock function for the type MockBalanceHistoryStorage
+func (_mock *MockBalanceHistoryStorage) BalancesAt(uuids []string, at time.Time) ([]datastorage.WalletBalance, error) {
+	ret := _mock.Called(uuids, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BalancesAt")
+	}
+
+	var r0 []datastorage.WalletBalance
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func([]string, time.Time) ([]datastorage.WalletBalance, error)); ok {
+		return returnFunc(uuids, at)
+	}
+	if returnFunc, ok := ret.Get(0).(func([]string, time.Time) []datastorage.WalletBalance); ok {
+		r0 = returnFunc(uuids, at)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]datastorage.WalletBalance)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func([]string, time.Time) error); ok {
+		r1 = returnFunc(uuids, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockBalanceHistoryStorage_BalancesAt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BalancesAt'
+type MockBalanceHistoryStorage_BalancesAt_Call struct {
+	*mock.Call
+}
+
+// BalancesAt is a helper method to define mock.On call
+//   - uuids []string
+//   - at time.Time
+func (_e *MockBalanceHistoryStorage_Expecter) BalancesAt(uuids interface{}, at interface{}) *MockBalanceHistoryStorage_BalancesAt_Call {
+	return &MockBalanceHistoryStorage_BalancesAt_Call{Call: _e.mock.On("BalancesAt", uuids, at)}
+}
+
+func (_c *MockBalanceHistoryStorage_BalancesAt_Call) Run(run func(uuids []string, at time.Time)) *MockBalanceHistoryStorage_BalancesAt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 []string
+		if args[0] != nil {
+			arg0 = args[0].([]string)
+		}
+		var arg1 time.Time
+		if args[1] != nil {
+			arg1 = args[1].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockBalanceHistoryStorage_BalancesAt_Call) Return(walletBalances []datastorage.WalletBalance, err error) *MockBalanceHistoryStorage_BalancesAt_Call {
+	_c.Call.Return(walletBalances, err)
+	return _c
+}
+
+func (_c *MockBalanceHistoryStorage_BalancesAt_Call) RunAndReturn(run func(uuids []string, at time.Time) ([]datastorage.WalletBalance, error)) *MockBalanceHistoryStorage_BalancesAt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockBatchStorage creates a new instance of MockBatchStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockBatchStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockBatchStorage {
+	mock := &MockBatchStorage{}
 	mock.Mock.Test(t)
 
-	t.Cleanup(func() { mock.AssertExpectations(t) })
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockBatchStorage is an autogenerated mock type for the BatchStorage type
+type MockBatchStorage struct {
+	mock.Mock
+}
+
+type MockBatchStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockBatchStorage) EXPECT() *MockBatchStorage_Expecter {
+	return &MockBatchStorage_Expecter{mock: &_m.Mock}
+}
+
+// Batch provides a mock function for the type MockBatchStorage
+func (_mock *MockBatchStorage) Batch(ops []datastorage.Operation, atomic bool) ([]error, error) {
+	ret := _mock.Called(ops, atomic)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Batch")
+	}
+
+	var r0 []error
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func([]datastorage.Operation, bool) ([]error, error)); ok {
+		return returnFunc(ops, atomic)
+	}
+	if returnFunc, ok := ret.Get(0).(func([]datastorage.Operation, bool) []error); ok {
+		r0 = returnFunc(ops, atomic)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]error)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func([]datastorage.Operation, bool) error); ok {
+		r1 = returnFunc(ops, atomic)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockBatchStorage_Batch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Batch'
+type MockBatchStorage_Batch_Call struct {
+	*mock.Call
+}
+
+// Batch is a helper method to define mock.On call
+//   - ops []datastorage.Operation
+//   - atomic bool
+func (_e *MockBatchStorage_Expecter) Batch(ops interface{}, atomic interface{}) *MockBatchStorage_Batch_Call {
+	return &MockBatchStorage_Batch_Call{Call: _e.mock.On("Batch", ops, atomic)}
+}
+
+func (_c *MockBatchStorage_Batch_Call) Run(run func(ops []datastorage.Operation, atomic bool)) *MockBatchStorage_Batch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 []datastorage.Operation
+		if args[0] != nil {
+			arg0 = args[0].([]datastorage.Operation)
+		}
+		var arg1 bool
+		if args[1] != nil {
+			arg1 = args[1].(bool)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockBatchStorage_Batch_Call) Return(errs []error, err error) *MockBatchStorage_Batch_Call {
+	_c.Call.Return(errs, err)
+	return _c
+}
+
+func (_c *MockBatchStorage_Batch_Call) RunAndReturn(run func(ops []datastorage.Operation, atomic bool) ([]error, error)) *MockBatchStorage_Batch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockBulkStorage creates a new instance of MockBulkStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockBulkStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockBulkStorage {
+	mock := &MockBulkStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockBulkStorage is an autogenerated mock type for the BulkStorage type
+type MockBulkStorage struct {
+	mock.Mock
+}
+
+type MockBulkStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockBulkStorage) EXPECT() *MockBulkStorage_Expecter {
+	return &MockBulkStorage_Expecter{mock: &_m.Mock}
+}
+
+// ExportWallets provides a mock function for the type MockBulkStorage
+func (_mock *MockBulkStorage) ExportWallets(each func(record datastorage.WalletRecord) error) error {
+	ret := _mock.Called(each)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportWallets")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(func(record datastorage.WalletRecord) error) error); ok {
+		r0 = returnFunc(each)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockBulkStorage_ExportWallets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExportWallets'
+type MockBulkStorage_ExportWallets_Call struct {
+	*mock.Call
+}
+
+// ExportWallets is a helper method to define mock.On call
+//   - each func(record datastorage.WalletRecord) error
+func (_e *MockBulkStorage_Expecter) ExportWallets(each interface{}) *MockBulkStorage_ExportWallets_Call {
+	return &MockBulkStorage_ExportWallets_Call{Call: _e.mock.On("ExportWallets", each)}
+}
+
+func (_c *MockBulkStorage_ExportWallets_Call) Run(run func(each func(record datastorage.WalletRecord) error)) *MockBulkStorage_ExportWallets_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 func(record datastorage.WalletRecord) error
+		if args[0] != nil {
+			arg0 = args[0].(func(record datastorage.WalletRecord) error)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockBulkStorage_ExportWallets_Call) Return(err error) *MockBulkStorage_ExportWallets_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockBulkStorage_ExportWallets_Call) RunAndReturn(run func(each func(record datastorage.WalletRecord) error) error) *MockBulkStorage_ExportWallets_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ImportWallets provides a mock function for the type MockBulkStorage
+func (_mock *MockBulkStorage) ImportWallets(records []datastorage.WalletRecord) error {
+	ret := _mock.Called(records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportWallets")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func([]datastorage.WalletRecord) error); ok {
+		r0 = returnFunc(records)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockBulkStorage_ImportWallets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ImportWallets'
+type MockBulkStorage_ImportWallets_Call struct {
+	*mock.Call
+}
+
+// ImportWallets is a helper method to define mock.On call
+//   - records []datastorage.WalletRecord
+func (_e *MockBulkStorage_Expecter) ImportWallets(records interface{}) *MockBulkStorage_ImportWallets_Call {
+	return &MockBulkStorage_ImportWallets_Call{Call: _e.mock.On("ImportWallets", records)}
+}
+
+func (_c *MockBulkStorage_ImportWallets_Call) Run(run func(records []datastorage.WalletRecord)) *MockBulkStorage_ImportWallets_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 []datastorage.WalletRecord
+		if args[0] != nil {
+			arg0 = args[0].([]datastorage.WalletRecord)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockBulkStorage_ImportWallets_Call) Return(err error) *MockBulkStorage_ImportWallets_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockBulkStorage_ImportWallets_Call) RunAndReturn(run func(records []datastorage.WalletRecord) error) *MockBulkStorage_ImportWallets_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockCreditStorage creates a new instance of MockCreditStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockCreditStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockCreditStorage {
+	mock := &MockCreditStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockCreditStorage is an autogenerated mock type for the CreditStorage type
+type MockCreditStorage struct {
+	mock.Mock
+}
+
+type MockCreditStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockCreditStorage) EXPECT() *MockCreditStorage_Expecter {
+	return &MockCreditStorage_Expecter{mock: &_m.Mock}
+}
+
+// SetCreditLimit provides a mock function for the type MockCreditStorage
+func (_mock *MockCreditStorage) SetCreditLimit(uuid string, creditLimit float64) error {
+	ret := _mock.Called(uuid, creditLimit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCreditLimit")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, float64) error); ok {
+		r0 = returnFunc(uuid, creditLimit)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockCreditStorage_SetCreditLimit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetCreditLimit'
+type MockCreditStorage_SetCreditLimit_Call struct {
+	*mock.Call
+}
+
+// SetCreditLimit is a helper method to define mock.On call
+//   - uuid string
+//   - creditLimit float64
+func (_e *MockCreditStorage_Expecter) SetCreditLimit(uuid interface{}, creditLimit interface{}) *MockCreditStorage_SetCreditLimit_Call {
+	return &MockCreditStorage_SetCreditLimit_Call{Call: _e.mock.On("SetCreditLimit", uuid, creditLimit)}
+}
+
+func (_c *MockCreditStorage_SetCreditLimit_Call) Run(run func(uuid string, creditLimit float64)) *MockCreditStorage_SetCreditLimit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 float64
+		if args[1] != nil {
+			arg1 = args[1].(float64)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCreditStorage_SetCreditLimit_Call) Return(err error) *MockCreditStorage_SetCreditLimit_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockCreditStorage_SetCreditLimit_Call) RunAndReturn(run func(uuid string, creditLimit float64) error) *MockCreditStorage_SetCreditLimit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockEventHistory creates a new instance of MockEventHistory. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockEventHistory(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockEventHistory {
+	mock := &MockEventHistory{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockEventHistory is an autogenerated mock type for the EventHistory type
+type MockEventHistory struct {
+	mock.Mock
+}
+
+type MockEventHistory_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockEventHistory) EXPECT() *MockEventHistory_Expecter {
+	return &MockEventHistory_Expecter{mock: &_m.Mock}
+}
+
+// EventsAfter provides a mock function for the type MockEventHistory
+func (_mock *MockEventHistory) EventsAfter(uuid string, afterId int64, limit int) ([]datastorage.Event, error) {
+	ret := _mock.Called(uuid, afterId, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EventsAfter")
+	}
+
+	var r0 []datastorage.Event
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, int64, int) ([]datastorage.Event, error)); ok {
+		return returnFunc(uuid, afterId, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, int64, int) []datastorage.Event); ok {
+		r0 = returnFunc(uuid, afterId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]datastorage.Event)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, int64, int) error); ok {
+		r1 = returnFunc(uuid, afterId, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockEventHistory_EventsAfter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EventsAfter'
+type MockEventHistory_EventsAfter_Call struct {
+	*mock.Call
+}
+
+// EventsAfter is a helper method to define mock.On call
+//   - uuid string
+//   - afterId int64
+//   - limit int
+func (_e *MockEventHistory_Expecter) EventsAfter(uuid interface{}, afterId interface{}, limit interface{}) *MockEventHistory_EventsAfter_Call {
+	return &MockEventHistory_EventsAfter_Call{Call: _e.mock.On("EventsAfter", uuid, afterId, limit)}
+}
+
+func (_c *MockEventHistory_EventsAfter_Call) Run(run func(uuid string, afterId int64, limit int)) *MockEventHistory_EventsAfter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockEventHistory_EventsAfter_Call) Return(events []datastorage.Event, err error) *MockEventHistory_EventsAfter_Call {
+	_c.Call.Return(events, err)
+	return _c
+}
+
+func (_c *MockEventHistory_EventsAfter_Call) RunAndReturn(run func(uuid string, afterId int64, limit int) ([]datastorage.Event, error)) *MockEventHistory_EventsAfter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockEventListener creates a new instance of MockEventListener. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockEventListener(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockEventListener {
+	mock := &MockEventListener{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockEventListener is an autogenerated mock type for the EventListener type
+type MockEventListener struct {
+	mock.Mock
+}
+
+type MockEventListener_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockEventListener) EXPECT() *MockEventListener_Expecter {
+	return &MockEventListener_Expecter{mock: &_m.Mock}
+}
+
+// ListenEvents provides a mock function for the type MockEventListener
+func (_mock *MockEventListener) ListenEvents(ctx context.Context, publish func(event datastorage.Event)) error {
+	ret := _mock.Called(ctx, publish)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListenEvents")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, func(event datastorage.Event)) error); ok {
+		r0 = returnFunc(ctx, publish)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockEventListener_ListenEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListenEvents'
+type MockEventListener_ListenEvents_Call struct {
+	*mock.Call
+}
+
+// ListenEvents is a helper method to define mock.On call
+//   - ctx context.Context
+//   - publish func(event datastorage.Event)
+func (_e *MockEventListener_Expecter) ListenEvents(ctx interface{}, publish interface{}) *MockEventListener_ListenEvents_Call {
+	return &MockEventListener_ListenEvents_Call{Call: _e.mock.On("ListenEvents", ctx, publish)}
+}
+
+func (_c *MockEventListener_ListenEvents_Call) Run(run func(ctx context.Context, publish func(event datastorage.Event))) *MockEventListener_ListenEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 func(event datastorage.Event)
+		if args[1] != nil {
+			arg1 = args[1].(func(event datastorage.Event))
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockEventListener_ListenEvents_Call) Return(err error) *MockEventListener_ListenEvents_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockEventListener_ListenEvents_Call) RunAndReturn(run func(ctx context.Context, publish func(event datastorage.Event)) error) *MockEventListener_ListenEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockFeeStorage creates a new instance of MockFeeStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockFeeStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockFeeStorage {
+	mock := &MockFeeStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockFeeStorage is an autogenerated mock type for the FeeStorage type
+type MockFeeStorage struct {
+	mock.Mock
+}
+
+type MockFeeStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockFeeStorage) EXPECT() *MockFeeStorage_Expecter {
+	return &MockFeeStorage_Expecter{mock: &_m.Mock}
+}
+
+// DeleteFeeRule provides a mock function for the type MockFeeStorage
+func (_mock *MockFeeStorage) DeleteFeeRule(tier string, operationType string) error {
+	ret := _mock.Called(tier, operationType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteFeeRule")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = returnFunc(tier, operationType)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockFeeStorage_DeleteFeeRule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteFeeRule'
+type MockFeeStorage_DeleteFeeRule_Call struct {
+	*mock.Call
+}
+
+// DeleteFeeRule is a helper method to define mock.On call
+//   - tier string
+//   - operationType string
+func (_e *MockFeeStorage_Expecter) DeleteFeeRule(tier interface{}, operationType interface{}) *MockFeeStorage_DeleteFeeRule_Call {
+	return &MockFeeStorage_DeleteFeeRule_Call{Call: _e.mock.On("DeleteFeeRule", tier, operationType)}
+}
+
+func (_c *MockFeeStorage_DeleteFeeRule_Call) Run(run func(tier string, operationType string)) *MockFeeStorage_DeleteFeeRule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockFeeStorage_DeleteFeeRule_Call) Return(err error) *MockFeeStorage_DeleteFeeRule_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockFeeStorage_DeleteFeeRule_Call) RunAndReturn(run func(tier string, operationType string) error) *MockFeeStorage_DeleteFeeRule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListFeeRules provides a mock function for the type MockFeeStorage
+func (_mock *MockFeeStorage) ListFeeRules() ([]datastorage.FeeRule, error) {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListFeeRules")
+	}
+
+	var r0 []datastorage.FeeRule
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func() ([]datastorage.FeeRule, error)); ok {
+		return returnFunc()
+	}
+	if returnFunc, ok := ret.Get(0).(func() []datastorage.FeeRule); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]datastorage.FeeRule)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func() error); ok {
+		r1 = returnFunc()
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockFeeStorage_ListFeeRules_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListFeeRules'
+type MockFeeStorage_ListFeeRules_Call struct {
+	*mock.Call
+}
+
+// ListFeeRules is a helper method to define mock.On call
+func (_e *MockFeeStorage_Expecter) ListFeeRules() *MockFeeStorage_ListFeeRules_Call {
+	return &MockFeeStorage_ListFeeRules_Call{Call: _e.mock.On("ListFeeRules")}
+}
+
+func (_c *MockFeeStorage_ListFeeRules_Call) Run(run func()) *MockFeeStorage_ListFeeRules_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockFeeStorage_ListFeeRules_Call) Return(feeRules []datastorage.FeeRule, err error) *MockFeeStorage_ListFeeRules_Call {
+	_c.Call.Return(feeRules, err)
+	return _c
+}
+
+func (_c *MockFeeStorage_ListFeeRules_Call) RunAndReturn(run func() ([]datastorage.FeeRule, error)) *MockFeeStorage_ListFeeRules_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetFeeRule provides a mock function for the type MockFeeStorage
+func (_mock *MockFeeStorage) SetFeeRule(rule datastorage.FeeRule) error {
+	ret := _mock.Called(rule)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetFeeRule")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(datastorage.FeeRule) error); ok {
+		r0 = returnFunc(rule)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockFeeStorage_SetFeeRule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetFeeRule'
+type MockFeeStorage_SetFeeRule_Call struct {
+	*mock.Call
+}
+
+// SetFeeRule is a helper method to define mock.On call
+//   - rule datastorage.FeeRule
+func (_e *MockFeeStorage_Expecter) SetFeeRule(rule interface{}) *MockFeeStorage_SetFeeRule_Call {
+	return &MockFeeStorage_SetFeeRule_Call{Call: _e.mock.On("SetFeeRule", rule)}
+}
+
+func (_c *MockFeeStorage_SetFeeRule_Call) Run(run func(rule datastorage.FeeRule)) *MockFeeStorage_SetFeeRule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 datastorage.FeeRule
+		if args[0] != nil {
+			arg0 = args[0].(datastorage.FeeRule)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockFeeStorage_SetFeeRule_Call) Return(err error) *MockFeeStorage_SetFeeRule_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockFeeStorage_SetFeeRule_Call) RunAndReturn(run func(rule datastorage.FeeRule) error) *MockFeeStorage_SetFeeRule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockIdempotencyStorage creates a new instance of MockIdempotencyStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockIdempotencyStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockIdempotencyStorage {
+	mock := &MockIdempotencyStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockIdempotencyStorage is an autogenerated mock type for the IdempotencyStorage type
+type MockIdempotencyStorage struct {
+	mock.Mock
+}
+
+type MockIdempotencyStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockIdempotencyStorage) EXPECT() *MockIdempotencyStorage_Expecter {
+	return &MockIdempotencyStorage_Expecter{mock: &_m.Mock}
+}
+
+// ReleaseIdempotencyKey provides a mock function for the type MockIdempotencyStorage
+func (_mock *MockIdempotencyStorage) ReleaseIdempotencyKey(key string) (bool, error) {
+	ret := _mock.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseIdempotencyKey")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return returnFunc(key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = returnFunc(key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockIdempotencyStorage_ReleaseIdempotencyKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseIdempotencyKey'
+type MockIdempotencyStorage_ReleaseIdempotencyKey_Call struct {
+	*mock.Call
+}
+
+// ReleaseIdempotencyKey is a helper method to define mock.On call
+//   - key string
+func (_e *MockIdempotencyStorage_Expecter) ReleaseIdempotencyKey(key interface{}) *MockIdempotencyStorage_ReleaseIdempotencyKey_Call {
+	return &MockIdempotencyStorage_ReleaseIdempotencyKey_Call{Call: _e.mock.On("ReleaseIdempotencyKey", key)}
+}
+
+func (_c *MockIdempotencyStorage_ReleaseIdempotencyKey_Call) Run(run func(key string)) *MockIdempotencyStorage_ReleaseIdempotencyKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockIdempotencyStorage_ReleaseIdempotencyKey_Call) Return(b bool, err error) *MockIdempotencyStorage_ReleaseIdempotencyKey_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockIdempotencyStorage_ReleaseIdempotencyKey_Call) RunAndReturn(run func(key string) (bool, error)) *MockIdempotencyStorage_ReleaseIdempotencyKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReserveIdempotencyKey provides a mock function for the type MockIdempotencyStorage
+func (_mock *MockIdempotencyStorage) ReserveIdempotencyKey(key string, requestHash string) (bool, datastorage.IdempotentResponse, error) {
+	ret := _mock.Called(key, requestHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReserveIdempotencyKey")
+	}
+
+	var r0 bool
+	var r1 datastorage.IdempotentResponse
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(string, string) (bool, datastorage.IdempotentResponse, error)); ok {
+		return returnFunc(key, requestHash)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = returnFunc(key, requestHash)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, string) datastorage.IdempotentResponse); ok {
+		r1 = returnFunc(key, requestHash)
+	} else {
+		r1 = ret.Get(1).(datastorage.IdempotentResponse)
+	}
+	if returnFunc, ok := ret.Get(2).(func(string, string) error); ok {
+		r2 = returnFunc(key, requestHash)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockIdempotencyStorage_ReserveIdempotencyKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReserveIdempotencyKey'
+type MockIdempotencyStorage_ReserveIdempotencyKey_Call struct {
+	*mock.Call
+}
+
+// ReserveIdempotencyKey is a helper method to define mock.On call
+//   - key string
+//   - requestHash string
+func (_e *MockIdempotencyStorage_Expecter) ReserveIdempotencyKey(key interface{}, requestHash interface{}) *MockIdempotencyStorage_ReserveIdempotencyKey_Call {
+	return &MockIdempotencyStorage_ReserveIdempotencyKey_Call{Call: _e.mock.On("ReserveIdempotencyKey", key, requestHash)}
+}
+
+func (_c *MockIdempotencyStorage_ReserveIdempotencyKey_Call) Run(run func(key string, requestHash string)) *MockIdempotencyStorage_ReserveIdempotencyKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockIdempotencyStorage_ReserveIdempotencyKey_Call) Return(b bool, idempotentResponse datastorage.IdempotentResponse, err error) *MockIdempotencyStorage_ReserveIdempotencyKey_Call {
+	_c.Call.Return(b, idempotentResponse, err)
+	return _c
+}
+
+func (_c *MockIdempotencyStorage_ReserveIdempotencyKey_Call) RunAndReturn(run func(key string, requestHash string) (bool, datastorage.IdempotentResponse, error)) *MockIdempotencyStorage_ReserveIdempotencyKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveIdempotentResponse provides a mock function for the type MockIdempotencyStorage
+func (_mock *MockIdempotencyStorage) SaveIdempotentResponse(key string, response datastorage.IdempotentResponse) error {
+	ret := _mock.Called(key, response)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveIdempotentResponse")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, datastorage.IdempotentResponse) error); ok {
+		r0 = returnFunc(key, response)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockIdempotencyStorage_SaveIdempotentResponse_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveIdempotentResponse'
+type MockIdempotencyStorage_SaveIdempotentResponse_Call struct {
+	*mock.Call
+}
+
+// SaveIdempotentResponse is a helper method to define mock.On call
+//   - key string
+//   - response datastorage.IdempotentResponse
+func (_e *MockIdempotencyStorage_Expecter) SaveIdempotentResponse(key interface{}, response interface{}) *MockIdempotencyStorage_SaveIdempotentResponse_Call {
+	return &MockIdempotencyStorage_SaveIdempotentResponse_Call{Call: _e.mock.On("SaveIdempotentResponse", key, response)}
+}
+
+func (_c *MockIdempotencyStorage_SaveIdempotentResponse_Call) Run(run func(key string, response datastorage.IdempotentResponse)) *MockIdempotencyStorage_SaveIdempotentResponse_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 datastorage.IdempotentResponse
+		if args[1] != nil {
+			arg1 = args[1].(datastorage.IdempotentResponse)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockIdempotencyStorage_SaveIdempotentResponse_Call) Return(err error) *MockIdempotencyStorage_SaveIdempotentResponse_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockIdempotencyStorage_SaveIdempotentResponse_Call) RunAndReturn(run func(key string, response datastorage.IdempotentResponse) error) *MockIdempotencyStorage_SaveIdempotentResponse_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockInterestStorage creates a new instance of MockInterestStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockInterestStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockInterestStorage {
+	mock := &MockInterestStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockInterestStorage is an autogenerated mock type for the InterestStorage type
+type MockInterestStorage struct {
+	mock.Mock
+}
+
+type MockInterestStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockInterestStorage) EXPECT() *MockInterestStorage_Expecter {
+	return &MockInterestStorage_Expecter{mock: &_m.Mock}
+}
+
+// DeleteInterestRule provides a mock function for the type MockInterestStorage
+func (_mock *MockInterestStorage) DeleteInterestRule(tier string) error {
+	ret := _mock.Called(tier)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteInterestRule")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string) error); ok {
+		r0 = returnFunc(tier)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockInterestStorage_DeleteInterestRule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteInterestRule'
+type MockInterestStorage_DeleteInterestRule_Call struct {
+	*mock.Call
+}
+
+// DeleteInterestRule is a helper method to define mock.On call
+//   - tier string
+func (_e *MockInterestStorage_Expecter) DeleteInterestRule(tier interface{}) *MockInterestStorage_DeleteInterestRule_Call {
+	return &MockInterestStorage_DeleteInterestRule_Call{Call: _e.mock.On("DeleteInterestRule", tier)}
+}
+
+func (_c *MockInterestStorage_DeleteInterestRule_Call) Run(run func(tier string)) *MockInterestStorage_DeleteInterestRule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterestStorage_DeleteInterestRule_Call) Return(err error) *MockInterestStorage_DeleteInterestRule_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockInterestStorage_DeleteInterestRule_Call) RunAndReturn(run func(tier string) error) *MockInterestStorage_DeleteInterestRule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListInterestRules provides a mock function for the type MockInterestStorage
+func (_mock *MockInterestStorage) ListInterestRules() ([]datastorage.InterestRule, error) {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListInterestRules")
+	}
+
+	var r0 []datastorage.InterestRule
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func() ([]datastorage.InterestRule, error)); ok {
+		return returnFunc()
+	}
+	if returnFunc, ok := ret.Get(0).(func() []datastorage.InterestRule); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]datastorage.InterestRule)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func() error); ok {
+		r1 = returnFunc()
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockInterestStorage_ListInterestRules_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListInterestRules'
+type MockInterestStorage_ListInterestRules_Call struct {
+	*mock.Call
+}
+
+// ListInterestRules is a helper method to define mock.On call
+func (_e *MockInterestStorage_Expecter) ListInterestRules() *MockInterestStorage_ListInterestRules_Call {
+	return &MockInterestStorage_ListInterestRules_Call{Call: _e.mock.On("ListInterestRules")}
+}
+
+func (_c *MockInterestStorage_ListInterestRules_Call) Run(run func()) *MockInterestStorage_ListInterestRules_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockInterestStorage_ListInterestRules_Call) Return(interestRules []datastorage.InterestRule, err error) *MockInterestStorage_ListInterestRules_Call {
+	_c.Call.Return(interestRules, err)
+	return _c
+}
+
+func (_c *MockInterestStorage_ListInterestRules_Call) RunAndReturn(run func() ([]datastorage.InterestRule, error)) *MockInterestStorage_ListInterestRules_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetInterestRule provides a mock function for the type MockInterestStorage
+func (_mock *MockInterestStorage) SetInterestRule(rule datastorage.InterestRule) error {
+	ret := _mock.Called(rule)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetInterestRule")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(datastorage.InterestRule) error); ok {
+		r0 = returnFunc(rule)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockInterestStorage_SetInterestRule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetInterestRule'
+type MockInterestStorage_SetInterestRule_Call struct {
+	*mock.Call
+}
+
+// SetInterestRule is a helper method to define mock.On call
+//   - rule datastorage.InterestRule
+func (_e *MockInterestStorage_Expecter) SetInterestRule(rule interface{}) *MockInterestStorage_SetInterestRule_Call {
+	return &MockInterestStorage_SetInterestRule_Call{Call: _e.mock.On("SetInterestRule", rule)}
+}
+
+func (_c *MockInterestStorage_SetInterestRule_Call) Run(run func(rule datastorage.InterestRule)) *MockInterestStorage_SetInterestRule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 datastorage.InterestRule
+		if args[0] != nil {
+			arg0 = args[0].(datastorage.InterestRule)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterestStorage_SetInterestRule_Call) Return(err error) *MockInterestStorage_SetInterestRule_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockInterestStorage_SetInterestRule_Call) RunAndReturn(run func(rule datastorage.InterestRule) error) *MockInterestStorage_SetInterestRule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockLedgerStorage creates a new instance of MockLedgerStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockLedgerStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockLedgerStorage {
+	mock := &MockLedgerStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockLedgerStorage is an autogenerated mock type for the LedgerStorage type
+type MockLedgerStorage struct {
+	mock.Mock
+}
+
+type MockLedgerStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockLedgerStorage) EXPECT() *MockLedgerStorage_Expecter {
+	return &MockLedgerStorage_Expecter{mock: &_m.Mock}
+}
+
+// TrialBalance provides a mock function for the type MockLedgerStorage
+func (_mock *MockLedgerStorage) TrialBalance() (datastorage.TrialBalance, error) {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for TrialBalance")
+	}
+
+	var r0 datastorage.TrialBalance
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func() (datastorage.TrialBalance, error)); ok {
+		return returnFunc()
+	}
+	if returnFunc, ok := ret.Get(0).(func() datastorage.TrialBalance); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(datastorage.TrialBalance)
+	}
+	if returnFunc, ok := ret.Get(1).(func() error); ok {
+		r1 = returnFunc()
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockLedgerStorage_TrialBalance_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TrialBalance'
+type MockLedgerStorage_TrialBalance_Call struct {
+	*mock.Call
+}
+
+// TrialBalance is a helper method to define mock.On call
+func (_e *MockLedgerStorage_Expecter) TrialBalance() *MockLedgerStorage_TrialBalance_Call {
+	return &MockLedgerStorage_TrialBalance_Call{Call: _e.mock.On("TrialBalance")}
+}
+
+func (_c *MockLedgerStorage_TrialBalance_Call) Run(run func()) *MockLedgerStorage_TrialBalance_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockLedgerStorage_TrialBalance_Call) Return(trialBalance datastorage.TrialBalance, err error) *MockLedgerStorage_TrialBalance_Call {
+	_c.Call.Return(trialBalance, err)
+	return _c
+}
+
+func (_c *MockLedgerStorage_TrialBalance_Call) RunAndReturn(run func() (datastorage.TrialBalance, error)) *MockLedgerStorage_TrialBalance_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockLimitStorage creates a new instance of MockLimitStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockLimitStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockLimitStorage {
+	mock := &MockLimitStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockLimitStorage is an autogenerated mock type for the LimitStorage type
+type MockLimitStorage struct {
+	mock.Mock
+}
+
+type MockLimitStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockLimitStorage) EXPECT() *MockLimitStorage_Expecter {
+	return &MockLimitStorage_Expecter{mock: &_m.Mock}
+}
+
+// GetLimits provides a mock function for the type MockLimitStorage
+func (_mock *MockLimitStorage) GetLimits(uuid string) (bool, datastorage.Limits, error) {
+	ret := _mock.Called(uuid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLimits")
+	}
+
+	var r0 bool
+	var r1 datastorage.Limits
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(string) (bool, datastorage.Limits, error)); ok {
+		return returnFunc(uuid)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = returnFunc(uuid)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) datastorage.Limits); ok {
+		r1 = returnFunc(uuid)
+	} else {
+		r1 = ret.Get(1).(datastorage.Limits)
+	}
+	if returnFunc, ok := ret.Get(2).(func(string) error); ok {
+		r2 = returnFunc(uuid)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockLimitStorage_GetLimits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLimits'
+type MockLimitStorage_GetLimits_Call struct {
+	*mock.Call
+}
+
+// GetLimits is a helper method to define mock.On call
+//   - uuid string
+func (_e *MockLimitStorage_Expecter) GetLimits(uuid interface{}) *MockLimitStorage_GetLimits_Call {
+	return &MockLimitStorage_GetLimits_Call{Call: _e.mock.On("GetLimits", uuid)}
+}
+
+func (_c *MockLimitStorage_GetLimits_Call) Run(run func(uuid string)) *MockLimitStorage_GetLimits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockLimitStorage_GetLimits_Call) Return(b bool, limits datastorage.Limits, err error) *MockLimitStorage_GetLimits_Call {
+	_c.Call.Return(b, limits, err)
+	return _c
+}
+
+func (_c *MockLimitStorage_GetLimits_Call) RunAndReturn(run func(uuid string) (bool, datastorage.Limits, error)) *MockLimitStorage_GetLimits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetTierLimits provides a mock function for the type MockLimitStorage
+func (_mock *MockLimitStorage) SetTierLimits(tier string, limits datastorage.Limits) error {
+	ret := _mock.Called(tier, limits)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetTierLimits")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, datastorage.Limits) error); ok {
+		r0 = returnFunc(tier, limits)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockLimitStorage_SetTierLimits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetTierLimits'
+type MockLimitStorage_SetTierLimits_Call struct {
+	*mock.Call
+}
+
+// SetTierLimits is a helper method to define mock.On call
+//   - tier string
+//   - limits datastorage.Limits
+func (_e *MockLimitStorage_Expecter) SetTierLimits(tier interface{}, limits interface{}) *MockLimitStorage_SetTierLimits_Call {
+	return &MockLimitStorage_SetTierLimits_Call{Call: _e.mock.On("SetTierLimits", tier, limits)}
+}
+
+func (_c *MockLimitStorage_SetTierLimits_Call) Run(run func(tier string, limits datastorage.Limits)) *MockLimitStorage_SetTierLimits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 datastorage.Limits
+		if args[1] != nil {
+			arg1 = args[1].(datastorage.Limits)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockLimitStorage_SetTierLimits_Call) Return(err error) *MockLimitStorage_SetTierLimits_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockLimitStorage_SetTierLimits_Call) RunAndReturn(run func(tier string, limits datastorage.Limits) error) *MockLimitStorage_SetTierLimits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetWalletLimits provides a mock function for the type MockLimitStorage
+func (_mock *MockLimitStorage) SetWalletLimits(uuid string, limits datastorage.Limits) error {
+	ret := _mock.Called(uuid, limits)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetWalletLimits")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, datastorage.Limits) error); ok {
+		r0 = returnFunc(uuid, limits)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockLimitStorage_SetWalletLimits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWalletLimits'
+type MockLimitStorage_SetWalletLimits_Call struct {
+	*mock.Call
+}
+
+// SetWalletLimits is a helper method to define mock.On call
+//   - uuid string
+//   - limits datastorage.Limits
+func (_e *MockLimitStorage_Expecter) SetWalletLimits(uuid interface{}, limits interface{}) *MockLimitStorage_SetWalletLimits_Call {
+	return &MockLimitStorage_SetWalletLimits_Call{Call: _e.mock.On("SetWalletLimits", uuid, limits)}
+}
+
+func (_c *MockLimitStorage_SetWalletLimits_Call) Run(run func(uuid string, limits datastorage.Limits)) *MockLimitStorage_SetWalletLimits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 datastorage.Limits
+		if args[1] != nil {
+			arg1 = args[1].(datastorage.Limits)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockLimitStorage_SetWalletLimits_Call) Return(err error) *MockLimitStorage_SetWalletLimits_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockLimitStorage_SetWalletLimits_Call) RunAndReturn(run func(uuid string, limits datastorage.Limits) error) *MockLimitStorage_SetWalletLimits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockOperationStorage creates a new instance of MockOperationStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockOperationStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockOperationStorage {
+	mock := &MockOperationStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockOperationStorage is an autogenerated mock type for the OperationStorage type
+type MockOperationStorage struct {
+	mock.Mock
+}
+
+type MockOperationStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockOperationStorage) EXPECT() *MockOperationStorage_Expecter {
+	return &MockOperationStorage_Expecter{mock: &_m.Mock}
+}
+
+// FindOperations provides a mock function for the type MockOperationStorage
+func (_mock *MockOperationStorage) FindOperations(filter datastorage.OperationFilter) ([]datastorage.OperationRecord, error) {
+	ret := _mock.Called(filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindOperations")
+	}
+
+	var r0 []datastorage.OperationRecord
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(datastorage.OperationFilter) ([]datastorage.OperationRecord, error)); ok {
+		return returnFunc(filter)
+	}
+	if returnFunc, ok := ret.Get(0).(func(datastorage.OperationFilter) []datastorage.OperationRecord); ok {
+		r0 = returnFunc(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]datastorage.OperationRecord)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(datastorage.OperationFilter) error); ok {
+		r1 = returnFunc(filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockOperationStorage_FindOperations_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindOperations'
+type MockOperationStorage_FindOperations_Call struct {
+	*mock.Call
+}
+
+// FindOperations is a helper method to define mock.On call
+//   - filter datastorage.OperationFilter
+func (_e *MockOperationStorage_Expecter) FindOperations(filter interface{}) *MockOperationStorage_FindOperations_Call {
+	return &MockOperationStorage_FindOperations_Call{Call: _e.mock.On("FindOperations", filter)}
+}
+
+func (_c *MockOperationStorage_FindOperations_Call) Run(run func(filter datastorage.OperationFilter)) *MockOperationStorage_FindOperations_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 datastorage.OperationFilter
+		if args[0] != nil {
+			arg0 = args[0].(datastorage.OperationFilter)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockOperationStorage_FindOperations_Call) Return(operationRecords []datastorage.OperationRecord, err error) *MockOperationStorage_FindOperations_Call {
+	_c.Call.Return(operationRecords, err)
+	return _c
+}
+
+func (_c *MockOperationStorage_FindOperations_Call) RunAndReturn(run func(filter datastorage.OperationFilter) ([]datastorage.OperationRecord, error)) *MockOperationStorage_FindOperations_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockReversalStorage creates a new instance of MockReversalStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockReversalStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockReversalStorage {
+	mock := &MockReversalStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockReversalStorage is an autogenerated mock type for the ReversalStorage type
+type MockReversalStorage struct {
+	mock.Mock
+}
+
+type MockReversalStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockReversalStorage) EXPECT() *MockReversalStorage_Expecter {
+	return &MockReversalStorage_Expecter{mock: &_m.Mock}
+}
+
+// ReverseOperation provides a mock function for the type MockReversalStorage
+func (_mock *MockReversalStorage) ReverseOperation(id int64, amount float64, details datastorage.OperationDetails) (datastorage.Reversal, error) {
+	ret := _mock.Called(id, amount, details)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReverseOperation")
+	}
+
+	var r0 datastorage.Reversal
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(int64, float64, datastorage.OperationDetails) (datastorage.Reversal, error)); ok {
+		return returnFunc(id, amount, details)
+	}
+	if returnFunc, ok := ret.Get(0).(func(int64, float64, datastorage.OperationDetails) datastorage.Reversal); ok {
+		r0 = returnFunc(id, amount, details)
+	} else {
+		r0 = ret.Get(0).(datastorage.Reversal)
+	}
+	if returnFunc, ok := ret.Get(1).(func(int64, float64, datastorage.OperationDetails) error); ok {
+		r1 = returnFunc(id, amount, details)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockReversalStorage_ReverseOperation_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReverseOperation'
+type MockReversalStorage_ReverseOperation_Call struct {
+	*mock.Call
+}
+
+// ReverseOperation is a helper method to define mock.On call
+//   - id int64
+//   - amount float64
+//   - details datastorage.OperationDetails
+func (_e *MockReversalStorage_Expecter) ReverseOperation(id interface{}, amount interface{}, details interface{}) *MockReversalStorage_ReverseOperation_Call {
+	return &MockReversalStorage_ReverseOperation_Call{Call: _e.mock.On("ReverseOperation", id, amount, details)}
+}
+
+func (_c *MockReversalStorage_ReverseOperation_Call) Run(run func(id int64, amount float64, details datastorage.OperationDetails)) *MockReversalStorage_ReverseOperation_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 int64
+		if args[0] != nil {
+			arg0 = args[0].(int64)
+		}
+		var arg1 float64
+		if args[1] != nil {
+			arg1 = args[1].(float64)
+		}
+		var arg2 datastorage.OperationDetails
+		if args[2] != nil {
+			arg2 = args[2].(datastorage.OperationDetails)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockReversalStorage_ReverseOperation_Call) Return(reversal datastorage.Reversal, err error) *MockReversalStorage_ReverseOperation_Call {
+	_c.Call.Return(reversal, err)
+	return _c
+}
+
+func (_c *MockReversalStorage_ReverseOperation_Call) RunAndReturn(run func(id int64, amount float64, details datastorage.OperationDetails) (datastorage.Reversal, error)) *MockReversalStorage_ReverseOperation_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockScheduleStorage creates a new instance of MockScheduleStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockScheduleStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockScheduleStorage {
+	mock := &MockScheduleStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockScheduleStorage is an autogenerated mock type for the ScheduleStorage type
+type MockScheduleStorage struct {
+	mock.Mock
+}
+
+type MockScheduleStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockScheduleStorage) EXPECT() *MockScheduleStorage_Expecter {
+	return &MockScheduleStorage_Expecter{mock: &_m.Mock}
+}
+
+// CancelSchedule provides a mock function for the type MockScheduleStorage
+func (_mock *MockScheduleStorage) CancelSchedule(id int64) (bool, datastorage.Schedule, error) {
+	ret := _mock.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelSchedule")
+	}
+
+	var r0 bool
+	var r1 datastorage.Schedule
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(int64) (bool, datastorage.Schedule, error)); ok {
+		return returnFunc(id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(int64) bool); ok {
+		r0 = returnFunc(id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(int64) datastorage.Schedule); ok {
+		r1 = returnFunc(id)
+	} else {
+		r1 = ret.Get(1).(datastorage.Schedule)
+	}
+	if returnFunc, ok := ret.Get(2).(func(int64) error); ok {
+		r2 = returnFunc(id)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockScheduleStorage_CancelSchedule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CancelSchedule'
+type MockScheduleStorage_CancelSchedule_Call struct {
+	*mock.Call
+}
+
+// CancelSchedule is a helper method to define mock.On call
+//   - id int64
+func (_e *MockScheduleStorage_Expecter) CancelSchedule(id interface{}) *MockScheduleStorage_CancelSchedule_Call {
+	return &MockScheduleStorage_CancelSchedule_Call{Call: _e.mock.On("CancelSchedule", id)}
+}
+
+func (_c *MockScheduleStorage_CancelSchedule_Call) Run(run func(id int64)) *MockScheduleStorage_CancelSchedule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 int64
+		if args[0] != nil {
+			arg0 = args[0].(int64)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockScheduleStorage_CancelSchedule_Call) Return(b bool, schedule datastorage.Schedule, err error) *MockScheduleStorage_CancelSchedule_Call {
+	_c.Call.Return(b, schedule, err)
+	return _c
+}
+
+func (_c *MockScheduleStorage_CancelSchedule_Call) RunAndReturn(run func(id int64) (bool, datastorage.Schedule, error)) *MockScheduleStorage_CancelSchedule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateSchedule provides a mock function for the type MockScheduleStorage
+func (_mock *MockScheduleStorage) CreateSchedule(schedule datastorage.Schedule) (datastorage.Schedule, error) {
+	ret := _mock.Called(schedule)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateSchedule")
+	}
+
+	var r0 datastorage.Schedule
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(datastorage.Schedule) (datastorage.Schedule, error)); ok {
+		return returnFunc(schedule)
+	}
+	if returnFunc, ok := ret.Get(0).(func(datastorage.Schedule) datastorage.Schedule); ok {
+		r0 = returnFunc(schedule)
+	} else {
+		r0 = ret.Get(0).(datastorage.Schedule)
+	}
+	if returnFunc, ok := ret.Get(1).(func(datastorage.Schedule) error); ok {
+		r1 = returnFunc(schedule)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockScheduleStorage_CreateSchedule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateSchedule'
+type MockScheduleStorage_CreateSchedule_Call struct {
+	*mock.Call
+}
+
+// CreateSchedule is a helper method to define mock.On call
+//   - schedule datastorage.Schedule
+func (_e *MockScheduleStorage_Expecter) CreateSchedule(schedule interface{}) *MockScheduleStorage_CreateSchedule_Call {
+	return &MockScheduleStorage_CreateSchedule_Call{Call: _e.mock.On("CreateSchedule", schedule)}
+}
+
+func (_c *MockScheduleStorage_CreateSchedule_Call) Run(run func(schedule datastorage.Schedule)) *MockScheduleStorage_CreateSchedule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 datastorage.Schedule
+		if args[0] != nil {
+			arg0 = args[0].(datastorage.Schedule)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockScheduleStorage_CreateSchedule_Call) Return(schedule datastorage.Schedule, err error) *MockScheduleStorage_CreateSchedule_Call {
+	_c.Call.Return(schedule, err)
+	return _c
+}
+
+func (_c *MockScheduleStorage_CreateSchedule_Call) RunAndReturn(run func(schedule datastorage.Schedule) (datastorage.Schedule, error)) *MockScheduleStorage_CreateSchedule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSchedule provides a mock function for the type MockScheduleStorage
+func (_mock *MockScheduleStorage) GetSchedule(id int64) (bool, datastorage.Schedule, error) {
+	ret := _mock.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSchedule")
+	}
+
+	var r0 bool
+	var r1 datastorage.Schedule
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(int64) (bool, datastorage.Schedule, error)); ok {
+		return returnFunc(id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(int64) bool); ok {
+		r0 = returnFunc(id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(int64) datastorage.Schedule); ok {
+		r1 = returnFunc(id)
+	} else {
+		r1 = ret.Get(1).(datastorage.Schedule)
+	}
+	if returnFunc, ok := ret.Get(2).(func(int64) error); ok {
+		r2 = returnFunc(id)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockScheduleStorage_GetSchedule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSchedule'
+type MockScheduleStorage_GetSchedule_Call struct {
+	*mock.Call
+}
+
+// GetSchedule is a helper method to define mock.On call
+//   - id int64
+func (_e *MockScheduleStorage_Expecter) GetSchedule(id interface{}) *MockScheduleStorage_GetSchedule_Call {
+	return &MockScheduleStorage_GetSchedule_Call{Call: _e.mock.On("GetSchedule", id)}
+}
+
+func (_c *MockScheduleStorage_GetSchedule_Call) Run(run func(id int64)) *MockScheduleStorage_GetSchedule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 int64
+		if args[0] != nil {
+			arg0 = args[0].(int64)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockScheduleStorage_GetSchedule_Call) Return(b bool, schedule datastorage.Schedule, err error) *MockScheduleStorage_GetSchedule_Call {
+	_c.Call.Return(b, schedule, err)
+	return _c
+}
+
+func (_c *MockScheduleStorage_GetSchedule_Call) RunAndReturn(run func(id int64) (bool, datastorage.Schedule, error)) *MockScheduleStorage_GetSchedule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListSchedules provides a mock function for the type MockScheduleStorage
+func (_mock *MockScheduleStorage) ListSchedules(filter datastorage.ScheduleFilter) ([]datastorage.Schedule, error) {
+	ret := _mock.Called(filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSchedules")
+	}
+
+	var r0 []datastorage.Schedule
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(datastorage.ScheduleFilter) ([]datastorage.Schedule, error)); ok {
+		return returnFunc(filter)
+	}
+	if returnFunc, ok := ret.Get(0).(func(datastorage.ScheduleFilter) []datastorage.Schedule); ok {
+		r0 = returnFunc(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]datastorage.Schedule)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(datastorage.ScheduleFilter) error); ok {
+		r1 = returnFunc(filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockScheduleStorage_ListSchedules_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListSchedules'
+type MockScheduleStorage_ListSchedules_Call struct {
+	*mock.Call
+}
+
+// ListSchedules is a helper method to define mock.On call
+//   - filter datastorage.ScheduleFilter
+func (_e *MockScheduleStorage_Expecter) ListSchedules(filter interface{}) *MockScheduleStorage_ListSchedules_Call {
+	return &MockScheduleStorage_ListSchedules_Call{Call: _e.mock.On("ListSchedules", filter)}
+}
+
+func (_c *MockScheduleStorage_ListSchedules_Call) Run(run func(filter datastorage.ScheduleFilter)) *MockScheduleStorage_ListSchedules_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 datastorage.ScheduleFilter
+		if args[0] != nil {
+			arg0 = args[0].(datastorage.ScheduleFilter)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockScheduleStorage_ListSchedules_Call) Return(schedules []datastorage.Schedule, err error) *MockScheduleStorage_ListSchedules_Call {
+	_c.Call.Return(schedules, err)
+	return _c
+}
+
+func (_c *MockScheduleStorage_ListSchedules_Call) RunAndReturn(run func(filter datastorage.ScheduleFilter) ([]datastorage.Schedule, error)) *MockScheduleStorage_ListSchedules_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ScheduleRuns provides a mock function for the type MockScheduleStorage
+func (_mock *MockScheduleStorage) ScheduleRuns(id int64, limit int) (bool, []datastorage.ScheduleRun, error) {
+	ret := _mock.Called(id, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ScheduleRuns")
+	}
+
+	var r0 bool
+	var r1 []datastorage.ScheduleRun
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(int64, int) (bool, []datastorage.ScheduleRun, error)); ok {
+		return returnFunc(id, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(int64, int) bool); ok {
+		r0 = returnFunc(id, limit)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(int64, int) []datastorage.ScheduleRun); ok {
+		r1 = returnFunc(id, limit)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]datastorage.ScheduleRun)
+		}
+	}
+	if returnFunc, ok := ret.Get(2).(func(int64, int) error); ok {
+		r2 = returnFunc(id, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockScheduleStorage_ScheduleRuns_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ScheduleRuns'
+type MockScheduleStorage_ScheduleRuns_Call struct {
+	*mock.Call
+}
+
+// ScheduleRuns is a helper method to define mock.On call
+//   - id int64
+//   - limit int
+func (_e *MockScheduleStorage_Expecter) ScheduleRuns(id interface{}, limit interface{}) *MockScheduleStorage_ScheduleRuns_Call {
+	return &MockScheduleStorage_ScheduleRuns_Call{Call: _e.mock.On("ScheduleRuns", id, limit)}
+}
+
+func (_c *MockScheduleStorage_ScheduleRuns_Call) Run(run func(id int64, limit int)) *MockScheduleStorage_ScheduleRuns_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 int64
+		if args[0] != nil {
+			arg0 = args[0].(int64)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockScheduleStorage_ScheduleRuns_Call) Return(b bool, scheduleRuns []datastorage.ScheduleRun, err error) *MockScheduleStorage_ScheduleRuns_Call {
+	_c.Call.Return(b, scheduleRuns, err)
+	return _c
+}
+
+func (_c *MockScheduleStorage_ScheduleRuns_Call) RunAndReturn(run func(id int64, limit int) (bool, []datastorage.ScheduleRun, error)) *MockScheduleStorage_ScheduleRuns_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockStatusStorage creates a new instance of MockStatusStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockStatusStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockStatusStorage {
+	mock := &MockStatusStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockStatusStorage is an autogenerated mock type for the StatusStorage type
+type MockStatusStorage struct {
+	mock.Mock
+}
+
+type MockStatusStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockStatusStorage) EXPECT() *MockStatusStorage_Expecter {
+	return &MockStatusStorage_Expecter{mock: &_m.Mock}
+}
+
+// ChangeStatus provides a mock function for the type MockStatusStorage
+func (_mock *MockStatusStorage) ChangeStatus(uuid string, change datastorage.StatusChange) error {
+	ret := _mock.Called(uuid, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangeStatus")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, datastorage.StatusChange) error); ok {
+		r0 = returnFunc(uuid, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockStatusStorage_ChangeStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ChangeStatus'
+type MockStatusStorage_ChangeStatus_Call struct {
+	*mock.Call
+}
+
+// ChangeStatus is a helper method to define mock.On call
+//   - uuid string
+//   - change datastorage.StatusChange
+func (_e *MockStatusStorage_Expecter) ChangeStatus(uuid interface{}, change interface{}) *MockStatusStorage_ChangeStatus_Call {
+	return &MockStatusStorage_ChangeStatus_Call{Call: _e.mock.On("ChangeStatus", uuid, change)}
+}
+
+func (_c *MockStatusStorage_ChangeStatus_Call) Run(run func(uuid string, change datastorage.StatusChange)) *MockStatusStorage_ChangeStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 datastorage.StatusChange
+		if args[1] != nil {
+			arg1 = args[1].(datastorage.StatusChange)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockStatusStorage_ChangeStatus_Call) Return(err error) *MockStatusStorage_ChangeStatus_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockStatusStorage_ChangeStatus_Call) RunAndReturn(run func(uuid string, change datastorage.StatusChange) error) *MockStatusStorage_ChangeStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetStatus provides a mock function for the type MockStatusStorage
+func (_mock *MockStatusStorage) GetStatus(uuid string) (bool, datastorage.WalletStatus, error) {
+	ret := _mock.Called(uuid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStatus")
+	}
+
+	var r0 bool
+	var r1 datastorage.WalletStatus
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(string) (bool, datastorage.WalletStatus, error)); ok {
+		return returnFunc(uuid)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = returnFunc(uuid)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) datastorage.WalletStatus); ok {
+		r1 = returnFunc(uuid)
+	} else {
+		r1 = ret.Get(1).(datastorage.WalletStatus)
+	}
+	if returnFunc, ok := ret.Get(2).(func(string) error); ok {
+		r2 = returnFunc(uuid)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockStatusStorage_GetStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStatus'
+type MockStatusStorage_GetStatus_Call struct {
+	*mock.Call
+}
+
+// GetStatus is a helper method to define mock.On call
+//   - uuid string
+func (_e *MockStatusStorage_Expecter) GetStatus(uuid interface{}) *MockStatusStorage_GetStatus_Call {
+	return &MockStatusStorage_GetStatus_Call{Call: _e.mock.On("GetStatus", uuid)}
+}
+
+func (_c *MockStatusStorage_GetStatus_Call) Run(run func(uuid string)) *MockStatusStorage_GetStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockStatusStorage_GetStatus_Call) Return(b bool, walletStatus datastorage.WalletStatus, err error) *MockStatusStorage_GetStatus_Call {
+	_c.Call.Return(b, walletStatus, err)
+	return _c
+}
+
+func (_c *MockStatusStorage_GetStatus_Call) RunAndReturn(run func(uuid string) (bool, datastorage.WalletStatus, error)) *MockStatusStorage_GetStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockWalletStorage creates a new instance of MockWalletStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockWalletStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockWalletStorage {
+	mock := &MockWalletStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockWalletStorage is an autogenerated mock type for the WalletStorage type
+type MockWalletStorage struct {
+	mock.Mock
+}
+
+type MockWalletStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockWalletStorage) EXPECT() *MockWalletStorage_Expecter {
+	return &MockWalletStorage_Expecter{mock: &_m.Mock}
+}
+
+// ChangeBalance provides a mock function for the type MockWalletStorage
+func (_mock *MockWalletStorage) ChangeBalance(sum float64, uuid string, details datastorage.OperationDetails) (bool, datastorage.Receipt, error) {
+	ret := _mock.Called(sum, uuid, details)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangeBalance")
+	}
+
+	var r0 bool
+	var r1 datastorage.Receipt
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(float64, string, datastorage.OperationDetails) (bool, datastorage.Receipt, error)); ok {
+		return returnFunc(sum, uuid, details)
+	}
+	if returnFunc, ok := ret.Get(0).(func(float64, string, datastorage.OperationDetails) bool); ok {
+		r0 = returnFunc(sum, uuid, details)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(float64, string, datastorage.OperationDetails) datastorage.Receipt); ok {
+		r1 = returnFunc(sum, uuid, details)
+	} else {
+		r1 = ret.Get(1).(datastorage.Receipt)
+	}
+	if returnFunc, ok := ret.Get(2).(func(float64, string, datastorage.OperationDetails) error); ok {
+		r2 = returnFunc(sum, uuid, details)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockWalletStorage_ChangeBalance_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ChangeBalance'
+type MockWalletStorage_ChangeBalance_Call struct {
+	*mock.Call
+}
+
+// ChangeBalance is a helper method to define mock.On call
+//   - sum float64
+//   - uuid string
+//   - details datastorage.OperationDetails
+func (_e *MockWalletStorage_Expecter) ChangeBalance(sum interface{}, uuid interface{}, details interface{}) *MockWalletStorage_ChangeBalance_Call {
+	return &MockWalletStorage_ChangeBalance_Call{Call: _e.mock.On("ChangeBalance", sum, uuid, details)}
+}
+
+func (_c *MockWalletStorage_ChangeBalance_Call) Run(run func(sum float64, uuid string, details datastorage.OperationDetails)) *MockWalletStorage_ChangeBalance_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 float64
+		if args[0] != nil {
+			arg0 = args[0].(float64)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 datastorage.OperationDetails
+		if args[2] != nil {
+			arg2 = args[2].(datastorage.OperationDetails)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockWalletStorage_ChangeBalance_Call) Return(b bool, receipt datastorage.Receipt, err error) *MockWalletStorage_ChangeBalance_Call {
+	_c.Call.Return(b, receipt, err)
+	return _c
+}
+
+func (_c *MockWalletStorage_ChangeBalance_Call) RunAndReturn(run func(sum float64, uuid string, details datastorage.OperationDetails) (bool, datastorage.Receipt, error)) *MockWalletStorage_ChangeBalance_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Check provides a mock function for the type MockWalletStorage
+func (_mock *MockWalletStorage) Check(uuid string) (bool, error) {
+	ret := _mock.Called(uuid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Check")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return returnFunc(uuid)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = returnFunc(uuid)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(uuid)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockWalletStorage_Check_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Check'
+type MockWalletStorage_Check_Call struct {
+	*mock.Call
+}
+
+// Check is a helper method to define mock.On call
+//   - uuid string
+func (_e *MockWalletStorage_Expecter) Check(uuid interface{}) *MockWalletStorage_Check_Call {
+	return &MockWalletStorage_Check_Call{Call: _e.mock.On("Check", uuid)}
+}
+
+func (_c *MockWalletStorage_Check_Call) Run(run func(uuid string)) *MockWalletStorage_Check_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockWalletStorage_Check_Call) Return(b bool, err error) *MockWalletStorage_Check_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockWalletStorage_Check_Call) RunAndReturn(run func(uuid string) (bool, error)) *MockWalletStorage_Check_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateWallet provides a mock function for the type MockWalletStorage
+func (_mock *MockWalletStorage) CreateWallet(uuid string, options datastorage.WalletOptions) error {
+	ret := _mock.Called(uuid, options)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWallet")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, datastorage.WalletOptions) error); ok {
+		r0 = returnFunc(uuid, options)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockWalletStorage_CreateWallet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateWallet'
+type MockWalletStorage_CreateWallet_Call struct {
+	*mock.Call
+}
+
+// CreateWallet is a helper method to define mock.On call
+//   - uuid string
+//   - options datastorage.WalletOptions
+func (_e *MockWalletStorage_Expecter) CreateWallet(uuid interface{}, options interface{}) *MockWalletStorage_CreateWallet_Call {
+	return &MockWalletStorage_CreateWallet_Call{Call: _e.mock.On("CreateWallet", uuid, options)}
+}
+
+func (_c *MockWalletStorage_CreateWallet_Call) Run(run func(uuid string, options datastorage.WalletOptions)) *MockWalletStorage_CreateWallet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 datastorage.WalletOptions
+		if args[1] != nil {
+			arg1 = args[1].(datastorage.WalletOptions)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockWalletStorage_CreateWallet_Call) Return(err error) *MockWalletStorage_CreateWallet_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockWalletStorage_CreateWallet_Call) RunAndReturn(run func(uuid string, options datastorage.WalletOptions) error) *MockWalletStorage_CreateWallet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Get provides a mock function for the type MockWalletStorage
+func (_mock *MockWalletStorage) Get(uuid string) (bool, float64, error) {
+	ret := _mock.Called(uuid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 bool
+	var r1 float64
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(string) (bool, float64, error)); ok {
+		return returnFunc(uuid)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = returnFunc(uuid)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) float64); ok {
+		r1 = returnFunc(uuid)
+	} else {
+		r1 = ret.Get(1).(float64)
+	}
+	if returnFunc, ok := ret.Get(2).(func(string) error); ok {
+		r2 = returnFunc(uuid)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockWalletStorage_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type MockWalletStorage_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - uuid string
+func (_e *MockWalletStorage_Expecter) Get(uuid interface{}) *MockWalletStorage_Get_Call {
+	return &MockWalletStorage_Get_Call{Call: _e.mock.On("Get", uuid)}
+}
+
+func (_c *MockWalletStorage_Get_Call) Run(run func(uuid string)) *MockWalletStorage_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockWalletStorage_Get_Call) Return(b bool, f float64, err error) *MockWalletStorage_Get_Call {
+	_c.Call.Return(b, f, err)
+	return _c
+}
+
+func (_c *MockWalletStorage_Get_Call) RunAndReturn(run func(uuid string) (bool, float64, error)) *MockWalletStorage_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetWallet provides a mock function for the type MockWalletStorage
+func (_mock *MockWalletStorage) GetWallet(uuid string) (bool, datastorage.Wallet, error) {
+	ret := _mock.Called(uuid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWallet")
+	}
+
+	var r0 bool
+	var r1 datastorage.Wallet
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(string) (bool, datastorage.Wallet, error)); ok {
+		return returnFunc(uuid)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = returnFunc(uuid)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) datastorage.Wallet); ok {
+		r1 = returnFunc(uuid)
+	} else {
+		r1 = ret.Get(1).(datastorage.Wallet)
+	}
+	if returnFunc, ok := ret.Get(2).(func(string) error); ok {
+		r2 = returnFunc(uuid)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockWalletStorage_GetWallet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWallet'
+type MockWalletStorage_GetWallet_Call struct {
+	*mock.Call
+}
 
-	return mock
+// GetWallet is a helper method to define mock.On call
+//   - uuid string
+func (_e *MockWalletStorage_Expecter) GetWallet(uuid interface{}) *MockWalletStorage_GetWallet_Call {
+	return &MockWalletStorage_GetWallet_Call{Call: _e.mock.On("GetWallet", uuid)}
 }
 
-// MockWalletStorage is an autogenerated mock type for the WalletStorage type
-type MockWalletStorage struct {
-	mock.Mock
+func (_c *MockWalletStorage_GetWallet_Call) Run(run func(uuid string)) *MockWalletStorage_GetWallet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
 }
 
-type MockWalletStorage_Expecter struct {
-	mock *mock.Mock
+func (_c *MockWalletStorage_GetWallet_Call) Return(b bool, wallet datastorage.Wallet, err error) *MockWalletStorage_GetWallet_Call {
+	_c.Call.Return(b, wallet, err)
+	return _c
 }
 
-func (_m *MockWalletStorage) EXPECT() *MockWalletStorage_Expecter {
-	return &MockWalletStorage_Expecter{mock: &_m.Mock}
+func (_c *MockWalletStorage_GetWallet_Call) RunAndReturn(run func(uuid string) (bool, datastorage.Wallet, error)) *MockWalletStorage_GetWallet_Call {
+	_c.Call.Return(run)
+	return _c
 }
 
-// ChangeBalance provides a mock function for the type MockWalletStorage
-func (_mock *MockWalletStorage) ChangeBalance(sum float64, uuid string) (bool, error) {
-	ret := _mock.Called(sum, uuid)
+// ListWallets provides a mock function for the type MockWalletStorage
+func (_mock *MockWalletStorage) ListWallets(filter datastorage.WalletFilter) (datastorage.WalletPage, error) {
+	ret := _mock.Called(filter)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ChangeBalance")
+		panic("no return value specified for ListWallets")
 	}
 
-	var r0 bool
+	var r0 datastorage.WalletPage
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(float64, string) (bool, error)); ok {
-		return returnFunc(sum, uuid)
+	if returnFunc, ok := ret.Get(0).(func(datastorage.WalletFilter) (datastorage.WalletPage, error)); ok {
+		return returnFunc(filter)
 	}
-	if returnFunc, ok := ret.Get(0).(func(float64, string) bool); ok {
-		r0 = returnFunc(sum, uuid)
+	if returnFunc, ok := ret.Get(0).(func(datastorage.WalletFilter) datastorage.WalletPage); ok {
+		r0 = returnFunc(filter)
 	} else {
-		r0 = ret.Get(0).(bool)
+		r0 = ret.Get(0).(datastorage.WalletPage)
 	}
-	if returnFunc, ok := ret.Get(1).(func(float64, string) error); ok {
-		r1 = returnFunc(sum, uuid)
+	if returnFunc, ok := ret.Get(1).(func(datastorage.WalletFilter) error); ok {
+		r1 = returnFunc(filter)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockWalletStorage_ChangeBalance_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ChangeBalance'
-type MockWalletStorage_ChangeBalance_Call struct {
+// MockWalletStorage_ListWallets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListWallets'
+type MockWalletStorage_ListWallets_Call struct {
 	*mock.Call
 }
 
-// ChangeBalance is a helper method to define mock.On call
+// ListWallets is a helper method to define mock.On call
+//   - filter datastorage.WalletFilter
+func (_e *MockWalletStorage_Expecter) ListWallets(filter interface{}) *MockWalletStorage_ListWallets_Call {
+	return &MockWalletStorage_ListWallets_Call{Call: _e.mock.On("ListWallets", filter)}
+}
+
+func (_c *MockWalletStorage_ListWallets_Call) Run(run func(filter datastorage.WalletFilter)) *MockWalletStorage_ListWallets_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 datastorage.WalletFilter
+		if args[0] != nil {
+			arg0 = args[0].(datastorage.WalletFilter)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockWalletStorage_ListWallets_Call) Return(walletPage datastorage.WalletPage, err error) *MockWalletStorage_ListWallets_Call {
+	_c.Call.Return(walletPage, err)
+	return _c
+}
+
+func (_c *MockWalletStorage_ListWallets_Call) RunAndReturn(run func(filter datastorage.WalletFilter) (datastorage.WalletPage, error)) *MockWalletStorage_ListWallets_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Transfer provides a mock function for the type MockWalletStorage
+func (_mock *MockWalletStorage) Transfer(sum float64, from string, to string, details datastorage.OperationDetails) (bool, datastorage.Receipt, error) {
+	ret := _mock.Called(sum, from, to, details)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Transfer")
+	}
+
+	var r0 bool
+	var r1 datastorage.Receipt
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(float64, string, string, datastorage.OperationDetails) (bool, datastorage.Receipt, error)); ok {
+		return returnFunc(sum, from, to, details)
+	}
+	if returnFunc, ok := ret.Get(0).(func(float64, string, string, datastorage.OperationDetails) bool); ok {
+		r0 = returnFunc(sum, from, to, details)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(float64, string, string, datastorage.OperationDetails) datastorage.Receipt); ok {
+		r1 = returnFunc(sum, from, to, details)
+	} else {
+		r1 = ret.Get(1).(datastorage.Receipt)
+	}
+	if returnFunc, ok := ret.Get(2).(func(float64, string, string, datastorage.OperationDetails) error); ok {
+		r2 = returnFunc(sum, from, to, details)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockWalletStorage_Transfer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Transfer'
+type MockWalletStorage_Transfer_Call struct {
+	*mock.Call
+}
+
+// Transfer is a helper method to define mock.On call
 //   - sum float64
-//   - uuid string
-func (_e *MockWalletStorage_Expecter) ChangeBalance(sum interface{}, uuid interface{}) *MockWalletStorage_ChangeBalance_Call {
-	return &MockWalletStorage_ChangeBalance_Call{Call: _e.mock.On("ChangeBalance", sum, uuid)}
+//   - from string
+//   - to string
+//   - details datastorage.OperationDetails
+func (_e *MockWalletStorage_Expecter) Transfer(sum interface{}, from interface{}, to interface{}, details interface{}) *MockWalletStorage_Transfer_Call {
+	return &MockWalletStorage_Transfer_Call{Call: _e.mock.On("Transfer", sum, from, to, details)}
 }
 
-func (_c *MockWalletStorage_ChangeBalance_Call) Run(run func(sum float64, uuid string)) *MockWalletStorage_ChangeBalance_Call {
+func (_c *MockWalletStorage_Transfer_Call) Run(run func(sum float64, from string, to string, details datastorage.OperationDetails)) *MockWalletStorage_Transfer_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 float64
 		if args[0] != nil {
@@ -83,117 +2904,169 @@ func (_c *MockWalletStorage_ChangeBalance_Call) Run(run func(sum float64, uuid s
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 datastorage.OperationDetails
+		if args[3] != nil {
+			arg3 = args[3].(datastorage.OperationDetails)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
+			arg3,
 		)
 	})
 	return _c
 }
 
-func (_c *MockWalletStorage_ChangeBalance_Call) Return(b bool, err error) *MockWalletStorage_ChangeBalance_Call {
-	_c.Call.Return(b, err)
+func (_c *MockWalletStorage_Transfer_Call) Return(b bool, receipt datastorage.Receipt, err error) *MockWalletStorage_Transfer_Call {
+	_c.Call.Return(b, receipt, err)
 	return _c
 }
 
-func (_c *MockWalletStorage_ChangeBalance_Call) RunAndReturn(run func(sum float64, uuid string) (bool, error)) *MockWalletStorage_ChangeBalance_Call {
+func (_c *MockWalletStorage_Transfer_Call) RunAndReturn(run func(sum float64, from string, to string, details datastorage.OperationDetails) (bool, datastorage.Receipt, error)) *MockWalletStorage_Transfer_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Check provides a mock function for the type MockWalletStorage
-func (_mock *MockWalletStorage) Check(uuid string) (bool, error) {
-	ret := _mock.Called(uuid)
+// UpdateMetadata provides a mock function for the type MockWalletStorage
+func (_mock *MockWalletStorage) UpdateMetadata(uuid string, changes map[string]*string) (datastorage.Wallet, error) {
+	ret := _mock.Called(uuid, changes)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Check")
+		panic("no return value specified for UpdateMetadata")
 	}
 
-	var r0 bool
+	var r0 datastorage.Wallet
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(string) (bool, error)); ok {
-		return returnFunc(uuid)
+	if returnFunc, ok := ret.Get(0).(func(string, map[string]*string) (datastorage.Wallet, error)); ok {
+		return returnFunc(uuid, changes)
 	}
-	if returnFunc, ok := ret.Get(0).(func(string) bool); ok {
-		r0 = returnFunc(uuid)
+	if returnFunc, ok := ret.Get(0).(func(string, map[string]*string) datastorage.Wallet); ok {
+		r0 = returnFunc(uuid, changes)
 	} else {
-		r0 = ret.Get(0).(bool)
+		r0 = ret.Get(0).(datastorage.Wallet)
 	}
-	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
-		r1 = returnFunc(uuid)
+	if returnFunc, ok := ret.Get(1).(func(string, map[string]*string) error); ok {
+		r1 = returnFunc(uuid, changes)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockWalletStorage_Check_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Check'
-type MockWalletStorage_Check_Call struct {
+// MockWalletStorage_UpdateMetadata_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateMetadata'
+type MockWalletStorage_UpdateMetadata_Call struct {
 	*mock.Call
 }
 
-// Check is a helper method to define mock.On call
+// UpdateMetadata is a helper method to define mock.On call
 //   - uuid string
-func (_e *MockWalletStorage_Expecter) Check(uuid interface{}) *MockWalletStorage_Check_Call {
-	return &MockWalletStorage_Check_Call{Call: _e.mock.On("Check", uuid)}
+//   - changes map[string]*string
+func (_e *MockWalletStorage_Expecter) UpdateMetadata(uuid interface{}, changes interface{}) *MockWalletStorage_UpdateMetadata_Call {
+	return &MockWalletStorage_UpdateMetadata_Call{Call: _e.mock.On("UpdateMetadata", uuid, changes)}
 }
 
-func (_c *MockWalletStorage_Check_Call) Run(run func(uuid string)) *MockWalletStorage_Check_Call {
+func (_c *MockWalletStorage_UpdateMetadata_Call) Run(run func(uuid string, changes map[string]*string)) *MockWalletStorage_UpdateMetadata_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 string
 		if args[0] != nil {
 			arg0 = args[0].(string)
 		}
+		var arg1 map[string]*string
+		if args[1] != nil {
+			arg1 = args[1].(map[string]*string)
+		}
 		run(
 			arg0,
+			arg1,
 		)
 	})
 	return _c
 }
 
-func (_c *MockWalletStorage_Check_Call) Return(b bool, err error) *MockWalletStorage_Check_Call {
-	_c.Call.Return(b, err)
+func (_c *MockWalletStorage_UpdateMetadata_Call) Return(wallet datastorage.Wallet, err error) *MockWalletStorage_UpdateMetadata_Call {
+	_c.Call.Return(wallet, err)
 	return _c
 }
 
-func (_c *MockWalletStorage_Check_Call) RunAndReturn(run func(uuid string) (bool, error)) *MockWalletStorage_Check_Call {
+func (_c *MockWalletStorage_UpdateMetadata_Call) RunAndReturn(run func(uuid string, changes map[string]*string) (datastorage.Wallet, error)) *MockWalletStorage_UpdateMetadata_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// CreateWallet provides a mock function for the type MockWalletStorage
-func (_mock *MockWalletStorage) CreateWallet(uuid string) error {
-	ret := _mock.Called(uuid)
+// NewMockWebhookStorage creates a new instance of MockWebhookStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockWebhookStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockWebhookStorage {
+	mock := &MockWebhookStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockWebhookStorage is an autogenerated mock type for the WebhookStorage type
+type MockWebhookStorage struct {
+	mock.Mock
+}
+
+type MockWebhookStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockWebhookStorage) EXPECT() *MockWebhookStorage_Expecter {
+	return &MockWebhookStorage_Expecter{mock: &_m.Mock}
+}
+
+// CreateWebhook provides a mock function for the type MockWebhookStorage
+func (_mock *MockWebhookStorage) CreateWebhook(subscription datastorage.WebhookSubscription) (datastorage.WebhookSubscription, error) {
+	ret := _mock.Called(subscription)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateWallet")
+		panic("no return value specified for CreateWebhook")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(string) error); ok {
-		r0 = returnFunc(uuid)
+	var r0 datastorage.WebhookSubscription
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(datastorage.WebhookSubscription) (datastorage.WebhookSubscription, error)); ok {
+		return returnFunc(subscription)
+	}
+	if returnFunc, ok := ret.Get(0).(func(datastorage.WebhookSubscription) datastorage.WebhookSubscription); ok {
+		r0 = returnFunc(subscription)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(datastorage.WebhookSubscription)
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(datastorage.WebhookSubscription) error); ok {
+		r1 = returnFunc(subscription)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockWalletStorage_CreateWallet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateWallet'
-type MockWalletStorage_CreateWallet_Call struct {
+// MockWebhookStorage_CreateWebhook_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateWebhook'
+type MockWebhookStorage_CreateWebhook_Call struct {
 	*mock.Call
 }
 
-// CreateWallet is a helper method to define mock.On call
-//   - uuid string
-func (_e *MockWalletStorage_Expecter) CreateWallet(uuid interface{}) *MockWalletStorage_CreateWallet_Call {
-	return &MockWalletStorage_CreateWallet_Call{Call: _e.mock.On("CreateWallet", uuid)}
+// CreateWebhook is a helper method to define mock.On call
+//   - subscription datastorage.WebhookSubscription
+func (_e *MockWebhookStorage_Expecter) CreateWebhook(subscription interface{}) *MockWebhookStorage_CreateWebhook_Call {
+	return &MockWebhookStorage_CreateWebhook_Call{Call: _e.mock.On("CreateWebhook", subscription)}
 }
 
-func (_c *MockWalletStorage_CreateWallet_Call) Run(run func(uuid string)) *MockWalletStorage_CreateWallet_Call {
+func (_c *MockWebhookStorage_CreateWebhook_Call) Run(run func(subscription datastorage.WebhookSubscription)) *MockWebhookStorage_CreateWebhook_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 datastorage.WebhookSubscription
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(datastorage.WebhookSubscription)
 		}
 		run(
 			arg0,
@@ -202,78 +3075,207 @@ func (_c *MockWalletStorage_CreateWallet_Call) Run(run func(uuid string)) *MockW
 	return _c
 }
 
-func (_c *MockWalletStorage_CreateWallet_Call) Return(err error) *MockWalletStorage_CreateWallet_Call {
-	_c.Call.Return(err)
+func (_c *MockWebhookStorage_CreateWebhook_Call) Return(webhookSubscription datastorage.WebhookSubscription, err error) *MockWebhookStorage_CreateWebhook_Call {
+	_c.Call.Return(webhookSubscription, err)
 	return _c
 }
 
-func (_c *MockWalletStorage_CreateWallet_Call) RunAndReturn(run func(uuid string) error) *MockWalletStorage_CreateWallet_Call {
+func (_c *MockWebhookStorage_CreateWebhook_Call) RunAndReturn(run func(subscription datastorage.WebhookSubscription) (datastorage.WebhookSubscription, error)) *MockWebhookStorage_CreateWebhook_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Get provides a mock function for the type MockWalletStorage
-func (_mock *MockWalletStorage) Get(uuid string) (bool, float64, error) {
-	ret := _mock.Called(uuid)
+// DeleteWebhook provides a mock function for the type MockWebhookStorage
+func (_mock *MockWebhookStorage) DeleteWebhook(id int64) (bool, error) {
+	ret := _mock.Called(id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Get")
+		panic("no return value specified for DeleteWebhook")
 	}
 
 	var r0 bool
-	var r1 float64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(int64) (bool, error)); ok {
+		return returnFunc(id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(int64) bool); ok {
+		r0 = returnFunc(id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = returnFunc(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockWebhookStorage_DeleteWebhook_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteWebhook'
+type MockWebhookStorage_DeleteWebhook_Call struct {
+	*mock.Call
+}
+
+// DeleteWebhook is a helper method to define mock.On call
+//   - id int64
+func (_e *MockWebhookStorage_Expecter) DeleteWebhook(id interface{}) *MockWebhookStorage_DeleteWebhook_Call {
+	return &MockWebhookStorage_DeleteWebhook_Call{Call: _e.mock.On("DeleteWebhook", id)}
+}
+
+func (_c *MockWebhookStorage_DeleteWebhook_Call) Run(run func(id int64)) *MockWebhookStorage_DeleteWebhook_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 int64
+		if args[0] != nil {
+			arg0 = args[0].(int64)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockWebhookStorage_DeleteWebhook_Call) Return(b bool, err error) *MockWebhookStorage_DeleteWebhook_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockWebhookStorage_DeleteWebhook_Call) RunAndReturn(run func(id int64) (bool, error)) *MockWebhookStorage_DeleteWebhook_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListWebhooks provides a mock function for the type MockWebhookStorage
+func (_mock *MockWebhookStorage) ListWebhooks() ([]datastorage.WebhookSubscription, error) {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhooks")
+	}
+
+	var r0 []datastorage.WebhookSubscription
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func() ([]datastorage.WebhookSubscription, error)); ok {
+		return returnFunc()
+	}
+	if returnFunc, ok := ret.Get(0).(func() []datastorage.WebhookSubscription); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]datastorage.WebhookSubscription)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func() error); ok {
+		r1 = returnFunc()
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockWebhookStorage_ListWebhooks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListWebhooks'
+type MockWebhookStorage_ListWebhooks_Call struct {
+	*mock.Call
+}
+
+// ListWebhooks is a helper method to define mock.On call
+func (_e *MockWebhookStorage_Expecter) ListWebhooks() *MockWebhookStorage_ListWebhooks_Call {
+	return &MockWebhookStorage_ListWebhooks_Call{Call: _e.mock.On("ListWebhooks")}
+}
+
+func (_c *MockWebhookStorage_ListWebhooks_Call) Run(run func()) *MockWebhookStorage_ListWebhooks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockWebhookStorage_ListWebhooks_Call) Return(webhookSubscriptions []datastorage.WebhookSubscription, err error) *MockWebhookStorage_ListWebhooks_Call {
+	_c.Call.Return(webhookSubscriptions, err)
+	return _c
+}
+
+func (_c *MockWebhookStorage_ListWebhooks_Call) RunAndReturn(run func() ([]datastorage.WebhookSubscription, error)) *MockWebhookStorage_ListWebhooks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WebhookDeliveries provides a mock function for the type MockWebhookStorage
+func (_mock *MockWebhookStorage) WebhookDeliveries(id int64, status string, limit int) (bool, []datastorage.WebhookDeliveryLog, error) {
+	ret := _mock.Called(id, status, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WebhookDeliveries")
+	}
+
+	var r0 bool
+	var r1 []datastorage.WebhookDeliveryLog
 	var r2 error
-	if returnFunc, ok := ret.Get(0).(func(string) (bool, float64, error)); ok {
-		return returnFunc(uuid)
+	if returnFunc, ok := ret.Get(0).(func(int64, string, int) (bool, []datastorage.WebhookDeliveryLog, error)); ok {
+		return returnFunc(id, status, limit)
 	}
-	if returnFunc, ok := ret.Get(0).(func(string) bool); ok {
-		r0 = returnFunc(uuid)
+	if returnFunc, ok := ret.Get(0).(func(int64, string, int) bool); ok {
+		r0 = returnFunc(id, status, limit)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
-	if returnFunc, ok := ret.Get(1).(func(string) float64); ok {
-		r1 = returnFunc(uuid)
+	if returnFunc, ok := ret.Get(1).(func(int64, string, int) []datastorage.WebhookDeliveryLog); ok {
+		r1 = returnFunc(id, status, limit)
 	} else {
-		r1 = ret.Get(1).(float64)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]datastorage.WebhookDeliveryLog)
+		}
 	}
-	if returnFunc, ok := ret.Get(2).(func(string) error); ok {
-		r2 = returnFunc(uuid)
+	if returnFunc, ok := ret.Get(2).(func(int64, string, int) error); ok {
+		r2 = returnFunc(id, status, limit)
 	} else {
 		r2 = ret.Error(2)
 	}
 	return r0, r1, r2
 }
 
-// MockWalletStorage_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
-type MockWalletStorage_Get_Call struct {
+// MockWebhookStorage_WebhookDeliveries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WebhookDeliveries'
+type MockWebhookStorage_WebhookDeliveries_Call struct {
 	*mock.Call
 }
 
-// Get is a helper method to define mock.On call
-//   - uuid string
-func (_e *MockWalletStorage_Expecter) Get(uuid interface{}) *MockWalletStorage_Get_Call {
-	return &MockWalletStorage_Get_Call{Call: _e.mock.On("Get", uuid)}
+// WebhookDeliveries is a helper method to define mock.On call
+//   - id int64
+//   - status string
+//   - limit int
+func (_e *MockWebhookStorage_Expecter) WebhookDeliveries(id interface{}, status interface{}, limit interface{}) *MockWebhookStorage_WebhookDeliveries_Call {
+	return &MockWebhookStorage_WebhookDeliveries_Call{Call: _e.mock.On("WebhookDeliveries", id, status, limit)}
 }
 
-func (_c *MockWalletStorage_Get_Call) Run(run func(uuid string)) *MockWalletStorage_Get_Call {
+func (_c *MockWebhookStorage_WebhookDeliveries_Call) Run(run func(id int64, status string, limit int)) *MockWebhookStorage_WebhookDeliveries_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 int64
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(int64)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
 		}
 		run(
 			arg0,
+			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *MockWalletStorage_Get_Call) Return(b bool, f float64, err error) *MockWalletStorage_Get_Call {
-	_c.Call.Return(b, f, err)
+func (_c *MockWebhookStorage_WebhookDeliveries_Call) Return(b bool, webhookDeliveryLogs []datastorage.WebhookDeliveryLog, err error) *MockWebhookStorage_WebhookDeliveries_Call {
+	_c.Call.Return(b, webhookDeliveryLogs, err)
 	return _c
 }
 
-func (_c *MockWalletStorage_Get_Call) RunAndReturn(run func(uuid string) (bool, float64, error)) *MockWalletStorage_Get_Call {
+func (_c *MockWebhookStorage_WebhookDeliveries_Call) RunAndReturn(run func(id int64, status string, limit int) (bool, []datastorage.WebhookDeliveryLog, error)) *MockWebhookStorage_WebhookDeliveries_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,26 @@
+package server
+
+import (
+	_ "embed"
+	"log"
+	"net/http"
+)
+
+// openapiSpec - описание всех маршрутов из Start. openapi_test.go проверяет,
+// что в нём есть каждый маршрут, метод и код ответа обработчиков.
+//
+//go:embed openapi.json
+var openapiSpec []byte
+
+func newOpenAPIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openapiSpec)
+	}
+}
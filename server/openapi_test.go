@@ -0,0 +1,231 @@
+package server
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusCodes - константы net/http, которые используют обработчики
+var statusCodes = map[string]string{
+	"StatusOK":                    "200",
+	"StatusCreated":               "201",
+	"StatusNoContent":             "204",
+	"StatusNotModified":           "304",
+	"StatusBadRequest":            "400",
+	"StatusUnauthorized":          "401",
+	"StatusForbidden":             "403",
+	"StatusNotFound":              "404",
+	"StatusMethodNotAllowed":      "405",
+	"StatusConflict":              "409",
+	"StatusPreconditionFailed":    "412",
+	"StatusRequestEntityTooLarge": "413",
+	"StatusUnprocessableEntity":   "422",
+	"StatusTooManyRequests":       "429",
+	"StatusInternalServerError":   "500",
+	"StatusServiceUnavailable":    "503",
+}
+
+type openapiOperation struct {
+	Responses map[string]json.RawMessage `json:"responses"`
+}
+
+type openapiDocument struct {
+	OpenAPI string                                `json:"openapi"`
+	Paths   map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+// handlerUsage - методы и коды ответов, которые встречаются в обработчике и вызываемых им функциях
+type handlerUsage struct {
+	methods  map[string]bool
+	statuses map[string]bool
+}
+
+var pathParam = regexp.MustCompile(`\{[^}]+\}`)
+
+// specPath приводит шаблон маршрута ServeMux к виду пути в спецификации
+func specPath(pattern string) string {
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "{id}"
+	}
+	return pathParam.ReplaceAllString(pattern, "{}")
+}
+
+func parseServerFuncs(t *testing.T) map[string]*ast.FuncDecl {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+
+	funcs := map[string]*ast.FuncDecl{}
+	fset := token.NewFileSet()
+
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		require.NoError(t, err)
+
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				if fn.Recv == nil {
+					funcs[fn.Name.Name] = fn
+				} else if fn.Name.Name == "Start" {
+					funcs["Server.Start"] = fn
+				}
+			}
+		}
+	}
+
+	return funcs
+}
+
+func collectUsage(t *testing.T, node ast.Node, funcs map[string]*ast.FuncDecl, visited map[string]bool, usage handlerUsage) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if pkg, ok := n.X.(*ast.Ident); !ok || pkg.Name != "http" {
+				return true
+			}
+
+			name := n.Sel.Name
+			switch {
+			case name == "NotFound":
+				usage.statuses["404"] = true
+			case strings.HasPrefix(name, "Status"):
+				code, ok := statusCodes[name]
+				if !ok {
+					t.Errorf("unknown status %s, add it to statusCodes", name)
+				}
+				usage.statuses[code] = true
+			case strings.HasPrefix(name, "Method"):
+				usage.methods[strings.ToLower(strings.TrimPrefix(name, "Method"))] = true
+			}
+
+		case *ast.CallExpr:
+			if id, ok := n.Fun.(*ast.Ident); ok && funcs[id.Name] != nil && !visited[id.Name] {
+				visited[id.Name] = true
+				collectUsage(t, funcs[id.Name].Body, funcs, visited, usage)
+			}
+		}
+		return true
+	})
+}
+
+// registeredRoutes находит все вызовы mux.HandleFunc в Server.Start
+func registeredRoutes(t *testing.T, funcs map[string]*ast.FuncDecl) map[string]handlerUsage {
+	start := funcs["Server.Start"]
+	require.NotNil(t, start)
+
+	routes := map[string]handlerUsage{}
+
+	ast.Inspect(start.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "HandleFunc" || len(call.Args) != 2 {
+			return true
+		}
+
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		require.True(t, ok, "route pattern must be a string literal")
+
+		pattern, err := strconv.Unquote(lit.Value)
+		require.NoError(t, err)
+
+		usage := handlerUsage{methods: map[string]bool{}, statuses: map[string]bool{}}
+		collectUsage(t, call.Args[1], funcs, map[string]bool{}, usage)
+		routes[specPath(pattern)] = usage
+
+		return false
+	})
+
+	return routes
+}
+
+func loadOpenAPI(t *testing.T) openapiDocument {
+	var doc openapiDocument
+	require.NoError(t, json.Unmarshal(openapiSpec, &doc))
+	require.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+	return doc
+}
+
+func TestOpenAPIDescribesRoutes(t *testing.T) {
+	doc := loadOpenAPI(t)
+	routes := registeredRoutes(t, parseServerFuncs(t))
+	require.NotEmpty(t, routes)
+
+	described := map[string]map[string]json.RawMessage{}
+	for path, item := range doc.Paths {
+		described[specPath(path)] = item
+	}
+
+	for path, usage := range routes {
+		item, ok := described[path]
+		if !assert.True(t, ok, "route %s is not described", path) {
+			continue
+		}
+
+		responses := map[string]bool{}
+
+		for method, raw := range item {
+			if method == "parameters" {
+				continue
+			}
+
+			var op openapiOperation
+			require.NoError(t, json.Unmarshal(raw, &op))
+
+			success := false
+			for code := range op.Responses {
+				responses[code] = true
+				success = success || strings.HasPrefix(code, "2")
+			}
+			assert.True(t, success, "%s %s has no success response", method, path)
+		}
+
+		for method := range usage.methods {
+			_, ok := item[method]
+			assert.True(t, ok, "method %s of %s is not described", method, path)
+		}
+
+		for status := range usage.statuses {
+			assert.True(t, responses[status], "status %s of %s is not described", status, path)
+		}
+	}
+
+	for path := range described {
+		_, ok := routes[path]
+		assert.True(t, ok, "described path %s is not registered", path)
+	}
+}
+
+func TestGetOpenAPI(t *testing.T) {
+	handler := newOpenAPIHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var doc openapiDocument
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Contains(t, doc.Paths, "/api/v1/wallets/{walletId}")
+}
@@ -0,0 +1,259 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+	"walletGolang/scheduler"
+)
+
+const (
+	defaultSchedulesPage = 100
+	maxSchedulesPage     = 1000
+)
+
+type ScheduleStorage interface {
+	CreateSchedule(schedule datastorage.Schedule) (datastorage.Schedule, error)
+	ListSchedules(filter datastorage.ScheduleFilter) ([]datastorage.Schedule, error)
+	GetSchedule(id int64) (bool, datastorage.Schedule, error)
+	CancelSchedule(id int64) (bool, datastorage.Schedule, error)
+	ScheduleRuns(id int64, limit int) (bool, []datastorage.ScheduleRun, error)
+}
+
+// scheduleMessage - тело запроса планирования операции. externalId каждому запуску задаётся сам.
+type scheduleMessage struct {
+	datastorage.Operation
+	RunAt    time.Time  `json:"runAt"`
+	Interval string     `json:"interval"`
+	Cron     string     `json:"cron"`
+	EndsAt   *time.Time `json:"endsAt"`
+}
+
+// pageLimit разбирает параметр limit: по умолчанию fallback, не больше max
+func pageLimit(value string, fallback, max int) (int, string) {
+	if value == "" {
+		return fallback, ""
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 || limit > max {
+		return 0, "limit must be from 1 to " + strconv.Itoa(max)
+	}
+
+	return limit, ""
+}
+
+// newSchedulesHandler обслуживает /api/v1/schedules: GET выдаёт запланированные операции
+// ?walletId=&status=&limit=, POST планирует разовую или повторяющуюся операцию
+func newSchedulesHandler(ds ScheduleStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/schedules" {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+
+			filter := datastorage.ScheduleFilter{WalletId: query.Get("walletId"), Status: query.Get("status")}
+
+			switch filter.Status {
+			case "", datastorage.ScheduleActive, datastorage.ScheduleCompleted, datastorage.ScheduleCancelled:
+			default:
+				log.Println("wrong schedule status:", filter.Status)
+				http.Error(w, "status must be active, completed or cancelled", http.StatusBadRequest)
+				return
+			}
+
+			var reason string
+			if filter.Limit, reason = pageLimit(query.Get("limit"), defaultSchedulesPage, maxSchedulesPage); reason != "" {
+				log.Println("wrong limit:", query.Get("limit"))
+				http.Error(w, reason, http.StatusBadRequest)
+				return
+			}
+
+			schedules, err := ds.ListSchedules(filter)
+
+			if err != nil {
+				log.Println("error in list schedules method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if schedules == nil {
+				schedules = []datastorage.Schedule{}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(schedules)
+
+		case http.MethodPost:
+			var msg scheduleMessage
+			if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+				log.Println("wrong json")
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			msg.Amount = math.Floor(msg.Amount*100) / 100
+
+			if reason := validOperation(msg.Operation); reason != "" {
+				log.Println("wrong scheduled operation:", reason)
+				http.Error(w, reason, http.StatusBadRequest)
+				return
+			}
+
+			if msg.ExternalId != "" {
+				log.Println("externalId in scheduled operation")
+				http.Error(w, "externalId is set for each run and must be empty", http.StatusBadRequest)
+				return
+			}
+
+			if msg.OperationType != datastorage.OperationTransfer {
+				msg.ToWalletId = ""
+			}
+
+			schedule := datastorage.Schedule{
+				WalletId:      msg.WalletId,
+				OperationType: msg.OperationType,
+				Amount:        msg.Amount,
+				ToWalletId:    msg.ToWalletId,
+				Reference:     msg.Reference,
+				Description:   msg.Description,
+				RunAt:         msg.RunAt,
+				Interval:      msg.Interval,
+				Cron:          msg.Cron,
+				EndsAt:        msg.EndsAt,
+				Actor:         adminActor(r),
+			}
+
+			var err error
+			if schedule.RunAt, err = scheduler.FirstRun(schedule, time.Now()); err != nil {
+				log.Println("wrong schedule:", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			schedule, err = ds.CreateSchedule(schedule)
+
+			if err != nil {
+				writeOperationError(w, err)
+				return
+			}
+
+			log.Println("Operation scheduled:", schedule.Id, "by", schedule.Actor)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(schedule)
+
+		default:
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// newScheduleHandler обслуживает /api/v1/schedules/{id}: GET выдаёт запланированную операцию,
+// DELETE отменяет её будущие запуски (история запусков остаётся)
+func newScheduleHandler(ds ScheduleStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathId(r.URL.Path, "/api/v1/schedules", "") // проверяем, что запрос имеет вид /api/v1/schedules/{ID}
+		if !ok {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		var got bool
+		var schedule datastorage.Schedule
+		var err error
+
+		if r.Method == http.MethodGet {
+			got, schedule, err = ds.GetSchedule(id)
+		} else {
+			log.Println("cancel schedule", id, "by", adminActor(r))
+			got, schedule, err = ds.CancelSchedule(id)
+		}
+
+		if errors.As(err, &datastorage.ScheduleNotActive{}) {
+			log.Println("schedule not active:", err)
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		if err != nil {
+			log.Println("error in schedule method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !got {
+			log.Println("schedule undefined:", id)
+			http.Error(w, "schedule undefined", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(schedule)
+	}
+}
+
+// newScheduleRunsHandler выдаёт последние запуски /api/v1/schedules/{id}/runs?limit=
+func newScheduleRunsHandler(ds ScheduleStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathId(r.URL.Path, "/api/v1/schedules", "runs") // проверяем, что запрос имеет вид /api/v1/schedules/{ID}/runs
+		if !ok {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		limit, reason := pageLimit(r.URL.Query().Get("limit"), defaultSchedulesPage, maxSchedulesPage)
+		if reason != "" {
+			log.Println("wrong limit:", r.URL.Query().Get("limit"))
+			http.Error(w, reason, http.StatusBadRequest)
+			return
+		}
+
+		got, runs, err := ds.ScheduleRuns(id, limit)
+
+		if err != nil {
+			log.Println("error in schedule runs method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !got {
+			log.Println("schedule undefined:", id)
+			http.Error(w, "schedule undefined", http.StatusNotFound)
+			return
+		}
+
+		if runs == nil {
+			runs = []datastorage.ScheduleRun{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(runs)
+	}
+}
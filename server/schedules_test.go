@@ -0,0 +1,158 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGoodCreateSchedule(t *testing.T) {
+	ds := NewMockScheduleStorage(t)
+
+	runAt := time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC)
+
+	ds.EXPECT().
+		CreateSchedule(mock.MatchedBy(func(s datastorage.Schedule) bool {
+			return s.WalletId == "1" && s.OperationType == datastorage.OperationTransfer && s.Amount == 9.99 &&
+				s.ToWalletId == "2" && s.Reference == "subscription-7" && s.Cron == "0 9 1 * *" &&
+				s.RunAt.Equal(runAt) && s.Actor == "billing"
+		})).
+		RunAndReturn(func(s datastorage.Schedule) (datastorage.Schedule, error) {
+			s.Id = 5
+			s.Status = datastorage.ScheduleActive
+			s.NextRunAt = &s.RunAt
+			s.CreatedAt = runAt.AddDate(0, -1, 0)
+			return s, nil
+		}).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/schedules", strings.NewReader(
+		`{"walletId":"1","operationType":"TRANSFER","amount":9.999,"toWalletId":"2","reference":"subscription-7",
+		  "cron":"0 9 1 * *","runAt":"2026-11-01T08:30:00Z"}`))
+	req.Header.Set("X-Actor", "billing")
+
+	rec := httptest.NewRecorder()
+	newSchedulesHandler(ds).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.JSONEq(t, `{"id":5,"walletId":"1","operationType":"TRANSFER","amount":9.99,"toWalletId":"2",
+		"reference":"subscription-7","runAt":"2026-11-01T09:00:00Z","cron":"0 9 1 * *","status":"active",
+		"nextRunAt":"2026-11-01T09:00:00Z","runs":0,"actor":"billing","createdAt":"2026-10-01T09:00:00Z"}`, rec.Body.String())
+}
+
+func TestWrongCreateSchedule(t *testing.T) {
+	ds := NewMockScheduleStorage(t)
+	ds.EXPECT().CreateSchedule(mock.Anything).Return(datastorage.Schedule{}, datastorage.UUIDUndefined{}).Once()
+
+	handler := newSchedulesHandler(ds)
+
+	tests := map[string]string{
+		`{"walletId":"1","operationType":"WITHDRAW","amount":0.001,"runAt":"2026-11-01T09:00:00Z"}`:                 "sum must be more 0\n",
+		`{"walletId":"1","operationType":"TRANSFER","amount":5,"runAt":"2026-11-01T09:00:00Z"}`:                     "toWalletId must be another wallet\n",
+		`{"walletId":"1","operationType":"WITHDRAW","amount":5,"externalId":"x","runAt":"2026-11-01T09:00:00Z"}`:    "externalId is set for each run and must be empty\n",
+		`{"walletId":"1","operationType":"WITHDRAW","amount":5}`:                                                    "runAt is required for one-off operation\n",
+		`{"walletId":"1","operationType":"WITHDRAW","amount":5,"interval":"1s"}`:                                    "interval must be at least 1m0s\n",
+		`{"walletId":"1","operationType":"WITHDRAW","amount":5,"cron":"0 9 * *"}`:                                   "cron must have 5 fields: minute hour day month weekday\n",
+		`{"walletId":"none","operationType":"DEPOSIT","amount":5,"interval":"24h","endsAt":"2030-01-01T00:00:00Z"}`: "UUID_UNDEFINED: UUID undifined\n",
+	}
+
+	for body, message := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/schedules", strings.NewReader(body)))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		assert.Equal(t, message, rec.Body.String(), body)
+	}
+}
+
+func TestListSchedules(t *testing.T) {
+	ds := NewMockScheduleStorage(t)
+	ds.EXPECT().
+		ListSchedules(datastorage.ScheduleFilter{WalletId: "1", Status: datastorage.ScheduleActive, Limit: 10}).
+		Return(nil, nil).
+		Once()
+
+	handler := newSchedulesHandler(ds)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/schedules?walletId=1&status=active&limit=10", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "[]\n", rec.Body.String())
+
+	for _, target := range []string{"/api/v1/schedules?status=paused", "/api/v1/schedules?limit=1001"} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+	}
+}
+
+func TestCancelSchedule(t *testing.T) {
+	ds := NewMockScheduleStorage(t)
+	ds.EXPECT().CancelSchedule(int64(5)).
+		Return(true, datastorage.Schedule{Id: 5, Status: datastorage.ScheduleCancelled}, nil).Once()
+	ds.EXPECT().CancelSchedule(int64(6)).
+		Return(true, datastorage.Schedule{}, datastorage.ScheduleNotActive{Status: datastorage.ScheduleCompleted}).Once()
+	ds.EXPECT().GetSchedule(int64(7)).Return(false, datastorage.Schedule{}, nil).Once()
+
+	handler := newScheduleHandler(ds)
+
+	tests := []struct {
+		method, target string
+		status         int
+		body           string
+	}{
+		{http.MethodDelete, "/api/v1/schedules/5", http.StatusOK, ""},
+		{http.MethodDelete, "/api/v1/schedules/6", http.StatusConflict, "schedule is completed\n"},
+		{http.MethodGet, "/api/v1/schedules/7", http.StatusNotFound, "schedule undefined\n"},
+		{http.MethodGet, "/api/v1/schedules/abc", http.StatusNotFound, ""},
+		{http.MethodPost, "/api/v1/schedules/5", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(test.method, test.target, nil))
+
+		assert.Equal(t, test.status, rec.Code, test.method+" "+test.target)
+		if test.body != "" {
+			assert.Equal(t, test.body, rec.Body.String())
+		}
+	}
+}
+
+func TestScheduleRuns(t *testing.T) {
+	operationId := int64(42)
+	ranAt := time.Date(2026, 11, 1, 9, 0, 2, 0, time.UTC)
+
+	ds := NewMockScheduleStorage(t)
+	ds.EXPECT().ScheduleRuns(int64(5), 100).Return(true, []datastorage.ScheduleRun{
+		{Id: 2, ScheduledFor: ranAt.Truncate(time.Minute).AddDate(0, 1, 0), Status: datastorage.RunFailed,
+			Error: "balance small for operation", RanAt: ranAt.AddDate(0, 1, 0)},
+		{Id: 1, ScheduledFor: ranAt.Truncate(time.Minute), Status: datastorage.RunSucceeded, OperationId: &operationId, RanAt: ranAt},
+	}, nil).Once()
+	ds.EXPECT().ScheduleRuns(int64(6), 100).Return(false, nil, nil).Once()
+
+	handler := newScheduleRunsHandler(ds)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/schedules/5/runs", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[
+		{"id":2,"scheduledFor":"2026-12-01T09:00:00Z","status":"failed","error":"balance small for operation","ranAt":"2026-12-01T09:00:02Z"},
+		{"id":1,"scheduledFor":"2026-11-01T09:00:00Z","status":"succeeded","operationId":42,"ranAt":"2026-11-01T09:00:02Z"}
+	]`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/schedules/6/runs", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
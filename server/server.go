@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -9,30 +10,56 @@ import (
 	"time"
 
 	"log"
+
+	datastorage "walletGolang/dataStorage"
+	"walletGolang/events"
 )
 
 type UpdateWalletmessage struct {
 	WalletId      string  `json:"walletId"`
 	OperationType string  `json:"operationType"`
 	Amount        float64 `json:"amount"`
+	ToWalletId    string  `json:"toWalletId,omitempty"`
+	datastorage.OperationDetails
 }
 
 type createWalletmessage struct {
 	WalletId string `json:"walletId"`
+	datastorage.WalletOptions
 }
 
 type WalletStorage interface {
 	Get(uuid string) (bool, float64, error)
+	GetWallet(uuid string) (bool, datastorage.Wallet, error)
 	Check(uuid string) (bool, error)
-	ChangeBalance(sum float64, uuid string) (bool, error)
-	CreateWallet(uuid string) error
+	ChangeBalance(sum float64, uuid string, details datastorage.OperationDetails) (bool, datastorage.Receipt, error)
+	Transfer(sum float64, from, to string, details datastorage.OperationDetails) (bool, datastorage.Receipt, error)
+	CreateWallet(uuid string, options datastorage.WalletOptions) error
+	ListWallets(filter datastorage.WalletFilter) (datastorage.WalletPage, error)
+	UpdateMetadata(uuid string, changes map[string]*string) (datastorage.Wallet, error)
 }
 
 type Server struct {
-	storage WalletStorage
+	storage    WalletStorage
+	AdminToken string
+	BatchLimit int
+	GRPCPort   string // если пустой, gRPC API не запускается
+}
+
+// walletPathId достаёт id кошелька из пути вида /api/v1/wallets/{WALLET_UUID}/{suffix}
+func walletPathId(path, suffix string) (string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	if len(parts) != 5 || parts[4] != suffix || path != "/api/v1/wallets/"+parts[3]+"/"+suffix {
+		return "", false
+	}
+
+	return parts[3], true
 }
 
 func newGetBalanceHandler(ds WalletStorage) http.HandlerFunc {
+	history, _ := ds.(BalanceHistoryStorage)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 
@@ -50,7 +77,18 @@ func newGetBalanceHandler(ds WalletStorage) http.HandlerFunc {
 
 			log.Println("uuid:", uuid)
 
-			got, sum, err := ds.Get(uuid)
+			if at := r.URL.Query().Get("at"); at != "" {
+				writeBalanceAt(w, r, history, uuid, at)
+				return
+			}
+
+			if strings.Contains(r.Header.Get("Accept"), "application/json") {
+				writeWallet(w, ds, uuid)
+				return
+			}
+
+			// баланс читается вместе с версией, чтобы ETag соответствовал ответу
+			got, wallet, err := ds.GetWallet(uuid)
 
 			if err != nil {
 				log.Println("error get request:", err)
@@ -65,7 +103,8 @@ func newGetBalanceHandler(ds WalletStorage) http.HandlerFunc {
 			}
 
 			log.Println("Operation is done")
-			fmt.Fprintln(w, math.Floor(sum*100)/100)
+			setWalletETag(w, wallet.Version)
+			fmt.Fprintln(w, math.Floor(wallet.Balance*100)/100)
 
 		} else {
 			log.Println("wrong method on path:", r.URL.Path)
@@ -74,6 +113,37 @@ func newGetBalanceHandler(ds WalletStorage) http.HandlerFunc {
 	}
 }
 
+// writeWallet отдаёт баланс вместе с кредитным лимитом и статусом в JSON
+func writeWallet(w http.ResponseWriter, ds WalletStorage, uuid string) {
+	got, wallet, err := ds.GetWallet(uuid)
+
+	if err != nil {
+		log.Println("error get request:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !got {
+		log.Println("uuid undefined")
+		http.Error(w, "uuid undefined", http.StatusBadRequest)
+		return
+	}
+
+	log.Println("Operation is done")
+	encodeWallet(w, wallet)
+}
+
+// encodeWallet отдаёт кошелёк в JSON с суммами, округлёнными вниз до копеек, и его версию в ETag
+func encodeWallet(w http.ResponseWriter, wallet datastorage.Wallet) {
+	setWalletETag(w, wallet.Version)
+
+	wallet.Balance = math.Floor(wallet.Balance*100) / 100
+	wallet.AvailableCredit = math.Floor(wallet.AvailableCredit*100) / 100
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wallet)
+}
+
 func newChangeBalanceHandler(ds WalletStorage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -102,6 +172,12 @@ func newChangeBalanceHandler(ds WalletStorage) http.HandlerFunc {
 				return
 			}
 
+			if reason := validDetails(msg.OperationDetails); reason != "" {
+				log.Println("wrong operation details:", reason)
+				http.Error(w, reason, http.StatusBadRequest)
+				return
+			}
+
 			check, err := ds.Check(msg.WalletId)
 
 			if err != nil {
@@ -118,21 +194,39 @@ func newChangeBalanceHandler(ds WalletStorage) http.HandlerFunc {
 
 			msg.Amount = math.Floor(msg.Amount*100) / 100
 
+			// с If-Match операция проводится, только если кошелёк (для перевода - отправителя) не менялся
+			msg.IfMatch = ifMatchVersions(r.Header.Get("If-Match"))
+			msg.IdempotencyKey = r.Header.Get(idempotencyHeader)
+
 			changed := false
+			var receipt datastorage.Receipt
 
 			switch msg.OperationType {
-			case "DEPOSIT":
-				changed, err = ds.ChangeBalance(msg.Amount, msg.WalletId)
-			case "WITHDRAW":
-				changed, err = ds.ChangeBalance(-msg.Amount, msg.WalletId)
+			case datastorage.OperationDeposit:
+				changed, receipt, err = ds.ChangeBalance(msg.Amount, msg.WalletId, msg.OperationDetails)
+			case datastorage.OperationWithdraw:
+				changed, receipt, err = ds.ChangeBalance(-msg.Amount, msg.WalletId, msg.OperationDetails)
+			case datastorage.OperationTransfer:
+				if msg.ToWalletId == "" || msg.ToWalletId == msg.WalletId {
+					log.Println("wrong transfer target:", msg.ToWalletId)
+					http.Error(w, "toWalletId must be another wallet", http.StatusBadRequest)
+					return
+				}
+				changed, receipt, err = ds.Transfer(msg.Amount, msg.WalletId, msg.ToWalletId, msg.OperationDetails)
 			default:
 				log.Println("wrong operation type")
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				http.Error(w, "wrong operation type", http.StatusBadRequest)
+				return
+			}
+
+			if errors.As(err, &datastorage.VersionMismatch{}) {
+				log.Println("wallet changed since If-Match:", err)
+				http.Error(w, "VERSION_MISMATCH: "+err.Error(), http.StatusPreconditionFailed)
+				return
 			}
 
 			if err != nil {
-				log.Println("wrong server behaviour")
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeOperationError(w, err)
 				return
 			} else {
 
@@ -141,6 +235,14 @@ func newChangeBalanceHandler(ds WalletStorage) http.HandlerFunc {
 					http.Error(w, "balance small for Withdraw", http.StatusBadRequest)
 				} else {
 					log.Println("Operation complit")
+
+					// сумма, комиссия и сколько дошло отдаются только тем, кто просит JSON
+					if strings.Contains(r.Header.Get("Accept"), "application/json") {
+						w.Header().Set("Content-Type", "application/json")
+						json.NewEncoder(w).Encode(receipt)
+						return
+					}
+
 					fmt.Fprintln(w, "Operation complit")
 					return
 				}
@@ -151,6 +253,51 @@ func newChangeBalanceHandler(ds WalletStorage) http.HandlerFunc {
 	}
 }
 
+// operationError возвращает код ошибки операции с балансом и HTTP статус ответа
+func operationError(err error) (string, int) {
+	switch {
+	case errors.As(err, &datastorage.LimitExceeded{}):
+		return "LIMIT_EXCEEDED", http.StatusBadRequest
+	case errors.As(err, &datastorage.WalletNotActive{}):
+		return "WALLET_NOT_ACTIVE", http.StatusBadRequest
+	case errors.As(err, &datastorage.InsufficientFunds{}):
+		return "INSUFFICIENT_FUNDS", http.StatusBadRequest
+	case errors.As(err, &datastorage.UUIDUndefined{}):
+		return "UUID_UNDEFINED", http.StatusBadRequest
+	case errors.As(err, &datastorage.WrongOperation{}):
+		return "WRONG_OPERATION", http.StatusBadRequest
+	case errors.As(err, &datastorage.DuplicateExternalId{}):
+		return "DUPLICATE_EXTERNAL_ID", http.StatusConflict
+	case errors.As(err, &datastorage.OperationUndefined{}):
+		return "OPERATION_UNDEFINED", http.StatusNotFound
+	case errors.As(err, &datastorage.AlreadyReversed{}):
+		return "ALREADY_REVERSED", http.StatusConflict
+	case errors.As(err, &datastorage.FeeExceedsAmount{}):
+		return "FEE_EXCEEDS_AMOUNT", http.StatusBadRequest
+	case errors.As(err, &datastorage.ReversalExceeded{}):
+		return "REVERSAL_EXCEEDED", http.StatusBadRequest
+	case errors.As(err, &datastorage.NotReversible{}):
+		return "NOT_REVERSIBLE", http.StatusBadRequest
+	}
+
+	return "INTERNAL", http.StatusInternalServerError
+}
+
+// writeOperationError отвечает на ошибку операции с балансом.
+// Отказы по правилам кошелька отдаются с кодом ошибки в начале текста.
+func writeOperationError(w http.ResponseWriter, err error) {
+	code, status := operationError(err)
+
+	if status == http.StatusInternalServerError {
+		log.Println("wrong server behaviour")
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	log.Println("operation rejected:", code, err)
+	http.Error(w, code+": "+err.Error(), status)
+}
+
 func newCreateWalletHandler(ds WalletStorage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -175,6 +322,12 @@ func newCreateWalletHandler(ds WalletStorage) http.HandlerFunc {
 				return
 			}
 
+			if err = datastorage.ValidateMetadata(msg.Metadata); err != nil {
+				log.Println("wrong metadata:", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
 			log.Println("uuid:", msg.WalletId)
 			check, err := ds.Check(msg.WalletId)
 
@@ -190,7 +343,8 @@ func newCreateWalletHandler(ds WalletStorage) http.HandlerFunc {
 				return
 			}
 
-			err = ds.CreateWallet(msg.WalletId)
+			msg.IdempotencyKey = r.Header.Get(idempotencyHeader)
+			err = ds.CreateWallet(msg.WalletId, msg.WalletOptions)
 
 			if err != nil {
 				log.Println("error in create method: ", err)
@@ -221,17 +375,137 @@ func (server *Server) Start(ds WalletStorage, port string) {
 
 	server.storage = ds
 
+	var broadcaster *events.Broadcaster
+	var history EventHistory
+
+	// если хранилище не уведомляет о событиях само, их публикуют обработчики
+	if listener, ok := ds.(EventListener); ok {
+		broadcaster = events.NewBroadcaster(0)
+		go listenEvents(listener, broadcaster)
+	} else {
+		broadcaster = events.NewBroadcaster(fallbackHistory)
+		server.storage = broadcastingStorage{WalletStorage: ds, broadcaster: broadcaster}
+	}
+
+	history, ok := ds.(EventHistory)
+	if !ok {
+		history = broadcaster
+	}
+
+	audit, _ := ds.(AuditStorage)
+
+	if server.GRPCPort != "" {
+		go server.startGRPC(broadcaster, audit)
+	}
+
+	idempotency, _ := ds.(IdempotencyStorage)
+
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/api/v1/wallets/", withDBLimit(newGetBalanceHandler(server.storage)))
+	mux.HandleFunc("/api/v1/wallets/", withDBLimit(newWalletHandler(server.storage)))
+
+	mux.HandleFunc("/api/v1/wallets", withAdminAuth(server.AdminToken, withDBLimit(newListWalletsHandler(server.storage))))
+
+	mux.HandleFunc("/api/v1/wallets/wallet/create", withDBLimit(withIdempotency(idempotency, newCreateWalletHandler(server.storage))))
+
+	mux.HandleFunc("/api/v1/wallets/wallet", withDBLimit(withIdempotency(idempotency, newChangeBalanceHandler(server.storage))))
+
+	mux.HandleFunc("/api/v1/wallets/{id}/history", withDBLimit(newWalletHistoryHandler(server.storage, history)))
+
+	mux.HandleFunc("/openapi.json", newOpenAPIHandler())
+
+	mux.HandleFunc("/api/v1/wallets/{id}/events", newWalletEventsHandler(server.storage, broadcaster, history, heartbeatInterval))
+
+	if bs, ok := ds.(BatchStorage); ok {
+		batchLimit := server.BatchLimit
+		if batchLimit <= 0 {
+			batchLimit = defaultBatchLimit
+		}
+
+		mux.HandleFunc("/api/v1/wallets/batch", withDBLimit(withIdempotency(idempotency, newBatchHandler(bs, batchLimit))))
+	}
+
+	if bs, ok := ds.(BulkStorage); ok {
+		mux.HandleFunc("/api/v1/wallets/import", withAdminAuth(server.AdminToken, withDBLimit(newImportHandler(bs))))
+
+		mux.HandleFunc("/api/v1/wallets/export", withAdminAuth(server.AdminToken, withDBLimit(newExportHandler(bs))))
+	}
+
+	if ws, ok := ds.(WebhookStorage); ok {
+		mux.HandleFunc("/api/v1/webhooks", withAdminAuth(server.AdminToken, withDBLimit(newWebhooksHandler(ws))))
+
+		mux.HandleFunc("/api/v1/webhooks/{id}", withAdminAuth(server.AdminToken, withDBLimit(newDeleteWebhookHandler(ws))))
+
+		mux.HandleFunc("/api/v1/webhooks/{id}/deliveries", withAdminAuth(server.AdminToken, withDBLimit(newWebhookDeliveriesHandler(ws))))
+	}
+
+	if ops, ok := ds.(OperationStorage); ok {
+		mux.HandleFunc("/api/v1/transactions", withDBLimit(newFindTransactionsHandler(ops)))
+	}
+
+	if rs, ok := ds.(ReversalStorage); ok {
+		mux.HandleFunc("/api/v1/transactions/{id}/reverse", withAdminAuth(server.AdminToken, withDBLimit(withIdempotency(idempotency, newReverseHandler(rs)))))
+	}
+
+	if bs, ok := ds.(BalanceHistoryStorage); ok {
+		mux.HandleFunc("/api/v1/wallets/balances", withAdminAuth(server.AdminToken, withDBLimit(newBalancesHandler(bs))))
+	}
+
+	if ls, ok := ds.(LedgerStorage); ok {
+		mux.HandleFunc("/api/v1/ledger/trial-balance", withAdminAuth(server.AdminToken, withDBLimit(newTrialBalanceHandler(ls))))
+	}
+
+	if fs, ok := ds.(FeeStorage); ok {
+		mux.HandleFunc("/api/v1/fees", withAdminAuth(server.AdminToken, withDBLimit(newFeeRulesHandler(fs))))
+	}
+
+	if is, ok := ds.(InterestStorage); ok {
+		mux.HandleFunc("/api/v1/interest", withAdminAuth(server.AdminToken, withDBLimit(newInterestRulesHandler(is))))
+	}
+
+	if ss, ok := ds.(ScheduleStorage); ok {
+		mux.HandleFunc("/api/v1/schedules", withAdminAuth(server.AdminToken, withDBLimit(newSchedulesHandler(ss))))
+
+		mux.HandleFunc("/api/v1/schedules/{id}", withAdminAuth(server.AdminToken, withDBLimit(newScheduleHandler(ss))))
+
+		mux.HandleFunc("/api/v1/schedules/{id}/runs", withAdminAuth(server.AdminToken, withDBLimit(newScheduleRunsHandler(ss))))
+	}
 
-	mux.HandleFunc("/api/v1/wallets/wallet/create", withDBLimit(newCreateWalletHandler(server.storage)))
+	if ls, ok := ds.(LimitStorage); ok {
+		mux.HandleFunc("/api/v1/wallets/{id}/limits", withAdminAuth(server.AdminToken, withDBLimit(newWalletLimitsHandler(ls))))
 
-	mux.HandleFunc("/api/v1/wallets/wallet", withDBLimit(newChangeBalanceHandler(server.storage)))
+		mux.HandleFunc("/api/v1/tiers/{tier}", withAdminAuth(server.AdminToken, withDBLimit(newTierLimitsHandler(ls))))
+	}
+
+	if cs, ok := ds.(CreditStorage); ok {
+		mux.HandleFunc("/api/v1/wallets/{id}/credit", withAdminAuth(server.AdminToken, withDBLimit(newSetCreditLimitHandler(cs))))
+	}
+
+	if audit != nil {
+		mux.HandleFunc("/api/v1/audit", withAdminAuth(server.AdminToken, withDBLimit(newAuditHandler(audit))))
+
+		mux.HandleFunc("/api/v1/audit/verify", withAdminAuth(server.AdminToken, withDBLimit(newAuditVerifyHandler(audit))))
+	}
+
+	if ss, ok := ds.(StatusStorage); ok {
+		mux.HandleFunc("/api/v1/wallets/{id}/status", withAdminAuth(server.AdminToken, withDBLimit(newGetStatusHandler(ss))))
+
+		mux.HandleFunc("/api/v1/wallets/{id}/freeze", withAdminAuth(server.AdminToken, withDBLimit(newChangeStatusHandler(ss, "freeze"))))
+
+		mux.HandleFunc("/api/v1/wallets/{id}/unfreeze", withAdminAuth(server.AdminToken, withDBLimit(newChangeStatusHandler(ss, "unfreeze"))))
+
+		mux.HandleFunc("/api/v1/wallets/{id}/close", withAdminAuth(server.AdminToken, withDBLimit(newChangeStatusHandler(ss, "close"))))
+	}
+
+	// изменяющие запросы записываются в журнал аудита
+	var handler http.Handler = mux
+	if audit != nil {
+		handler = withAudit(audit, ds, server.AdminToken, mux)
+	}
 
 	srv := &http.Server{
 		Addr:         port,
-		Handler:      mux,
+		Handler:      handler,
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 5 * time.Second,
 		IdleTimeout:  60 * time.Second,
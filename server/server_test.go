@@ -4,7 +4,11 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,8 +19,8 @@ func TestGoodGetMethod(t *testing.T) {
 	uuid := "1"
 
 	ds.EXPECT().
-		Get(uuid).
-		Return(true, 3, nil).
+		GetWallet(uuid).
+		Return(true, datastorage.Wallet{Id: uuid, Balance: 3, Version: 7}, nil).
 		Once()
 
 	handler := newGetBalanceHandler(ds)
@@ -38,6 +42,7 @@ func TestGoodGetMethod(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 	assert.Equal(t, string(body), "3\n")
+	assert.Equal(t, `"7"`, res.Header.Get("ETag"))
 
 }
 
@@ -47,8 +52,8 @@ func TestUUIDUndefinedGetMethod(t *testing.T) {
 	uuid := "1"
 
 	ds.EXPECT().
-		Get(uuid).
-		Return(false, 0, nil).
+		GetWallet(uuid).
+		Return(false, datastorage.Wallet{}, nil).
 		Once()
 
 	handler := newGetBalanceHandler(ds)
@@ -80,8 +85,8 @@ func TestBDErrorGetMethod(t *testing.T) {
 	errorText := "some error text"
 
 	ds.EXPECT().
-		Get(uuid).
-		Return(false, 0, errors.New(errorText)).
+		GetWallet(uuid).
+		Return(false, datastorage.Wallet{}, errors.New(errorText)).
 		Once()
 
 	handler := newGetBalanceHandler(ds)
@@ -184,3 +189,215 @@ func TestWrongTextURLGetMethod(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, res.StatusCode)
 
 }
+
+func TestLimitExceededChangeBalanceMethod(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+
+	uuid := "1"
+
+	ds.EXPECT().
+		Check(uuid).
+		Return(true, nil).
+		Once()
+
+	ds.EXPECT().
+		ChangeBalance(-50.0, uuid, datastorage.OperationDetails{}).
+		Return(false, datastorage.Receipt{}, datastorage.LimitExceeded{Limit: datastorage.LimitDailyWithdrawal, Value: 100}).
+		Once()
+
+	handler := newChangeBalanceHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/wallet",
+		strings.NewReader(`{"walletId":"1","operationType":"WITHDRAW","amount":50}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "LIMIT_EXCEEDED: daily_withdrawal limit 100 exceeded\n", rec.Body.String())
+}
+
+func TestFrozenWalletChangeBalanceMethod(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+
+	uuid := "1"
+
+	ds.EXPECT().
+		Check(uuid).
+		Return(true, nil).
+		Once()
+
+	ds.EXPECT().
+		ChangeBalance(-50.0, uuid, datastorage.OperationDetails{}).
+		Return(false, datastorage.Receipt{}, datastorage.WalletNotActive{Status: datastorage.StatusFrozen}).
+		Once()
+
+	handler := newChangeBalanceHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/wallet",
+		strings.NewReader(`{"walletId":"1","operationType":"WITHDRAW","amount":50}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "WALLET_NOT_ACTIVE: wallet is frozen\n", rec.Body.String())
+}
+
+func TestJSONGetMethod(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+
+	uuid := "1"
+
+	ds.EXPECT().
+		GetWallet(uuid).
+		Return(true, datastorage.Wallet{
+			Id:              uuid,
+			Balance:         -30.555,
+			CreditLimit:     100,
+			AvailableCredit: 69.445,
+			Status:          datastorage.StatusActive,
+			Tier:            "default",
+			Owner:           "ivan",
+			CreatedAt:       time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+			Version:         4,
+		}, nil).
+		Once()
+
+	handler := newGetBalanceHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodGet,
+		"/api/v1/wallets/"+uuid,
+		nil,
+	)
+	req.Header.Set("Accept", "application/json")
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"4"`, res.Header.Get("ETag"))
+	assert.JSONEq(t,
+		`{"walletId":"1","balance":-30.56,"creditLimit":100,"availableCredit":69.44,"status":"active","tier":"default","owner":"ivan","createdAt":"2026-03-01T12:00:00Z","version":4}`,
+		rec.Body.String())
+}
+
+func TestGoodTransferChangeBalanceMethod(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+
+	ds.EXPECT().
+		Check("1").
+		Return(true, nil).
+		Once()
+
+	ds.EXPECT().
+		Transfer(25.5, "1", "2", datastorage.OperationDetails{Reference: "order-7", ExternalId: "pay-7"}).
+		Return(true, datastorage.Receipt{}, nil).
+		Once()
+
+	handler := newChangeBalanceHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/wallet",
+		strings.NewReader(`{"walletId":"1","operationType":"TRANSFER","amount":25.5,"toWalletId":"2","reference":"order-7","externalId":"pay-7"}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Operation complit\n", rec.Body.String())
+}
+
+func TestWrongTypeChangeBalanceMethod(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+
+	ds.EXPECT().
+		Check("1").
+		Return(true, nil).
+		Once()
+
+	handler := newChangeBalanceHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/wallet",
+		strings.NewReader(`{"walletId":"1","operationType":"SEND","amount":1}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "wrong operation type\n", rec.Body.String())
+}
+
+func TestDuplicateExternalIdChangeBalanceMethod(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+
+	ds.EXPECT().
+		Check("1").
+		Return(true, nil).
+		Once()
+
+	ds.EXPECT().
+		ChangeBalance(10.0, "1", datastorage.OperationDetails{ExternalId: "pay-7"}).
+		Return(false, datastorage.Receipt{}, datastorage.DuplicateExternalId{ExternalId: "pay-7"}).
+		Once()
+
+	handler := newChangeBalanceHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/wallet",
+		strings.NewReader(`{"walletId":"1","operationType":"DEPOSIT","amount":10,"externalId":"pay-7"}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "DUPLICATE_EXTERNAL_ID: operation with externalId pay-7 already exists\n", rec.Body.String())
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/wallet",
+		strings.NewReader(`{"walletId":"1","operationType":"DEPOSIT","amount":10,"reference":"`+strings.Repeat("r", 256)+`"}`),
+	)
+
+	rec = httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "reference must be at most 255 characters\n", rec.Body.String())
+}
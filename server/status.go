@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+type StatusStorage interface {
+	GetStatus(uuid string) (bool, datastorage.WalletStatus, error)
+	ChangeStatus(uuid string, change datastorage.StatusChange) error
+}
+
+type changeStatusMessage struct {
+	Reason        string `json:"reason"`
+	BlockDeposits bool   `json:"blockDeposits"`
+}
+
+var statusActions = map[string]string{
+	"freeze":   datastorage.StatusFrozen,
+	"unfreeze": datastorage.StatusActive,
+	"close":    datastorage.StatusClosed,
+}
+
+func newGetStatusHandler(ds StatusStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		uuid, ok := walletPathId(r.URL.Path, "status") // проверяем, что запрос имеет вид /api/v1/wallets/{WALLET_UUID}/status
+		if !ok {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		got, status, err := ds.GetStatus(uuid)
+
+		if err != nil {
+			log.Println("error in get status method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !got {
+			log.Println("uuid undefined")
+			http.Error(w, "uuid undefined", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+// newChangeStatusHandler обрабатывает freeze, unfreeze и close.
+// Кто изменил статус берётся из заголовка X-Actor, причина - из тела запроса.
+func newChangeStatusHandler(ds StatusStorage, action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		uuid, ok := walletPathId(r.URL.Path, action) // проверяем, что запрос имеет вид /api/v1/wallets/{WALLET_UUID}/{action}
+		if !ok {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		var msg changeStatusMessage
+		err := json.NewDecoder(r.Body).Decode(&msg)
+		if err != nil {
+			log.Println("wrong json")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if msg.Reason == "" {
+			log.Println("empty reason")
+			http.Error(w, "reason is required", http.StatusBadRequest)
+			return
+		}
+
+		change := datastorage.StatusChange{
+			Status:        statusActions[action],
+			BlockDeposits: msg.BlockDeposits,
+			Actor:         adminActor(r),
+			Reason:        msg.Reason,
+		}
+
+		log.Println(action, "wallet", uuid, "by", change.Actor)
+
+		err = ds.ChangeStatus(uuid, change)
+
+		if errors.As(err, &datastorage.UUIDUndefined{}) {
+			log.Println("uuid undefined")
+			http.Error(w, "uuid undefined", http.StatusBadRequest)
+			return
+		}
+
+		var statusErr datastorage.WalletNotActive
+		if errors.As(err, &statusErr) {
+			log.Println("wallet not active:", statusErr.Status)
+			http.Error(w, "WALLET_NOT_ACTIVE: "+statusErr.Error(), http.StatusConflict)
+			return
+		}
+
+		if errors.As(err, &datastorage.BalanceNotZero{}) {
+			log.Println("close wallet with balance")
+			http.Error(w, "BALANCE_NOT_ZERO: "+err.Error(), http.StatusConflict)
+			return
+		}
+
+		if err != nil {
+			log.Println("error in change status method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		log.Println("Status changed")
+		fmt.Fprintln(w, "Status changed")
+	}
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoodFreezeMethod(t *testing.T) {
+	ds := NewMockStatusStorage(t)
+
+	uuid := "1"
+
+	ds.EXPECT().
+		ChangeStatus(uuid, datastorage.StatusChange{
+			Status:        datastorage.StatusFrozen,
+			BlockDeposits: true,
+			Actor:         "ivan",
+			Reason:        "fraud check",
+		}).
+		Return(nil).
+		Once()
+
+	handler := newChangeStatusHandler(ds, "freeze")
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/"+uuid+"/freeze",
+		strings.NewReader(`{"reason":"fraud check","blockDeposits":true}`),
+	)
+	req.Header.Set("X-Actor", "ivan")
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Status changed\n", rec.Body.String())
+}
+
+func TestNoReasonFreezeMethod(t *testing.T) {
+	ds := NewMockStatusStorage(t)
+
+	handler := newChangeStatusHandler(ds, "freeze")
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/1/freeze",
+		strings.NewReader(`{}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "reason is required\n", rec.Body.String())
+}
+
+func TestBalanceNotZeroCloseMethod(t *testing.T) {
+	ds := NewMockStatusStorage(t)
+
+	uuid := "1"
+
+	ds.EXPECT().
+		ChangeStatus(uuid, datastorage.StatusChange{
+			Status: datastorage.StatusClosed,
+			Actor:  "admin",
+			Reason: "customer request",
+		}).
+		Return(datastorage.BalanceNotZero{}).
+		Once()
+
+	handler := newChangeStatusHandler(ds, "close")
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/"+uuid+"/close",
+		strings.NewReader(`{"reason":"customer request"}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+	assert.Equal(t, "BALANCE_NOT_ZERO: balance is not zero\n", rec.Body.String())
+}
+
+func TestWrongPathUnfreezeMethod(t *testing.T) {
+	ds := NewMockStatusStorage(t)
+
+	handler := newChangeStatusHandler(ds, "unfreeze")
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/wallets/1/freeze",
+		strings.NewReader(`{"reason":"ok"}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+}
+
+func TestGoodGetStatusMethod(t *testing.T) {
+	ds := NewMockStatusStorage(t)
+
+	uuid := "1"
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	ds.EXPECT().
+		GetStatus(uuid).
+		Return(true, datastorage.WalletStatus{
+			Status: datastorage.StatusFrozen,
+			History: []datastorage.StatusChange{
+				{Status: datastorage.StatusFrozen, Actor: "ivan", Reason: "check", CreatedAt: at},
+			},
+		}, nil).
+		Once()
+
+	handler := newGetStatusHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodGet,
+		"/api/v1/wallets/"+uuid+"/status",
+		nil,
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t,
+		`{"status":"frozen","blockDeposits":false,"history":[{"status":"frozen","blockDeposits":false,"actor":"ivan","reason":"check","createdAt":"2026-01-02T03:04:05Z"}]}`,
+		rec.Body.String())
+}
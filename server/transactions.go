@@ -0,0 +1,181 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+const (
+	maxReferenceLength   = 255
+	maxDescriptionLength = 1000
+	maxExternalIdLength  = 255
+
+	defaultTransactionsPage = 100
+	maxTransactionsPage     = 1000
+)
+
+type OperationStorage interface {
+	FindOperations(filter datastorage.OperationFilter) ([]datastorage.OperationRecord, error)
+}
+
+type ReversalStorage interface {
+	ReverseOperation(id int64, amount float64, details datastorage.OperationDetails) (datastorage.Reversal, error)
+}
+
+// reverseMessage - тело запроса отмены. Amount 0 отменяет весь остаток операции.
+type reverseMessage struct {
+	Amount float64 `json:"amount"`
+	datastorage.OperationDetails
+}
+
+// validDetails проверяет длину сведений об операции
+func validDetails(details datastorage.OperationDetails) string {
+	switch {
+	case utf8.RuneCountInString(details.Reference) > maxReferenceLength:
+		return "reference must be at most " + strconv.Itoa(maxReferenceLength) + " characters"
+	case utf8.RuneCountInString(details.Description) > maxDescriptionLength:
+		return "description must be at most " + strconv.Itoa(maxDescriptionLength) + " characters"
+	case utf8.RuneCountInString(details.ExternalId) > maxExternalIdLength:
+		return "externalId must be at most " + strconv.Itoa(maxExternalIdLength) + " characters"
+	}
+
+	return ""
+}
+
+// newFindTransactionsHandler ищет проведённые операции по walletId, externalId и reference,
+// начиная с последних. Нужен хотя бы один из этих параметров.
+func newFindTransactionsHandler(ds OperationStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.URL.Path != "/api/v1/transactions" {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		query := r.URL.Query()
+
+		filter := datastorage.OperationFilter{
+			WalletId:   query.Get("walletId"),
+			ExternalId: query.Get("externalId"),
+			Reference:  query.Get("reference"),
+			Limit:      defaultTransactionsPage,
+		}
+
+		log.Println("find transactions:", r.URL.RawQuery)
+
+		if filter.WalletId == "" && filter.ExternalId == "" && filter.Reference == "" {
+			log.Println("no transactions filter")
+			http.Error(w, "walletId, externalId or reference is required", http.StatusBadRequest)
+			return
+		}
+
+		if value := query.Get("limit"); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit <= 0 || limit > maxTransactionsPage {
+				log.Println("wrong limit:", value)
+				http.Error(w, "limit must be from 1 to "+strconv.Itoa(maxTransactionsPage), http.StatusBadRequest)
+				return
+			}
+			filter.Limit = limit
+		}
+
+		operations, err := ds.FindOperations(filter)
+
+		if err != nil {
+			log.Println("error in find operations method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if operations == nil {
+			operations = []datastorage.OperationRecord{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(operations)
+	}
+}
+
+// newReverseHandler проводит компенсирующую операцию для /api/v1/transactions/{id}/reverse
+func newReverseHandler(ds ReversalStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+		if len(parts) != 5 || r.URL.Path != "/api/v1/transactions/"+parts[3]+"/reverse" { // проверяем, что запрос имеет вид /api/v1/transactions/{ID}/reverse
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		id, err := strconv.ParseInt(parts[3], 10, 64)
+		if err != nil || id <= 0 {
+			log.Println("wrong operation id:", parts[3])
+			http.NotFound(w, r)
+			return
+		}
+
+		// тело необязательно: без него отменяется весь остаток
+		var msg reverseMessage
+		if err = json.NewDecoder(r.Body).Decode(&msg); err != nil && !errors.Is(err, io.EOF) {
+			log.Println("wrong json")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if msg.Amount < 0 {
+			log.Println("wrong json amount:", msg.Amount)
+			http.Error(w, "amount must not be negative", http.StatusBadRequest)
+			return
+		}
+
+		if reason := validDetails(msg.OperationDetails); reason != "" {
+			log.Println("wrong operation details:", reason)
+			http.Error(w, reason, http.StatusBadRequest)
+			return
+		}
+
+		// сумма меньше копейки не должна превратиться в отмену всего остатка
+		if msg.Amount > 0 && msg.Amount < 0.01 {
+			log.Println("wrong json amount:", msg.Amount)
+			http.Error(w, "amount must be at least 0.01", http.StatusBadRequest)
+			return
+		}
+
+		msg.Amount = math.Floor(msg.Amount*100) / 100
+
+		log.Println("reverse operation", id, "amount", msg.Amount, "by", adminActor(r))
+
+		msg.IdempotencyKey = r.Header.Get(idempotencyHeader)
+		reversal, err := ds.ReverseOperation(id, msg.Amount, msg.OperationDetails)
+
+		if err != nil {
+			writeOperationError(w, err)
+			return
+		}
+
+		log.Println("Operation reversed:", id)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reversal)
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoodFindTransactions(t *testing.T) {
+	ds := NewMockOperationStorage(t)
+	ds.EXPECT().
+		FindOperations(datastorage.OperationFilter{WalletId: "asd1", ExternalId: "pay-7", Limit: 5}).
+		Return([]datastorage.OperationRecord{{
+			Id:               12,
+			WalletId:         "asd1",
+			Amount:           -25.5,
+			OperationDetails: datastorage.OperationDetails{Reference: "order-7", ExternalId: "pay-7"},
+		}}, nil).
+		Once()
+	ds.EXPECT().
+		FindOperations(datastorage.OperationFilter{Reference: "order-8", Limit: defaultTransactionsPage}).
+		Return(nil, nil).
+		Once()
+
+	handler := newFindTransactionsHandler(ds)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/transactions?walletId=asd1&externalId=pay-7&limit=5", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var operations []datastorage.OperationRecord
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&operations))
+	require.Len(t, operations, 1)
+	assert.Equal(t, int64(12), operations[0].Id)
+	assert.Equal(t, "order-7", operations[0].Reference)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/transactions?reference=order-8", nil))
+
+	assert.Equal(t, "[]\n", rec.Body.String())
+}
+
+func TestWrongFindTransactions(t *testing.T) {
+	handler := newFindTransactionsHandler(NewMockOperationStorage(t))
+
+	cases := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{http.MethodPost, "/api/v1/transactions?walletId=asd1", http.StatusMethodNotAllowed, "Invalid request method\n"},
+		{http.MethodGet, "/api/v1/transactions/x", http.StatusNotFound, "404 page not found\n"},
+		{http.MethodGet, "/api/v1/transactions", http.StatusBadRequest, "walletId, externalId or reference is required\n"},
+		{http.MethodGet, "/api/v1/transactions?walletId=asd1&limit=x", http.StatusBadRequest, "limit must be from 1 to 1000\n"},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+
+		assert.Equal(t, c.status, rec.Code, c.path)
+		assert.Equal(t, c.body, rec.Body.String(), c.path)
+	}
+}
+
+func TestGoodReverse(t *testing.T) {
+	reversalOf := int64(12)
+
+	ds := NewMockReversalStorage(t)
+	ds.EXPECT().
+		ReverseOperation(int64(12), 10.5, datastorage.OperationDetails{Description: "ошибочное пополнение"}).
+		Return(datastorage.Reversal{
+			OperationId: 12,
+			Amount:      10.5,
+			Remaining:   4.5,
+			Operations:  []datastorage.OperationRecord{{Id: 20, WalletId: "asd1", Amount: -10.5, ReversalOf: &reversalOf}},
+		}, nil).
+		Once()
+	ds.EXPECT().ReverseOperation(int64(13), float64(0), datastorage.OperationDetails{}).Return(datastorage.Reversal{OperationId: 13}, nil).Once()
+
+	handler := newReverseHandler(ds)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/transactions/12/reverse",
+		strings.NewReader(`{"amount":10.509,"description":"ошибочное пополнение"}`)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var reversal datastorage.Reversal
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&reversal))
+	assert.Equal(t, 4.5, reversal.Remaining)
+	require.Len(t, reversal.Operations, 1)
+	assert.Equal(t, &reversalOf, reversal.Operations[0].ReversalOf)
+
+	// без тела отменяется весь остаток
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/transactions/13/reverse", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestWrongReverse(t *testing.T) {
+	ds := NewMockReversalStorage(t)
+	ds.EXPECT().ReverseOperation(int64(1), float64(0), datastorage.OperationDetails{}).Return(datastorage.Reversal{}, datastorage.OperationUndefined{}).Once()
+	ds.EXPECT().ReverseOperation(int64(2), float64(0), datastorage.OperationDetails{}).Return(datastorage.Reversal{}, datastorage.AlreadyReversed{}).Once()
+	ds.EXPECT().ReverseOperation(int64(3), float64(50), datastorage.OperationDetails{}).Return(datastorage.Reversal{}, datastorage.ReversalExceeded{Remaining: 20}).Once()
+	ds.EXPECT().ReverseOperation(int64(4), float64(0), datastorage.OperationDetails{}).Return(datastorage.Reversal{}, datastorage.InsufficientFunds{}).Once()
+
+	handler := newReverseHandler(ds)
+
+	cases := []struct {
+		method, path, body string
+		status             int
+		response           string
+	}{
+		{http.MethodGet, "/api/v1/transactions/1/reverse", "", http.StatusMethodNotAllowed, "Invalid request method\n"},
+		{http.MethodPost, "/api/v1/transactions/x/reverse", "", http.StatusNotFound, "404 page not found\n"},
+		{http.MethodPost, "/api/v1/transactions/1/reverse", `{"amount":-1}`, http.StatusBadRequest, "amount must not be negative\n"},
+		{http.MethodPost, "/api/v1/transactions/1/reverse", `{"amount":0.001}`, http.StatusBadRequest, "amount must be at least 0.01\n"},
+		{http.MethodPost, "/api/v1/transactions/1/reverse", "", http.StatusNotFound, "OPERATION_UNDEFINED: operation undefined\n"},
+		{http.MethodPost, "/api/v1/transactions/2/reverse", "", http.StatusConflict, "ALREADY_REVERSED: operation is already fully reversed\n"},
+		{http.MethodPost, "/api/v1/transactions/3/reverse", `{"amount":50}`, http.StatusBadRequest, "REVERSAL_EXCEEDED: amount exceeds remaining 20\n"},
+		{http.MethodPost, "/api/v1/transactions/4/reverse", "", http.StatusBadRequest, "INSUFFICIENT_FUNDS: balance small for operation\n"},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, strings.NewReader(c.body)))
+
+		assert.Equal(t, c.status, rec.Code, c.path+" "+c.body)
+		assert.Equal(t, c.response, rec.Body.String(), c.path+" "+c.body)
+	}
+}
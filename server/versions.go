@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// walletETag - сильный ETag версии кошелька
+func walletETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// setWalletETag отдаёт версию кошелька в заголовке ETag
+func setWalletETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", walletETag(version))
+}
+
+// ifMatchVersions разбирает заголовок If-Match в версии кошелька. nil - условия нет
+// (заголовка нет или он равен *). Слабые и чужие ETag ни с какой версией не совпадают,
+// поэтому заголовок только из них даёт пустой список, и операция отклоняется.
+func ifMatchVersions(header string) []int64 {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := []int64{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+
+		version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err == nil {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatchVersions(t *testing.T) {
+	assert.Nil(t, ifMatchVersions(""))
+	assert.Nil(t, ifMatchVersions(" * "))
+	assert.Equal(t, []int64{7}, ifMatchVersions(`"7"`))
+	assert.Equal(t, []int64{3, 5}, ifMatchVersions(`"3", W/"4", "5"`))
+	assert.Equal(t, []int64{}, ifMatchVersions(`W/"4"`))
+	assert.Equal(t, []int64{}, ifMatchVersions(`"abc", 7`))
+}
+
+func TestIfMatchChangeBalanceMethod(t *testing.T) {
+	ds := NewMockWalletStorage(t)
+
+	ds.EXPECT().Check("1").Return(true, nil).Twice()
+	ds.EXPECT().
+		ChangeBalance(-100.0, "1", datastorage.OperationDetails{IfMatch: []int64{7}}).
+		Return(true, datastorage.Receipt{OperationId: 8, Gross: 100, Net: 100}, nil).
+		Once()
+	ds.EXPECT().
+		Transfer(50.0, "1", "2", datastorage.OperationDetails{IfMatch: []int64{7}}).
+		Return(false, datastorage.Receipt{}, datastorage.VersionMismatch{Version: 8}).
+		Once()
+
+	handler := newChangeBalanceHandler(ds)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/wallet",
+		strings.NewReader(`{"walletId":"1","operationType":"WITHDRAW","amount":100}`))
+	req.Header.Set("If-Match", `"7"`)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Operation complit\n", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/wallets/wallet",
+		strings.NewReader(`{"walletId":"1","operationType":"TRANSFER","amount":50,"toWalletId":"2"}`))
+	req.Header.Set("If-Match", `"7"`)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t, "VERSION_MISMATCH: wallet version 8 does not match If-Match\n", rec.Body.String())
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+const (
+	defaultWalletsPage = 100
+	maxWalletsPage     = 1000
+)
+
+type walletsPage struct {
+	Wallets    []datastorage.Wallet `json:"wallets"`
+	Total      int64                `json:"total"`
+	NextCursor string               `json:"nextCursor,omitempty"`
+}
+
+// walletsCursor - содержимое параметра cursor: сортировка и ключ последнего кошелька страницы
+type walletsCursor struct {
+	Sort      string    `json:"sort"`
+	Id        string    `json:"id"`
+	Balance   float64   `json:"balance"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func encodeWalletsCursor(sort string, wallet datastorage.Wallet) string {
+	data, _ := json.Marshal(walletsCursor{Sort: sort, Id: wallet.Id, Balance: wallet.Balance, CreatedAt: wallet.CreatedAt})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeWalletsCursor(value, sort string) (*datastorage.WalletCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("wrong cursor")
+	}
+
+	var cursor walletsCursor
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.Id == "" {
+		return nil, errors.New("wrong cursor")
+	}
+
+	if cursor.Sort != sort {
+		return nil, errors.New("cursor does not match sort")
+	}
+
+	return &datastorage.WalletCursor{Id: cursor.Id, Balance: cursor.Balance, CreatedAt: cursor.CreatedAt}, nil
+}
+
+// parseTime принимает время в RFC 3339 или дату вида 2006-01-02
+func parseTime(value string) (time.Time, error) {
+	if created, err := time.Parse(time.RFC3339, value); err == nil {
+		return created, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// walletFilter разбирает параметры запроса списка кошельков
+func walletFilter(query url.Values) (datastorage.WalletFilter, string, error) {
+	filter := datastorage.WalletFilter{
+		Status:   query.Get("status"),
+		Owner:    query.Get("owner"),
+		IdPrefix: query.Get("idPrefix"),
+		Limit:    defaultWalletsPage,
+	}
+
+	for name, bound := range map[string]**float64{"minBalance": &filter.MinBalance, "maxBalance": &filter.MaxBalance} {
+		if value := query.Get(name); value != "" {
+			balance, err := strconv.ParseFloat(value, 64)
+			if err != nil || math.IsNaN(balance) || math.IsInf(balance, 0) {
+				return filter, "", errors.New(name + " must be a number")
+			}
+			*bound = &balance
+		}
+	}
+
+	// metadata.KEY=VALUE отбирает кошельки с такой парой в метаданных
+	for name, values := range query {
+		key, ok := strings.CutPrefix(name, "metadata.")
+		if !ok {
+			continue
+		}
+
+		if err := datastorage.ValidateMetadataKey(key); err != nil {
+			return filter, "", err
+		}
+
+		if filter.Metadata == nil {
+			filter.Metadata = map[string]string{}
+		}
+		filter.Metadata[key] = values[0]
+	}
+
+	switch filter.Status {
+	case "", datastorage.StatusActive, datastorage.StatusFrozen, datastorage.StatusClosed:
+	default:
+		return filter, "", errors.New("status must be active, frozen or closed")
+	}
+
+	for name, bound := range map[string]*time.Time{"createdFrom": &filter.CreatedFrom, "createdTo": &filter.CreatedTo} {
+		if value := query.Get(name); value != "" {
+			created, err := parseTime(value)
+			if err != nil {
+				return filter, "", errors.New(name + " must be RFC 3339 time or date")
+			}
+			*bound = created
+		}
+	}
+
+	sort := query.Get("sort")
+	if sort == "" {
+		sort = datastorage.SortById
+	}
+
+	filter.Sort, filter.Desc = strings.CutPrefix(sort, "-")
+
+	switch filter.Sort {
+	case datastorage.SortById, datastorage.SortByBalance, datastorage.SortByCreatedAt:
+	default:
+		return filter, "", errors.New("sort must be id, balance or createdAt, with - for descending order")
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxWalletsPage {
+			return filter, "", errors.New("limit must be from 1 to " + strconv.Itoa(maxWalletsPage))
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		after, err := decodeWalletsCursor(value, sort)
+		if err != nil {
+			return filter, "", err
+		}
+		filter.After = after
+	}
+
+	return filter, sort, nil
+}
+
+// newListWalletsHandler отдаёт страницу кошельков под фильтром и их общее число.
+// Следующая страница запрашивается с теми же параметрами и cursor из ответа.
+func newListWalletsHandler(ds WalletStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		log.Println("list wallets:", r.URL.RawQuery)
+
+		filter, sort, err := walletFilter(r.URL.Query())
+		if err != nil {
+			log.Println("wrong list query:", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		page, err := ds.ListWallets(filter)
+
+		if err != nil {
+			log.Println("error in list wallets method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := walletsPage{Wallets: page.Wallets, Total: page.Total}
+
+		if response.Wallets == nil {
+			response.Wallets = []datastorage.Wallet{}
+		}
+
+		if page.More && len(page.Wallets) > 0 {
+			response.NextCursor = encodeWalletsCursor(sort, page.Wallets[len(page.Wallets)-1])
+		}
+
+		for i := range response.Wallets {
+			response.Wallets[i].Balance = math.Floor(response.Wallets[i].Balance*100) / 100
+			response.Wallets[i].AvailableCredit = math.Floor(response.Wallets[i].AvailableCredit*100) / 100
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoodListWallets(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	minBalance := 10.0
+
+	ds := NewMockWalletStorage(t)
+	ds.EXPECT().ListWallets(datastorage.WalletFilter{
+		MinBalance:  &minBalance,
+		Status:      datastorage.StatusActive,
+		Owner:       "ivan",
+		IdPrefix:    "asd",
+		CreatedFrom: created,
+		Sort:        datastorage.SortByBalance,
+		Desc:        true,
+		Metadata:    map[string]string{"campaign": "spring"},
+		Limit:       2,
+	}).Return(datastorage.WalletPage{
+		Wallets: []datastorage.Wallet{
+			{Id: "asd2", Balance: 300.129, CreatedAt: created},
+			{Id: "asd1", Balance: 200, CreatedAt: created},
+		},
+		Total: 3,
+		More:  true,
+	}, nil).Once()
+
+	handler := newListWalletsHandler(ds)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+		"/api/v1/wallets?minBalance=10&status=active&owner=ivan&idPrefix=asd&createdFrom=2026-03-01T12:00:00Z&sort=-balance&limit=2&metadata.campaign=spring", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var page walletsPage
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	assert.Equal(t, int64(3), page.Total)
+	assert.Len(t, page.Wallets, 2)
+	assert.Equal(t, 300.12, page.Wallets[0].Balance)
+	require.NotEmpty(t, page.NextCursor)
+
+	// следующая страница продолжается после последнего кошелька
+	ds.EXPECT().ListWallets(datastorage.WalletFilter{
+		Sort:  datastorage.SortByBalance,
+		Desc:  true,
+		Limit: defaultWalletsPage,
+		After: &datastorage.WalletCursor{Id: "asd1", Balance: 200, CreatedAt: created},
+	}).Return(datastorage.WalletPage{Wallets: []datastorage.Wallet{{Id: "asd0"}}, Total: 3}, nil).Once()
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/wallets?sort=-balance&cursor="+page.NextCursor, nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	page = walletsPage{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	assert.Len(t, page.Wallets, 1)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestWrongListWallets(t *testing.T) {
+	handler := newListWalletsHandler(NewMockWalletStorage(t))
+
+	cursor := encodeWalletsCursor(datastorage.SortById, datastorage.Wallet{Id: "asd1"})
+
+	cases := []struct {
+		method, query string
+		status        int
+		body          string
+	}{
+		{http.MethodPost, "", http.StatusMethodNotAllowed, "Invalid request method\n"},
+		{http.MethodGet, "?minBalance=x", http.StatusBadRequest, "minBalance must be a number\n"},
+		{http.MethodGet, "?maxBalance=NaN", http.StatusBadRequest, "maxBalance must be a number\n"},
+		{http.MethodGet, "?status=deleted", http.StatusBadRequest, "status must be active, frozen or closed\n"},
+		{http.MethodGet, "?createdTo=yesterday", http.StatusBadRequest, "createdTo must be RFC 3339 time or date\n"},
+		{http.MethodGet, "?sort=owner", http.StatusBadRequest, "sort must be id, balance or createdAt, with - for descending order\n"},
+		{http.MethodGet, "?metadata.a%20b=c", http.StatusBadRequest, "wrong metadata: key \"a b\" must be 1 to 40 letters, digits, _ or -\n"},
+		{http.MethodGet, "?limit=0", http.StatusBadRequest, "limit must be from 1 to 1000\n"},
+		{http.MethodGet, "?cursor=!!!", http.StatusBadRequest, "wrong cursor\n"},
+		{http.MethodGet, "?sort=balance&cursor=" + cursor, http.StatusBadRequest, "cursor does not match sort\n"},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(c.method, "/api/v1/wallets"+c.query, nil))
+
+		assert.Equal(t, c.status, rec.Code, c.query)
+		assert.Equal(t, c.body, rec.Body.String(), c.query)
+	}
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+const maxDeliveriesPage = 100
+
+type WebhookStorage interface {
+	CreateWebhook(subscription datastorage.WebhookSubscription) (datastorage.WebhookSubscription, error)
+	ListWebhooks() ([]datastorage.WebhookSubscription, error)
+	DeleteWebhook(id int64) (bool, error)
+	WebhookDeliveries(id int64, status string, limit int) (bool, []datastorage.WebhookDeliveryLog, error)
+}
+
+type createWebhookMessage struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"eventTypes"`
+	WalletIds  []string `json:"walletIds"`
+}
+
+var webhookEventTypes = map[string]bool{
+	datastorage.EventWalletCreated: true,
+	datastorage.EventDeposited:     true,
+	datastorage.EventWithdrawn:     true,
+}
+
+// validWebhook возвращает текст ошибки или пустую строку, если подписка корректна
+func validWebhook(msg createWebhookMessage) string {
+	target, err := url.Parse(msg.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "url must be absolute http or https URL"
+	}
+
+	if msg.Secret != "" && len(msg.Secret) < 16 {
+		return "secret must be at least 16 characters"
+	}
+
+	for _, eventType := range msg.EventTypes {
+		if !webhookEventTypes[eventType] {
+			return "unknown event type: " + eventType
+		}
+	}
+
+	return ""
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// pathId достаёт id из пути вида {prefix}/{ID} или {prefix}/{ID}/{suffix}
+func pathId(path, prefix, suffix string) (int64, bool) {
+	rest, ok := strings.CutPrefix(path, prefix+"/")
+	if !ok {
+		return 0, false
+	}
+
+	value, tail, nested := strings.Cut(rest, "/")
+	if tail != suffix || nested != (suffix != "") {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	return id, err == nil
+}
+
+func newWebhooksHandler(ds WebhookStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/webhooks" {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			subscriptions, err := ds.ListWebhooks()
+
+			if err != nil {
+				log.Println("error in list webhooks method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if subscriptions == nil {
+				subscriptions = []datastorage.WebhookSubscription{}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(subscriptions)
+
+		case http.MethodPost:
+			var msg createWebhookMessage
+			err := json.NewDecoder(r.Body).Decode(&msg)
+			if err != nil {
+				log.Println("wrong json")
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if reason := validWebhook(msg); reason != "" {
+				log.Println("wrong webhook:", reason)
+				http.Error(w, reason, http.StatusBadRequest)
+				return
+			}
+
+			if msg.Secret == "" {
+				msg.Secret, err = newWebhookSecret()
+				if err != nil {
+					log.Println("error in secret generation:", err)
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+			}
+
+			subscription, err := ds.CreateWebhook(datastorage.WebhookSubscription{
+				URL:        msg.URL,
+				Secret:     msg.Secret,
+				EventTypes: msg.EventTypes,
+				WalletIds:  msg.WalletIds,
+			})
+
+			if err != nil {
+				log.Println("error in create webhook method:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			log.Println("Webhook created:", subscription.Id)
+
+			// секрет отдаётся только при создании подписки
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(subscription)
+
+		default:
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func newDeleteWebhookHandler(ds WebhookStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathId(r.URL.Path, "/api/v1/webhooks", "") // проверяем, что запрос имеет вид /api/v1/webhooks/{ID}
+		if !ok {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		deleted, err := ds.DeleteWebhook(id)
+
+		if err != nil {
+			log.Println("error in delete webhook method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !deleted {
+			log.Println("webhook undefined:", id)
+			http.Error(w, "webhook undefined", http.StatusNotFound)
+			return
+		}
+
+		log.Println("Webhook deleted:", id)
+		fmt.Fprintln(w, "Webhook deleted")
+	}
+}
+
+func newWebhookDeliveriesHandler(ds WebhookStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			log.Println("wrong method on path:", r.URL.Path)
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathId(r.URL.Path, "/api/v1/webhooks", "deliveries") // проверяем, что запрос имеет вид /api/v1/webhooks/{ID}/deliveries
+		if !ok {
+			log.Println("wrong path:", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		status := r.URL.Query().Get("status")
+		if status != "" && status != datastorage.DeliveryPending && status != datastorage.DeliveryDelivered && status != datastorage.DeliveryDead {
+			log.Println("wrong delivery status:", status)
+			http.Error(w, "status must be pending, delivered or dead", http.StatusBadRequest)
+			return
+		}
+
+		got, deliveries, err := ds.WebhookDeliveries(id, status, maxDeliveriesPage)
+
+		if err != nil {
+			log.Println("error in webhook deliveries method:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !got {
+			log.Println("webhook undefined:", id)
+			http.Error(w, "webhook undefined", http.StatusNotFound)
+			return
+		}
+
+		if deliveries == nil {
+			deliveries = []datastorage.WebhookDeliveryLog{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(deliveries)
+	}
+}
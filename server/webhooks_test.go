@@ -0,0 +1,152 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGoodCreateWebhook(t *testing.T) {
+	ds := NewMockWebhookStorage(t)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	ds.EXPECT().
+		CreateWebhook(mock.MatchedBy(func(s datastorage.WebhookSubscription) bool {
+			return s.URL == "https://partner.example/hook" && len(s.Secret) == 64 &&
+				len(s.EventTypes) == 1 && s.EventTypes[0] == datastorage.EventDeposited &&
+				len(s.WalletIds) == 1 && s.WalletIds[0] == "asd1"
+		})).
+		RunAndReturn(func(s datastorage.WebhookSubscription) (datastorage.WebhookSubscription, error) {
+			s.Id = 3
+			s.CreatedAt = created
+			return s, nil
+		}).
+		Once()
+
+	handler := newWebhooksHandler(ds)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/webhooks",
+		strings.NewReader(`{"url":"https://partner.example/hook","eventTypes":["wallet.deposited"],"walletIds":["asd1"]}`),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var subscription datastorage.WebhookSubscription
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&subscription))
+	assert.Equal(t, int64(3), subscription.Id)
+	assert.Len(t, subscription.Secret, 64)
+}
+
+func TestWrongCreateWebhook(t *testing.T) {
+	cases := map[string]string{
+		`{"url":"ftp://partner.example/hook"}`:                            "url must be absolute http or https URL\n",
+		`{"url":"/hook"}`:                                                 "url must be absolute http or https URL\n",
+		`{"url":"https://partner.example","secret":"short"}`:              "secret must be at least 16 characters\n",
+		`{"url":"https://partner.example","eventTypes":["wallet.eaten"]}`: "unknown event type: wallet.eaten\n",
+	}
+
+	for body, expected := range cases {
+		ds := NewMockWebhookStorage(t)
+
+		handler := newWebhooksHandler(ds)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(body))
+
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+		assert.Equal(t, expected, rec.Body.String())
+	}
+}
+
+func TestDeleteUndefinedWebhook(t *testing.T) {
+	ds := NewMockWebhookStorage(t)
+
+	ds.EXPECT().
+		DeleteWebhook(int64(7)).
+		Return(false, nil).
+		Once()
+
+	handler := newDeleteWebhookHandler(ds)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/webhooks/7", nil)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	assert.Equal(t, "webhook undefined\n", rec.Body.String())
+}
+
+func TestGoodWebhookDeliveries(t *testing.T) {
+	ds := NewMockWebhookStorage(t)
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	code := 500
+	errText := "webhook responded with status 500"
+
+	ds.EXPECT().
+		WebhookDeliveries(int64(7), datastorage.DeliveryDead, maxDeliveriesPage).
+		Return(true, []datastorage.WebhookDeliveryLog{{
+			Id:            1,
+			EventId:       10,
+			EventType:     datastorage.EventWithdrawn,
+			WalletId:      "asd1",
+			Status:        datastorage.DeliveryDead,
+			NextAttemptAt: at,
+			Attempts:      []datastorage.WebhookAttempt{{AttemptedAt: at, StatusCode: &code, Error: &errText}},
+		}}, nil).
+		Once()
+
+	handler := newWebhookDeliveriesHandler(ds)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/7/deliveries?status=dead", nil)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `[{"id":1,"eventId":10,"eventType":"wallet.withdrawn","walletId":"asd1","status":"dead",
+		"nextAttemptAt":"2026-01-02T03:04:05Z",
+		"attempts":[{"attemptedAt":"2026-01-02T03:04:05Z","statusCode":500,"error":"webhook responded with status 500"}]}]`,
+		rec.Body.String())
+}
+
+func TestWrongPathWebhookDeliveries(t *testing.T) {
+	ds := NewMockWebhookStorage(t)
+
+	handler := newWebhookDeliveriesHandler(ds)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/abc/deliveries", nil)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+}
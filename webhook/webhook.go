@@ -0,0 +1,154 @@
+// Package webhook доставляет события кошельков подписчикам.
+//
+// Каждый запрос подписан: заголовок X-Webhook-Signature содержит
+// "sha256=" и HMAC-SHA256 от строки "{X-Webhook-Timestamp}.{тело запроса}"
+// на секрете подписки. Получатель должен проверить подпись и отбросить
+// запросы со старым временем, а повторы - по X-Webhook-Delivery.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+)
+
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+type Store interface {
+	DeliverWebhooks(limit int, send func(delivery datastorage.WebhookDelivery) (int, error), retry func(attempts int) (time.Duration, bool)) (int, error)
+}
+
+// Sign возвращает значение заголовка X-Webhook-Signature
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись запроса и что он отправлен не раньше чем tolerance назад
+func Verify(secret string, timestamp int64, body []byte, signature string, tolerance time.Duration) bool {
+	if time.Since(time.Unix(timestamp, 0)).Abs() > tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+type Dispatcher struct {
+	Store       Store
+	Client      *http.Client
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	Now         func() time.Time
+}
+
+func NewDispatcher(store Store) Dispatcher {
+	return Dispatcher{
+		Store:       store,
+		Client:      &http.Client{Timeout: 5 * time.Second},
+		Interval:    time.Second,
+		BatchSize:   50,
+		MaxAttempts: 10,
+		MinBackoff:  10 * time.Second,
+		MaxBackoff:  time.Hour,
+		Now:         time.Now,
+	}
+}
+
+// Retry решает, повторять ли доставку после attempts неудачных попыток и через сколько.
+// После MaxAttempts попыток доставка уходит в dead.
+func (dispatcher Dispatcher) Retry(attempts int) (time.Duration, bool) {
+	if attempts >= dispatcher.MaxAttempts {
+		return 0, false
+	}
+
+	delay := dispatcher.MinBackoff
+	for i := 1; i < attempts && delay < dispatcher.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, dispatcher.MaxBackoff), true
+}
+
+// Send отправляет одну доставку и возвращает код ответа. Ответ не 2xx считается ошибкой.
+func (dispatcher Dispatcher) Send(ctx context.Context, delivery datastorage.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := dispatcher.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, body))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.Id, 10))
+
+	resp, err := dispatcher.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (dispatcher Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	return dispatcher.Store.DeliverWebhooks(dispatcher.BatchSize, func(delivery datastorage.WebhookDelivery) (int, error) {
+		code, err := dispatcher.Send(ctx, delivery)
+		if err != nil {
+			log.Println("webhook delivery", delivery.Id, "failed:", err)
+		}
+		return code, err
+	}, dispatcher.Retry)
+}
+
+// Run отправляет вебхуки, пока не отменён ctx
+func (dispatcher Dispatcher) Run(ctx context.Context) {
+	log.Println("webhook dispatcher started")
+
+	for {
+		sent, err := dispatcher.RunOnce(ctx)
+		if err != nil {
+			log.Println("webhook dispatcher error:", err)
+		}
+
+		if err == nil && sent == dispatcher.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("webhook dispatcher stopped")
+			return
+		case <-time.After(dispatcher.Interval):
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	datastorage "walletGolang/dataStorage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	deliveries []datastorage.WebhookDelivery
+	codes      []int
+	retries    []bool
+}
+
+func (store *fakeStore) DeliverWebhooks(limit int, send func(delivery datastorage.WebhookDelivery) (int, error), retry func(attempts int) (time.Duration, bool)) (int, error) {
+	for _, delivery := range store.deliveries {
+		code, err := send(delivery)
+		store.codes = append(store.codes, code)
+		if err != nil {
+			_, again := retry(delivery.Attempts + 1)
+			store.retries = append(store.retries, again)
+		}
+	}
+	return len(store.deliveries), nil
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	now := time.Now().Unix()
+
+	signature := Sign("secret", now, body)
+
+	assert.True(t, Verify("secret", now, body, signature, time.Minute))
+	assert.False(t, Verify("other", now, body, signature, time.Minute))
+	assert.False(t, Verify("secret", now, []byte(`{"id":2}`), signature, time.Minute))
+	assert.False(t, Verify("secret", now-3600, body, Sign("secret", now-3600, body), time.Minute))
+}
+
+func TestSignKnownValue(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac key
+	assert.Equal(t,
+		"sha256=9d713ed406bb7076d4123f0dc2c39d2df5c654ed4b0cd56b52c8b4c940bd63ae",
+		Sign("key", 1700000000, []byte("{}")))
+}
+
+func TestRetry(t *testing.T) {
+	dispatcher := Dispatcher{MaxAttempts: 4, MinBackoff: time.Second, MaxBackoff: 3 * time.Second}
+
+	delay, again := dispatcher.Retry(1)
+	assert.True(t, again)
+	assert.Equal(t, time.Second, delay)
+
+	delay, again = dispatcher.Retry(3)
+	assert.True(t, again)
+	assert.Equal(t, 3*time.Second, delay)
+
+	_, again = dispatcher.Retry(4)
+	assert.False(t, again)
+}
+
+func TestDispatcherSignsRequests(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var body []byte
+	var headers http.Header
+
+	partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		headers = r.Header
+		if r.Header.Get(HeaderDelivery) == "2" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer partner.Close()
+
+	store := &fakeStore{deliveries: []datastorage.WebhookDelivery{
+		{Id: 1, URL: partner.URL, Secret: "secret", Event: datastorage.Event{Id: 10, WalletId: "a", Type: datastorage.EventDeposited, Amount: 5, Balance: 5}},
+		{Id: 2, URL: partner.URL, Secret: "secret", Attempts: 9, Event: datastorage.Event{Id: 11}},
+	}}
+
+	dispatcher := NewDispatcher(store)
+	dispatcher.Now = func() time.Time { return now }
+
+	sent, err := dispatcher.RunOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []int{http.StatusOK, http.StatusInternalServerError}, store.codes)
+	assert.Equal(t, []bool{false}, store.retries)
+
+	timestamp, _ := strconv.ParseInt(headers.Get(HeaderTimestamp), 10, 64)
+	assert.Equal(t, now.Unix(), timestamp)
+	assert.Equal(t, Sign("secret", timestamp, body), headers.Get(HeaderSignature))
+	assert.Equal(t, "application/json", headers.Get("Content-Type"))
+}